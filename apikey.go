@@ -0,0 +1,307 @@
+package pensiondata
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Scopes granted to the API keys, ScopeAdmin grants every other scope
+const (
+	ScopeQuotesWrite = "quotes:write"
+	ScopeFundsWrite  = "funds:write"
+	ScopeAdmin       = "admin"
+)
+
+// apiKeyScopes are the scopes that can be granted to an API key
+var apiKeyScopes = map[string]bool{ScopeQuotesWrite: true, ScopeFundsWrite: true, ScopeAdmin: true}
+
+// apiKeyPrefix starts every API key, followed by the public prefix identifying the key and its secret
+const apiKeyPrefix = "pd_"
+
+// LegacyScraperAPIKey is the API key of the requests sending the shared scraper key of the previous versions,
+// only granted the scope of the scraper: writing quotes
+var LegacyScraperAPIKey = APIKey{
+	Name:   "legacy scraper",
+	Prefix: "legacy",
+	Scopes: []string{ScopeQuotesWrite},
+	Plan:   RateLimitPlanStandard,
+}
+
+// lastUsedPrecision is the precision of the last time an API key was used, it is written at most once per period
+const lastUsedPrecision = time.Minute
+
+// APIKey is APIKey's representation in the database.
+// Only the SHA-256 hash of the key is stored, Prefix is the public part of the key used to find it.
+// Zero ExpiresAt, LastUsedAt and RevokedAt mean never. Plan is the name of the RateLimitPlan of the key.
+type APIKey struct {
+	ID         int64
+	Name       string
+	Prefix     string
+	Hash       string
+	Scopes     []string
+	Plan       string
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	LastUsedAt time.Time
+	RevokedAt  time.Time
+}
+
+// APIKeyRepository handle the data access operations on APIKey
+type APIKeyRepository interface {
+	FindByPrefix(context.Context, string) (APIKey, error)
+	FindAll(context.Context) ([]APIKey, error)
+	Create(context.Context, APIKey) (APIKey, error)
+	Revoke(context.Context, int64) error
+	UpdateLastUsed(context.Context, int64, time.Time) error
+}
+
+// APIKeyService handle the use cases for APIKey
+type APIKeyService interface {
+	Authenticate(context.Context, string) (APIKey, error)
+	GetAPIKeys(context.Context) ([]PublicAPIKey, error)
+	CreateAPIKey(context.Context, AdminCreateAPIKey) (PublicCreatedAPIKey, error)
+	RevokeAPIKey(context.Context, int64) error
+}
+
+// APIKeyServiceImpl is the implementation of APIKeyService
+type APIKeyServiceImpl struct {
+	repo             APIKeyRepository
+	legacyScraperKey string
+	now              func() time.Time
+}
+
+// NewAPIKeyService return a new, fully functional, implementation of APIKeyService
+func NewAPIKeyService(repo APIKeyRepository) *APIKeyServiceImpl {
+	return &APIKeyServiceImpl{repo: repo, now: time.Now}
+}
+
+// AllowLegacyScraperKey accept the shared scraper key of the previous versions as LegacyScraperAPIKey,
+// during the transition of the scraper to its own API key. An empty key is never accepted.
+func (s *APIKeyServiceImpl) AllowLegacyScraperKey(key string) {
+	s.legacyScraperKey = key
+}
+
+// Authenticate return the API key matching the given key, or LegacyScraperAPIKey for the legacy scraper key.
+// ErrInvalidAPIKey is returned when the key is unknown, revoked or expired.
+func (s APIKeyServiceImpl) Authenticate(ctx context.Context, key string) (APIKey, error) {
+	if s.legacyScraperKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(s.legacyScraperKey)) == 1 {
+		return LegacyScraperAPIKey, nil
+	}
+
+	prefix, ok := parseAPIKeyPrefix(key)
+	if !ok {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+
+	apiKey, err := s.repo.FindByPrefix(ctx, prefix)
+	if err != nil {
+		if err == ErrAPIKeyNotFound {
+			return APIKey{}, ErrInvalidAPIKey
+		}
+		return APIKey{}, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashAPIKey(key)), []byte(apiKey.Hash)) != 1 {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+
+	now := s.now()
+	if !apiKey.RevokedAt.IsZero() || (!apiKey.ExpiresAt.IsZero() && !now.Before(apiKey.ExpiresAt)) {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+
+	if now.Sub(apiKey.LastUsedAt) >= lastUsedPrecision {
+		if err := s.repo.UpdateLastUsed(ctx, apiKey.ID, now); err != nil {
+			return APIKey{}, err
+		}
+		apiKey.LastUsedAt = now
+	}
+
+	return apiKey, nil
+}
+
+// GetAPIKeys return all API keys, without their secret
+func (s APIKeyServiceImpl) GetAPIKeys(ctx context.Context) ([]PublicAPIKey, error) {
+	apiKeys, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return []PublicAPIKey{}, err
+	}
+
+	publicAPIKeys := []PublicAPIKey{}
+	for _, apiKey := range apiKeys {
+		publicAPIKeys = append(publicAPIKeys, newPublicAPIKey(apiKey))
+	}
+
+	return publicAPIKeys, nil
+}
+
+// CreateAPIKey return the newly created API key along with the key itself, which cannot be retrieved afterwards
+func (s APIKeyServiceImpl) CreateAPIKey(ctx context.Context, adminAPIKey AdminCreateAPIKey) (PublicCreatedAPIKey, error) {
+	apiKey, err := adminAPIKey.toAPIKey(s.now())
+	if err != nil {
+		return PublicCreatedAPIKey{}, err
+	}
+
+	key, err := generateAPIKey()
+	if err != nil {
+		return PublicCreatedAPIKey{}, err
+	}
+	apiKey.Prefix, _ = parseAPIKeyPrefix(key)
+	apiKey.Hash = hashAPIKey(key)
+
+	createdAPIKey, err := s.repo.Create(ctx, apiKey)
+	if err != nil {
+		return PublicCreatedAPIKey{}, err
+	}
+
+	return PublicCreatedAPIKey{PublicAPIKey: newPublicAPIKey(createdAPIKey), Key: key}, nil
+}
+
+// RevokeAPIKey revoke the API key for the given id, it cannot be used anymore
+func (s APIKeyServiceImpl) RevokeAPIKey(ctx context.Context, id int64) error {
+	return s.repo.Revoke(ctx, id)
+}
+
+// HasScope return true when the API key has been granted the given scope, directly or through ScopeAdmin
+func (k APIKey) HasScope(scope string) bool {
+	for _, granted := range k.Scopes {
+		if granted == scope || granted == ScopeAdmin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Identity return the name and the prefix identifying the API key in logs and audit trails
+func (k APIKey) Identity() string {
+	return fmt.Sprintf("%s (%s)", k.Name, k.Prefix)
+}
+
+// generateAPIKey return a new random API key made of a public prefix and a secret
+func generateAPIKey() (string, error) {
+	prefix := make([]byte, 4)
+	if _, err := rand.Read(prefix); err != nil {
+		return "", err
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return apiKeyPrefix + hex.EncodeToString(prefix) + "_" + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// parseAPIKeyPrefix return the public prefix of the given API key
+func parseAPIKeyPrefix(key string) (string, bool) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return "", false
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(key, apiKeyPrefix), "_", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", false
+	}
+
+	return parts[0], true
+}
+
+// hashAPIKey return the hex encoded SHA-256 hash of the API key.
+// Keys are random and long enough for a fast hash to be safe, unlike passwords.
+func hashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// AdminCreateAPIKey is APIKey's representation send by an admin to be created.
+// ExpiresAt is optional and Plan is RateLimitPlanStandard by default.
+type AdminCreateAPIKey struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	Plan      string   `json:"plan"`
+	ExpiresAt string   `json:"expires_at"`
+}
+
+// toAPIKey return the APIKey to create, or ErrInvalidAPIKeyParameters when a parameter is invalid
+func (a AdminCreateAPIKey) toAPIKey(now time.Time) (APIKey, error) {
+	apiKey := APIKey{Name: strings.TrimSpace(a.Name)}
+	if apiKey.Name == "" {
+		return APIKey{}, fmt.Errorf("%w: the name is required", ErrInvalidAPIKeyParameters)
+	}
+
+	if len(a.Scopes) == 0 {
+		return APIKey{}, fmt.Errorf("%w: at least one scope is required", ErrInvalidAPIKeyParameters)
+	}
+	for _, scope := range a.Scopes {
+		if !apiKeyScopes[scope] {
+			return APIKey{}, fmt.Errorf("%w: the scope %s is unknown", ErrInvalidAPIKeyParameters, scope)
+		}
+	}
+	apiKey.Scopes = a.Scopes
+
+	apiKey.Plan = strings.TrimSpace(a.Plan)
+	if apiKey.Plan == "" {
+		apiKey.Plan = RateLimitPlanStandard
+	}
+
+	if a.ExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, a.ExpiresAt)
+		if err != nil || !expiresAt.After(now) {
+			return APIKey{}, fmt.Errorf("%w: the expiry must be a future RFC 3339 date", ErrInvalidAPIKeyParameters)
+		}
+		apiKey.ExpiresAt = expiresAt
+	}
+
+	return apiKey, nil
+}
+
+// PublicAPIKey is APIKey's representation to be returned by the API, without the key itself
+type PublicAPIKey struct {
+	ID         int64    `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	Plan       string   `json:"plan"`
+	CreatedAt  string   `json:"created_at"`
+	ExpiresAt  string   `json:"expires_at,omitempty"`
+	LastUsedAt string   `json:"last_used_at,omitempty"`
+	RevokedAt  string   `json:"revoked_at,omitempty"`
+}
+
+// PublicCreatedAPIKey is a newly created API key along with the key, only returned once
+type PublicCreatedAPIKey struct {
+	PublicAPIKey
+	Key string `json:"key"`
+}
+
+// newPublicAPIKey return a PublicAPIKey based on an APIKey
+func newPublicAPIKey(apiKey APIKey) PublicAPIKey {
+	publicAPIKey := PublicAPIKey{
+		ID:        apiKey.ID,
+		Name:      apiKey.Name,
+		Prefix:    apiKey.Prefix,
+		Scopes:    apiKey.Scopes,
+		Plan:      apiKey.Plan,
+		CreatedAt: apiKey.CreatedAt.Format(time.RFC3339),
+	}
+
+	if !apiKey.ExpiresAt.IsZero() {
+		publicAPIKey.ExpiresAt = apiKey.ExpiresAt.Format(time.RFC3339)
+	}
+	if !apiKey.LastUsedAt.IsZero() {
+		publicAPIKey.LastUsedAt = apiKey.LastUsedAt.Format(time.RFC3339)
+	}
+	if !apiKey.RevokedAt.IsZero() {
+		publicAPIKey.RevokedAt = apiKey.RevokedAt.Format(time.RFC3339)
+	}
+
+	return publicAPIKey
+}
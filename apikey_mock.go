@@ -0,0 +1,68 @@
+package pensiondata
+
+import (
+	"context"
+	"time"
+)
+
+// APIKeyRepositoryMock used for tests
+type APIKeyRepositoryMock struct {
+	FindByPrefixFn   func(context.Context, string) (APIKey, error)
+	FindAllFn        func(context.Context) ([]APIKey, error)
+	CreateFn         func(context.Context, APIKey) (APIKey, error)
+	RevokeFn         func(context.Context, int64) error
+	UpdateLastUsedFn func(context.Context, int64, time.Time) error
+}
+
+// APIKeyServiceMock used for tests
+type APIKeyServiceMock struct {
+	AuthenticateFn func(context.Context, string) (APIKey, error)
+	GetAPIKeysFn   func(context.Context) ([]PublicAPIKey, error)
+	CreateAPIKeyFn func(context.Context, AdminCreateAPIKey) (PublicCreatedAPIKey, error)
+	RevokeAPIKeyFn func(context.Context, int64) error
+}
+
+// FindByPrefix mock
+func (r APIKeyRepositoryMock) FindByPrefix(ctx context.Context, prefix string) (APIKey, error) {
+	return r.FindByPrefixFn(ctx, prefix)
+}
+
+// FindAll mock
+func (r APIKeyRepositoryMock) FindAll(ctx context.Context) ([]APIKey, error) {
+	return r.FindAllFn(ctx)
+}
+
+// Create mock
+func (r APIKeyRepositoryMock) Create(ctx context.Context, apiKey APIKey) (APIKey, error) {
+	return r.CreateFn(ctx, apiKey)
+}
+
+// Revoke mock
+func (r APIKeyRepositoryMock) Revoke(ctx context.Context, id int64) error {
+	return r.RevokeFn(ctx, id)
+}
+
+// UpdateLastUsed mock
+func (r APIKeyRepositoryMock) UpdateLastUsed(ctx context.Context, id int64, lastUsedAt time.Time) error {
+	return r.UpdateLastUsedFn(ctx, id, lastUsedAt)
+}
+
+// Authenticate mock
+func (s APIKeyServiceMock) Authenticate(ctx context.Context, key string) (APIKey, error) {
+	return s.AuthenticateFn(ctx, key)
+}
+
+// GetAPIKeys mock
+func (s APIKeyServiceMock) GetAPIKeys(ctx context.Context) ([]PublicAPIKey, error) {
+	return s.GetAPIKeysFn(ctx)
+}
+
+// CreateAPIKey mock
+func (s APIKeyServiceMock) CreateAPIKey(ctx context.Context, adminAPIKey AdminCreateAPIKey) (PublicCreatedAPIKey, error) {
+	return s.CreateAPIKeyFn(ctx, adminAPIKey)
+}
+
+// RevokeAPIKey mock
+func (s APIKeyServiceMock) RevokeAPIKey(ctx context.Context, id int64) error {
+	return s.RevokeAPIKeyFn(ctx, id)
+}
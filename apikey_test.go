@@ -0,0 +1,166 @@
+package pensiondata
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testAPIKeyService(apiKey APIKey, updateLastUsedFn func(context.Context, int64, time.Time) error) *APIKeyServiceImpl {
+	repo := APIKeyRepositoryMock{}
+	repo.FindByPrefixFn = func(ctx context.Context, prefix string) (APIKey, error) {
+		if prefix != apiKey.Prefix {
+			return APIKey{}, ErrAPIKeyNotFound
+		}
+		return apiKey, nil
+	}
+	repo.UpdateLastUsedFn = updateLastUsedFn
+
+	s := NewAPIKeyService(repo)
+	s.now = func() time.Time {
+		return time.Date(2020, time.July, 15, 9, 0, 0, 0, time.UTC)
+	}
+
+	return s
+}
+
+func TestAuthenticate(t *testing.T) {
+	key := "pd_0a1b2c3d_s3cr3t"
+	now := time.Date(2020, time.July, 15, 9, 0, 0, 0, time.UTC)
+	valid := APIKey{ID: 1, Name: "scraper", Prefix: "0a1b2c3d", Hash: hashAPIKey(key), Scopes: []string{ScopeQuotesWrite}}
+
+	t.Run("return API key and record its use", func(t *testing.T) {
+		var gotLastUsed time.Time
+		s := testAPIKeyService(valid, func(ctx context.Context, id int64, lastUsedAt time.Time) error {
+			gotLastUsed = lastUsedAt
+			return nil
+		})
+
+		got, err := s.Authenticate(context.Background(), key)
+
+		if err != nil || got.ID != valid.ID {
+			t.Errorf("want API key %d, got %v (%v)", valid.ID, got, err)
+		}
+		if !gotLastUsed.Equal(now) {
+			t.Errorf("want last use recorded at %s, got %s", now, gotLastUsed)
+		}
+	})
+
+	t.Run("do not record use more than once per minute", func(t *testing.T) {
+		recent := valid
+		recent.LastUsedAt = now.Add(-30 * time.Second)
+		s := testAPIKeyService(recent, func(ctx context.Context, id int64, lastUsedAt time.Time) error {
+			return errors.New("unexpected update")
+		})
+
+		if _, err := s.Authenticate(context.Background(), key); err != nil {
+			t.Errorf("want no error, got %s", err)
+		}
+	})
+
+	revoked := valid
+	revoked.RevokedAt = now.Add(-time.Hour)
+	expired := valid
+	expired.ExpiresAt = now
+
+	tests := []struct {
+		name   string
+		apiKey APIKey
+		key    string
+	}{
+		{name: "return error for malformed key", apiKey: valid, key: "s3cr3t"},
+		{name: "return error for unknown prefix", apiKey: valid, key: "pd_ffffffff_s3cr3t"},
+		{name: "return error for wrong secret", apiKey: valid, key: "pd_0a1b2c3d_wrong"},
+		{name: "return error for revoked key", apiKey: revoked, key: key},
+		{name: "return error for expired key", apiKey: expired, key: key},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := testAPIKeyService(tt.apiKey, nil).Authenticate(context.Background(), tt.key)
+
+			if err != ErrInvalidAPIKey {
+				t.Errorf("want %s, got %v", ErrInvalidAPIKey, err)
+			}
+		})
+	}
+
+	t.Run("return legacy scraper API key for the legacy scraper key", func(t *testing.T) {
+		s := testAPIKeyService(valid, nil)
+		s.AllowLegacyScraperKey("s3cr3t")
+
+		got, err := s.Authenticate(context.Background(), "s3cr3t")
+
+		if err != nil || got.Identity() != LegacyScraperAPIKey.Identity() {
+			t.Errorf("want %v, got %v (%v)", LegacyScraperAPIKey, got, err)
+		}
+		if !reflect.DeepEqual([]string{ScopeQuotesWrite}, got.Scopes) || got.HasScope(ScopeFundsWrite) || got.HasScope(ScopeAdmin) {
+			t.Errorf("want only scope %s, got %v", ScopeQuotesWrite, got.Scopes)
+		}
+	})
+
+	t.Run("return error for empty key without legacy scraper key", func(t *testing.T) {
+		if _, err := testAPIKeyService(valid, nil).Authenticate(context.Background(), ""); err != ErrInvalidAPIKey {
+			t.Errorf("want %s, got %v", ErrInvalidAPIKey, err)
+		}
+	})
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	t.Run("return created API key with a key matching its hash", func(t *testing.T) {
+		var stored APIKey
+		repo := APIKeyRepositoryMock{}
+		repo.CreateFn = func(ctx context.Context, apiKey APIKey) (APIKey, error) {
+			stored = apiKey
+			apiKey.ID = 1
+			return apiKey, nil
+		}
+
+		got, err := NewAPIKeyService(repo).CreateAPIKey(context.Background(), AdminCreateAPIKey{Name: "scraper", Scopes: []string{ScopeQuotesWrite}})
+		if err != nil {
+			t.Fatalf("want no error, got %s", err)
+		}
+
+		prefix, ok := parseAPIKeyPrefix(got.Key)
+		if !ok || prefix != got.Prefix {
+			t.Errorf("want key with prefix %s, got %s", got.Prefix, got.Key)
+		}
+		if stored.Hash != hashAPIKey(got.Key) || stored.Hash == got.Key {
+			t.Errorf("want only the hash of the key stored, got %s", stored.Hash)
+		}
+	})
+
+	tests := []struct {
+		name   string
+		apiKey AdminCreateAPIKey
+	}{
+		{name: "return error without name", apiKey: AdminCreateAPIKey{Scopes: []string{ScopeAdmin}}},
+		{name: "return error without scope", apiKey: AdminCreateAPIKey{Name: "scraper"}},
+		{name: "return error for unknown scope", apiKey: AdminCreateAPIKey{Name: "scraper", Scopes: []string{"quotes:read"}}},
+		{name: "return error for past expiry", apiKey: AdminCreateAPIKey{Name: "scraper", Scopes: []string{ScopeAdmin}, ExpiresAt: "2020-01-01T00:00:00Z"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAPIKeyService(APIKeyRepositoryMock{}).CreateAPIKey(context.Background(), tt.apiKey)
+
+			if !errors.Is(err, ErrInvalidAPIKeyParameters) {
+				t.Errorf("want %s, got %v", ErrInvalidAPIKeyParameters, err)
+			}
+		})
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	scraper := APIKey{Scopes: []string{ScopeQuotesWrite}}
+	admin := APIKey{Scopes: []string{ScopeAdmin}}
+
+	if !scraper.HasScope(ScopeQuotesWrite) || scraper.HasScope(ScopeFundsWrite) || scraper.HasScope(ScopeAdmin) {
+		t.Errorf("want only the %s scope, got %v", ScopeQuotesWrite, scraper.Scopes)
+	}
+	if !admin.HasScope(ScopeQuotesWrite) || !admin.HasScope(ScopeFundsWrite) {
+		t.Errorf("want every scope for admin")
+	}
+}
@@ -0,0 +1,278 @@
+package pensiondata
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// maxBacktestMonths is the maximum number of months a backtest can cover
+const maxBacktestMonths = 600
+
+// unitsPrecision is the number of decimal places kept for fund units
+const unitsPrecision = 8
+
+// amountPrecision is the number of decimal places of the amounts in euros
+const amountPrecision = 2
+
+// BacktestRequest are the parameters of a monthly savings plan backtest.
+// From and To are the first days of the first and last months of the plan.
+type BacktestRequest struct {
+	Monthly decimal.Decimal
+	From    time.Time
+	To      time.Time
+}
+
+// Backtest is the result of a monthly savings plan simulated over the quotes of a fund
+type Backtest struct {
+	Isin        string
+	Monthly     decimal.Decimal
+	Purchases   []BacktestPurchase
+	Invested    decimal.Decimal
+	Units       decimal.Decimal
+	ValueDate   time.Time
+	MarketValue decimal.Decimal
+	XIRR        *float64
+}
+
+// BacktestPurchase is a monthly purchase of a Backtest with the state of the plan right after it
+type BacktestPurchase struct {
+	Date        time.Time
+	Price       decimal.Decimal
+	Units       decimal.Decimal
+	Invested    decimal.Decimal
+	TotalUnits  decimal.Decimal
+	MarketValue decimal.Decimal
+}
+
+// BacktestService handle the use cases for savings plan backtests
+type BacktestService interface {
+	Backtest(context.Context, string, BacktestRequest) (PublicBacktest, error)
+}
+
+// BacktestServiceImpl is the implementation of BacktestService
+type BacktestServiceImpl struct {
+	fundRepo  FundRepository
+	quoteRepo QuoteRepository
+}
+
+// NewBacktestService return a new, fully functional, implementation of BacktestService
+func NewBacktestService(fundRepo FundRepository, quoteRepo QuoteRepository) *BacktestServiceImpl {
+	return &BacktestServiceImpl{fundRepo: fundRepo, quoteRepo: quoteRepo}
+}
+
+// Backtest return the result of investing the monthly amount in the fund for the given isin
+func (s BacktestServiceImpl) Backtest(ctx context.Context, isin string, request BacktestRequest) (PublicBacktest, error) {
+	if err := request.validate(); err != nil {
+		return PublicBacktest{}, err
+	}
+
+	fund, err := s.fundRepo.FindByISIN(ctx, isin)
+	if err != nil {
+		return PublicBacktest{}, err
+	}
+
+	quotes, err := s.quoteRepo.FindByCriteria(ctx, isin, QuoteCriteria{
+		From:  request.From,
+		To:    request.To.AddDate(0, 1, -1),
+		Order: OrderAsc,
+	})
+	if err != nil {
+		return PublicBacktest{}, err
+	}
+
+	backtest, err := computeBacktest(request, quotes)
+	if err != nil {
+		return PublicBacktest{}, err
+	}
+	backtest.Isin = isin
+
+	return newPublicBacktest(backtest, fund.PricePrecision), nil
+}
+
+// computeBacktest buy units at the first available quote of every month and value them at the last quote.
+// Months without quotes are skipped.
+func computeBacktest(request BacktestRequest, quotes []Quote) (Backtest, error) {
+	sorted := sortQuotesAsc(quotes)
+	if len(sorted) == 0 {
+		return Backtest{}, ErrQuoteNotFound
+	}
+
+	backtest := Backtest{Monthly: request.Monthly}
+	var cashFlows []cashFlow
+
+	i := 0
+	for month := request.From; !month.After(request.To); month = month.AddDate(0, 1, 0) {
+		nextMonth := month.AddDate(0, 1, 0)
+		for i < len(sorted) && truncateToDay(sorted[i].Date).Before(month) {
+			i++
+		}
+		if i == len(sorted) || !truncateToDay(sorted[i].Date).Before(nextMonth) || !sorted[i].Price.IsPositive() {
+			continue
+		}
+
+		quote := sorted[i]
+		units := request.Monthly.DivRound(quote.Price, unitsPrecision)
+		backtest.Invested = backtest.Invested.Add(request.Monthly)
+		backtest.Units = backtest.Units.Add(units)
+		backtest.Purchases = append(backtest.Purchases, BacktestPurchase{
+			Date:        truncateToDay(quote.Date),
+			Price:       quote.Price,
+			Units:       units,
+			Invested:    backtest.Invested,
+			TotalUnits:  backtest.Units,
+			MarketValue: backtest.Units.Mul(quote.Price).Round(amountPrecision),
+		})
+
+		amount, _ := request.Monthly.Float64()
+		cashFlows = append(cashFlows, cashFlow{date: truncateToDay(quote.Date), amount: -amount})
+	}
+
+	if len(backtest.Purchases) == 0 {
+		return Backtest{}, ErrQuoteNotFound
+	}
+
+	last := sorted[len(sorted)-1]
+	backtest.ValueDate = truncateToDay(last.Date)
+	backtest.MarketValue = backtest.Units.Mul(last.Price).Round(amountPrecision)
+
+	marketValue, _ := backtest.MarketValue.Float64()
+	cashFlows = append(cashFlows, cashFlow{date: backtest.ValueDate, amount: marketValue})
+	if rate, ok := xirr(cashFlows); ok {
+		rate = roundFloat(rate, returnPrecision)
+		backtest.XIRR = &rate
+	}
+
+	return backtest, nil
+}
+
+// cashFlow is an amount paid (negative) or received (positive) at a date
+type cashFlow struct {
+	date   time.Time
+	amount float64
+}
+
+// xirr return the annual money-weighted rate of return of the cash flows, found by bisection.
+// It returns false when the cash flows cover less than a day or do not change sign.
+func xirr(cashFlows []cashFlow) (float64, bool) {
+	if len(cashFlows) < 2 || !cashFlows[len(cashFlows)-1].date.After(cashFlows[0].date) {
+		return 0, false
+	}
+
+	npv := func(rate float64) float64 {
+		var total float64
+		for _, cf := range cashFlows {
+			years := cf.date.Sub(cashFlows[0].date).Hours() / 24 / daysPerYear
+			total += cf.amount / math.Pow(1+rate, years)
+		}
+		return total
+	}
+
+	low, high := -0.9999, 100.0
+	if npv(low)*npv(high) > 0 {
+		return 0, false
+	}
+
+	for i := 0; i < 200; i++ {
+		mid := (low + high) / 2
+		if npv(low)*npv(mid) <= 0 {
+			high = mid
+		} else {
+			low = mid
+		}
+	}
+
+	return (low + high) / 2, true
+}
+
+// validate return an error wrapping ErrInvalidBacktest if the request cannot be backtested
+func (r BacktestRequest) validate() error {
+	if !r.Monthly.IsPositive() {
+		return fmt.Errorf("%w: the monthly amount must be positive", ErrInvalidBacktest)
+	}
+
+	if r.From.IsZero() || r.To.IsZero() || r.To.Before(r.From) {
+		return fmt.Errorf("%w: the from month must be before the to month", ErrInvalidBacktest)
+	}
+
+	if r.From.AddDate(0, maxBacktestMonths, 0).Before(r.To) {
+		return fmt.Errorf("%w: a backtest cannot cover more than %d months", ErrInvalidBacktest, maxBacktestMonths)
+	}
+
+	return nil
+}
+
+// PublicBacktest is Backtest's representation to be returned by the API.
+// The amounts and units are exact decimals with the precision of euros and units.
+type PublicBacktest struct {
+	Isin        string                   `json:"isin"`
+	Monthly     Amount                   `json:"monthly"`
+	Invested    Amount                   `json:"invested"`
+	Units       Amount                   `json:"units"`
+	ValueDate   string                   `json:"value_date"`
+	MarketValue Amount                   `json:"market_value"`
+	XIRR        *Number                  `json:"xirr"`
+	Purchases   []PublicBacktestPurchase `json:"purchases"`
+}
+
+// PublicBacktestPurchase is BacktestPurchase's representation to be returned by the API
+type PublicBacktestPurchase struct {
+	Date        string `json:"date"`
+	Price       Price  `json:"price"`
+	Units       Amount `json:"units"`
+	Invested    Amount `json:"invested"`
+	TotalUnits  Amount `json:"total_units"`
+	MarketValue Amount `json:"market_value"`
+}
+
+// ExactDecimals return the backtest with its prices, amounts and computed values written as exact decimal strings
+func (b PublicBacktest) ExactDecimals() PublicBacktest {
+	b.Monthly.Exact = true
+	b.Invested.Exact = true
+	b.Units.Exact = true
+	b.MarketValue.Exact = true
+	b.XIRR = exactNumber(b.XIRR)
+
+	purchases := make([]PublicBacktestPurchase, len(b.Purchases))
+	for i, purchase := range b.Purchases {
+		purchase.Price.Exact = true
+		purchase.Units.Exact = true
+		purchase.Invested.Exact = true
+		purchase.TotalUnits.Exact = true
+		purchase.MarketValue.Exact = true
+		purchases[i] = purchase
+	}
+	b.Purchases = purchases
+
+	return b
+}
+
+// newPublicBacktest return a PublicBacktest based on a Backtest of a fund with the given price precision
+func newPublicBacktest(backtest Backtest, precision int32) PublicBacktest {
+	publicBacktest := PublicBacktest{
+		Isin:        backtest.Isin,
+		Monthly:     NewAmount(backtest.Monthly, amountPrecision),
+		Invested:    NewAmount(backtest.Invested, amountPrecision),
+		Units:       NewAmount(backtest.Units, unitsPrecision),
+		ValueDate:   backtest.ValueDate.Format("2006-01-02"),
+		MarketValue: NewAmount(backtest.MarketValue, amountPrecision),
+		XIRR:        newOptionalNumber(backtest.XIRR),
+		Purchases:   []PublicBacktestPurchase{},
+	}
+
+	for _, purchase := range backtest.Purchases {
+		publicBacktest.Purchases = append(publicBacktest.Purchases, PublicBacktestPurchase{
+			Date:        purchase.Date.Format("2006-01-02"),
+			Price:       NewPrice(purchase.Price, precision),
+			Units:       NewAmount(purchase.Units, unitsPrecision),
+			Invested:    NewAmount(purchase.Invested, amountPrecision),
+			TotalUnits:  NewAmount(purchase.TotalUnits, unitsPrecision),
+			MarketValue: NewAmount(purchase.MarketValue, amountPrecision),
+		})
+	}
+
+	return publicBacktest
+}
@@ -0,0 +1,13 @@
+package pensiondata
+
+import "context"
+
+// BacktestServiceMock used for tests
+type BacktestServiceMock struct {
+	BacktestFn func(context.Context, string, BacktestRequest) (PublicBacktest, error)
+}
+
+// Backtest mock
+func (s BacktestServiceMock) Backtest(ctx context.Context, isin string, request BacktestRequest) (PublicBacktest, error) {
+	return s.BacktestFn(ctx, isin, request)
+}
@@ -0,0 +1,127 @@
+package pensiondata
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestBacktest(t *testing.T) {
+	t.Run("return backtest successfully", func(t *testing.T) {
+		fundRepo := FundRepositoryMock{}
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return Fund{Isin: isin}, nil
+		}
+
+		var gotCriteria QuoteCriteria
+		quoteRepo := QuoteRepositoryMock{}
+		quoteRepo.FindByCriteriaFn = func(ctx context.Context, isin string, criteria QuoteCriteria) ([]Quote, error) {
+			gotCriteria = criteria
+			return testBacktestQuotes(), nil
+		}
+
+		s := NewBacktestService(fundRepo, quoteRepo)
+		got, err := s.Backtest(context.Background(), "BE123", testBacktestRequest())
+		if err != nil {
+			t.Fatalf("want no error, got %s", err)
+		}
+
+		if gotCriteria.To.Format("2006-01-02") != "2020-03-31" || gotCriteria.Order != OrderAsc {
+			t.Errorf("want quotes until 2020-03-31 asc, got until %s %s", gotCriteria.To.Format("2006-01-02"), gotCriteria.Order)
+		}
+		if len(got.Purchases) != 3 {
+			t.Fatalf("want %d, got %d", 3, len(got.Purchases))
+		}
+		if got.Purchases[0].Date != "2020-01-02" || got.Purchases[0].Units.String() != "2.00000000" {
+			t.Errorf("want 2.00000000 units on 2020-01-02, got %s on %s", got.Purchases[0].Units, got.Purchases[0].Date)
+		}
+		if got.Invested.String() != "300.00" || got.Units.String() != "4.25000000" || got.MarketValue.String() != "340.00" {
+			t.Errorf("want 300.00 invested, 4.25000000 units, 340.00 value, got %s, %s, %s", got.Invested, got.Units, got.MarketValue)
+		}
+		if got.XIRR == nil || !got.XIRR.Value.IsPositive() {
+			t.Errorf("want positive xirr, got %v", got.XIRR)
+		}
+	})
+
+	t.Run("return error for invalid request", func(t *testing.T) {
+		s := NewBacktestService(FundRepositoryMock{}, QuoteRepositoryMock{})
+
+		request := testBacktestRequest()
+		request.Monthly = decimal.NewFromInt(-100)
+		if _, err := s.Backtest(context.Background(), "BE123", request); !errors.Is(err, ErrInvalidBacktest) {
+			t.Errorf("want %s, got %v", ErrInvalidBacktest, err)
+		}
+
+		request = testBacktestRequest()
+		request.From, request.To = request.To, request.From
+		if _, err := s.Backtest(context.Background(), "BE123", request); !errors.Is(err, ErrInvalidBacktest) {
+			t.Errorf("want %s, got %v", ErrInvalidBacktest, err)
+		}
+	})
+
+	t.Run("return error when there are no quotes", func(t *testing.T) {
+		fundRepo := FundRepositoryMock{}
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return Fund{Isin: isin}, nil
+		}
+
+		quoteRepo := QuoteRepositoryMock{}
+		quoteRepo.FindByCriteriaFn = func(ctx context.Context, isin string, criteria QuoteCriteria) ([]Quote, error) {
+			return []Quote{}, nil
+		}
+
+		s := NewBacktestService(fundRepo, quoteRepo)
+		_, err := s.Backtest(context.Background(), "BE123", testBacktestRequest())
+
+		if err != ErrQuoteNotFound {
+			t.Errorf("want %s, got %v", ErrQuoteNotFound, err)
+		}
+	})
+}
+
+func TestXIRR(t *testing.T) {
+	t.Run("return the annual rate of a single investment", func(t *testing.T) {
+		start, _ := time.Parse("2006-01-02", "2019-01-01")
+		end, _ := time.Parse("2006-01-02", "2020-01-01")
+
+		got, ok := xirr([]cashFlow{{date: start, amount: -100}, {date: end, amount: 110}})
+		want := math.Pow(1.1, daysPerYear/365) - 1
+
+		if !ok || math.Abs(got-want) > 1e-9 {
+			t.Errorf("want %f, got %f", want, got)
+		}
+	})
+
+	t.Run("return false when cash flows do not change sign", func(t *testing.T) {
+		start, _ := time.Parse("2006-01-02", "2019-01-01")
+
+		if _, ok := xirr([]cashFlow{{date: start, amount: -100}, {date: start.AddDate(1, 0, 0), amount: -10}}); ok {
+			t.Errorf("want no rate")
+		}
+	})
+}
+
+func testBacktestRequest() BacktestRequest {
+	from, _ := time.Parse("2006-01", "2020-01")
+	to, _ := time.Parse("2006-01", "2020-03")
+
+	return BacktestRequest{Monthly: decimal.NewFromInt(100), From: from, To: to}
+}
+
+func testBacktestQuotes() []Quote {
+	quote := func(date string, price float64) Quote {
+		d, _ := time.Parse("2006-01-02", date)
+		return Quote{Date: d, Price: decimal.NewFromFloat(price)}
+	}
+
+	return []Quote{
+		quote("2020-03-31", 80),
+		quote("2020-02-03", 100),
+		quote("2020-01-15", 60),
+		quote("2020-01-02", 50),
+	}
+}
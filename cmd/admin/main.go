@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/obawi/pensiondata-api/postgres"
+
+	_ "github.com/lib/pq"
+	"github.com/obawi/pensiondata-api"
+)
+
+// Exit codes of the commands, exitIssues is used by the checks when they find issues
+const (
+	exitIssues = 1
+	exitError  = 2
+)
+
+const usage = `Usage: admin <command> [flags]
+
+Commands:
+  data-quality  report stale funds and gaps in the quotes, exit with 1 when issues are found
+  api-key       manage the API keys: create -name <name> -scopes <scopes> [-plan <plan>] [-expires-at <date>], list, revoke <id>
+`
+
+func main() {
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(exitError)
+	}
+
+	switch os.Args[1] {
+	case "data-quality":
+		os.Exit(dataQuality(os.Args[2:]))
+	case "api-key":
+		os.Exit(apiKey(os.Args[2:]))
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(exitError)
+	}
+}
+
+// dataQuality print the data quality report as JSON and return the exit code of the command
+func dataQuality(args []string) int {
+	flags := flag.NewFlagSet("data-quality", flag.ContinueOnError)
+	from := flags.String("from", "", "search gaps in the quotes since this day (YYYY-MM-DD), 90 days ago by default")
+	staleAfter := flags.Int("stale-after", pensiondata.DefaultDataQualityStaleAfter, "business days after which a late fund is stale")
+	gapTolerance := flags.Int("gap-tolerance", pensiondata.DefaultDataQualityGapTolerance, "missing business days tolerated in the quotes")
+	if err := flags.Parse(args); err != nil {
+		return exitError
+	}
+
+	criteria := pensiondata.DataQualityCriteria{StaleAfter: *staleAfter, GapTolerance: *gapTolerance}
+	if *from != "" {
+		var err error
+		if criteria.From, err = time.Parse("2006-01-02", *from); err != nil {
+			log.Printf("The from date %s must use the YYYY-MM-DD format", *from)
+			return exitError
+		}
+	}
+
+	db, err := postgres.NewConnection()
+	if err != nil {
+		log.Print(err)
+		return exitError
+	}
+	defer db.Close()
+
+	service := pensiondata.NewDataQualityService(postgres.NewFundRepository(db), postgres.NewQuoteRepository(db))
+	report, err := service.GetDataQualityReport(context.Background(), criteria)
+	if err != nil {
+		log.Printf("Error while getting data quality report: %s", err)
+		return exitError
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Print(err)
+		return exitError
+	}
+
+	if report.HasIssues {
+		return exitIssues
+	}
+
+	return 0
+}
+
+// apiKey run the API key subcommand and return the exit code of the command.
+// It creates the first admin key, before any key can be used on the admin routes.
+func apiKey(args []string) int {
+	if len(args) < 1 {
+		fmt.Fprint(os.Stderr, usage)
+		return exitError
+	}
+
+	db, err := postgres.NewConnection()
+	if err != nil {
+		log.Print(err)
+		return exitError
+	}
+	defer db.Close()
+
+	service := pensiondata.NewAPIKeyService(postgres.NewAPIKeyRepository(db))
+
+	var result interface{}
+	switch args[0] {
+	case "create":
+		flags := flag.NewFlagSet("api-key create", flag.ContinueOnError)
+		name := flags.String("name", "", "name of the client using the key")
+		scopes := flags.String("scopes", "", "comma separated scopes granted to the key: quotes:write, funds:write, admin")
+		plan := flags.String("plan", pensiondata.RateLimitPlanStandard, "rate limit plan of the key")
+		expiresAt := flags.String("expires-at", "", "expiry of the key (RFC 3339), never by default")
+		if err := flags.Parse(args[1:]); err != nil {
+			return exitError
+		}
+
+		createAPIKey := pensiondata.AdminCreateAPIKey{Name: *name, Plan: *plan, ExpiresAt: *expiresAt}
+		for _, scope := range strings.Split(*scopes, ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				createAPIKey.Scopes = append(createAPIKey.Scopes, scope)
+			}
+		}
+
+		if result, err = service.CreateAPIKey(context.Background(), createAPIKey); err != nil {
+			log.Printf("Error while creating API key: %s", err)
+			return exitError
+		}
+	case "list":
+		if result, err = service.GetAPIKeys(context.Background()); err != nil {
+			log.Printf("Error while listing API keys: %s", err)
+			return exitError
+		}
+	case "revoke":
+		if len(args) != 2 {
+			fmt.Fprint(os.Stderr, usage)
+			return exitError
+		}
+
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			log.Printf("The id %s must be a number", args[1])
+			return exitError
+		}
+
+		if err := service.RevokeAPIKey(context.Background(), id); err != nil {
+			log.Printf("Error while revoking API key: %s", err)
+			return exitError
+		}
+		log.Printf("API key %d revoked", id)
+		return 0
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return exitError
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		log.Print(err)
+		return exitError
+	}
+
+	return 0
+}
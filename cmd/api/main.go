@@ -1,17 +1,29 @@
 package main
 
 import (
+	"context"
 	"log"
+	nethttp "net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/obawi/pensiondata-api/http"
+	"github.com/obawi/pensiondata-api/memory"
 	"github.com/obawi/pensiondata-api/postgres"
 
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
 	"github.com/obawi/pensiondata-api"
+	"github.com/shopspring/decimal"
 )
 
+// shutdownTimeout is the time given to the ongoing requests to complete when the API is stopped
+const shutdownTimeout = 30 * time.Second
+
 func main() {
 	db, err := postgres.NewConnection()
 	if err != nil {
@@ -19,19 +31,158 @@ func main() {
 	}
 	defer db.Close()
 
-	router := gin.Default()
+	// The pending migrations are applied before serving when MIGRATE_ON_STARTUP is set, the instances starting
+	// together wait for the first one to apply them
+	if len(os.Getenv("MIGRATE_ON_STARTUP")) != 0 {
+		migrateOnStartup, err := strconv.ParseBool(os.Getenv("MIGRATE_ON_STARTUP"))
+		if err != nil {
+			log.Fatal(err)
+		}
+		if migrateOnStartup {
+			applied, err := postgres.NewMigrator(db).Up(context.Background())
+			if err != nil {
+				log.Fatal(err)
+			}
+			log.Printf("%d migrations applied", len(applied))
+		}
+	}
+
+	router := gin.New()
+	router.Use(gin.Logger(), http.Recovery())
+
+	// TRUSTED_PROXIES are the comma separated IPs and CIDRs of the reverse proxies in front of the API,
+	// whose X-Forwarded-For gives the client IP. No proxy is trusted when empty.
+	var trustedProxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trustedProxies = append(trustedProxies, proxy)
+		}
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatal(err)
+	}
+	router.NoRoute(http.RouteNotFound())
+
+	apiKeyService := pensiondata.NewAPIKeyService(postgres.NewAPIKeyRepository(db))
+	// SCRAPER_KEY is the shared key of the scraper before API keys, accepted until the scraper uses its own API key
+	if len(os.Getenv("SCRAPER_KEY")) != 0 {
+		log.Print("SCRAPER_KEY is deprecated, create an API key for the scraper with the admin api-key command")
+		apiKeyService.AllowLegacyScraperKey(os.Getenv("SCRAPER_KEY"))
+	}
+
+	rateLimitPlans, err := pensiondata.ParseRateLimitPlans(os.Getenv("RATE_LIMIT_PLANS"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	rateLimitService := pensiondata.NewRateLimitService(memory.NewRateLimitStore(), rateLimitPlans)
+	router.Use(http.Authenticate(apiKeyService, rateLimitService))
+
+	usageRepo := postgres.NewUsageRepository(db)
+	usageRecorder := pensiondata.NewUsageRecorder(usageRepo, pensiondata.DefaultUsageFlushInterval,
+		pensiondata.DefaultUsageBufferSize)
+	defer usageRecorder.Close()
+	router.Use(http.MeterUsage(usageRecorder))
+	router.Use(http.RateLimit(rateLimitService))
+
+	usageService := pensiondata.NewUsageService(usageRepo)
 
 	fundRepo := postgres.NewFundRepository(db)
 	fundService := pensiondata.NewFundService(fundRepo)
-	http.InitFundHandler(router, fundService)
+
+	maxPriceChange := decimal.NewFromFloat(0.2)
+	if len(os.Getenv("QUOTE_MAX_PRICE_CHANGE")) != 0 {
+		if maxPriceChange, err = decimal.NewFromString(os.Getenv("QUOTE_MAX_PRICE_CHANGE")); err != nil {
+			log.Fatal(err)
+		}
+	}
 
 	quoteRepo := postgres.NewQuoteRepository(db)
-	quoteService := pensiondata.NewQuoteService(fundRepo, quoteRepo)
-	http.InitQuoteHandler(router, quoteService)
+	quarantineRepo := postgres.NewQuarantineRepository(db)
+	validatedQuoteRepo := pensiondata.NewValidatedQuoteRepository(quoteRepo, fundRepo, quarantineRepo,
+		pensiondata.NewQuoteValidator(maxPriceChange))
+	quoteService := pensiondata.NewQuoteService(fundRepo, validatedQuoteRepo)
 
+	quarantineService := pensiondata.NewQuarantineService(quarantineRepo, quoteRepo)
+	dataQualityService := pensiondata.NewDataQualityService(fundRepo, quoteRepo)
+	performanceService := pensiondata.NewPerformanceService(fundRepo, quoteRepo)
+
+	riskFreeRate := 0.0
+	if len(os.Getenv("RISK_FREE_RATE")) != 0 {
+		if riskFreeRate, err = strconv.ParseFloat(os.Getenv("RISK_FREE_RATE"), 64); err != nil {
+			log.Fatal(err)
+		}
+	}
+	riskService := pensiondata.NewRiskService(fundRepo, quoteRepo, riskFreeRate)
+
+	taxParametersRepo := postgres.NewTaxParametersRepository(db)
+	simulationService := pensiondata.NewSimulationService(fundRepo, quoteRepo, taxParametersRepo)
+	backtestService := pensiondata.NewBacktestService(fundRepo, quoteRepo)
+	compareService := pensiondata.NewCompareService(fundRepo, quoteRepo)
+
+	routeTimeouts, err := http.ParseRouteTimeouts(os.Getenv("ROUTE_TIMEOUTS"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// The routes at the root are aliases of /v1, announced as deprecated once ROOT_ROUTES_DEPRECATED_AT is set
+	rootMiddlewares := []gin.HandlerFunc{http.Version(http.V1), http.Timeout("/", routeTimeouts)}
+	if len(os.Getenv("ROOT_ROUTES_DEPRECATED_AT")) != 0 {
+		deprecation := http.Deprecation{Successor: "/v1"}
+		if deprecation.At, err = time.Parse("2006-01-02", os.Getenv("ROOT_ROUTES_DEPRECATED_AT")); err != nil {
+			log.Fatal(err)
+		}
+		if len(os.Getenv("ROOT_ROUTES_SUNSET")) != 0 {
+			if deprecation.Sunset, err = time.Parse("2006-01-02", os.Getenv("ROOT_ROUTES_SUNSET")); err != nil {
+				log.Fatal(err)
+			}
+		}
+		rootMiddlewares = append(rootMiddlewares, http.Deprecated("/", deprecation))
+	}
+
+	v1 := router.Group("/v1", http.Version(http.V1), http.Timeout("/v1", routeTimeouts))
+	services := http.Services{
+		APIKey:      apiKeyService,
+		Usage:       usageService,
+		Fund:        fundService,
+		Quote:       quoteService,
+		Quarantine:  quarantineService,
+		DataQuality: dataQualityService,
+		Performance: performanceService,
+		Risk:        riskService,
+		Simulation:  simulationService,
+		Backtest:    backtestService,
+		Compare:     compareService,
+	}
+	for _, group := range []*gin.RouterGroup{v1, router.Group("/", rootMiddlewares...)} {
+		http.InitRoutes(group, services)
+	}
+
+	addr := ":8080"
+	if len(os.Getenv("PORT")) != 0 {
+		addr = ":" + os.Getenv("PORT")
+	}
 	if len(os.Getenv("ALWAYSDATA_HTTPD_IP")) != 0 && len(os.Getenv("ALWAYSDATA_HTTPD_PORT")) != 0 {
-		router.Run(os.Getenv("ALWAYSDATA_HTTPD_IP") + ":" + os.Getenv("ALWAYSDATA_HTTPD_PORT"))
+		addr = os.Getenv("ALWAYSDATA_HTTPD_IP") + ":" + os.Getenv("ALWAYSDATA_HTTPD_PORT")
 	}
 
-	router.Run()
+	server := &nethttp.Server{Addr: addr, Handler: router}
+	go func() {
+		log.Printf("Listening and serving HTTP on %s", addr)
+		if err := server.ListenAndServe(); err != nil && err != nethttp.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	// On SIGINT or SIGTERM the server stops accepting requests and waits for the ongoing ones, then main returns
+	// so that the deferred Close of the usage recorder flushes the last records before the database is closed
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	<-signals
+
+	log.Print("Shutting down")
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Error while shutting down: %s", err)
+	}
 }
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/obawi/pensiondata-api/postgres"
+
+	_ "github.com/lib/pq"
+)
+
+const exitError = 1
+
+const usage = `Usage: migrate <command> [flags]
+
+Commands:
+  up      apply the pending migrations
+  down    revert the latest applied migration, or the latest ones with -steps <n>
+  status  list the migrations with the time they were applied
+`
+
+func main() {
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(exitError)
+	}
+
+	switch os.Args[1] {
+	case "up", "down", "status":
+		os.Exit(migrate(os.Args[1], os.Args[2:]))
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(exitError)
+	}
+}
+
+// migrate run the migration command and return the exit code of the command
+func migrate(command string, args []string) int {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	steps := flags.Int("steps", 1, "number of migrations to revert with down")
+	if err := flags.Parse(args); err != nil {
+		return exitError
+	}
+
+	db, err := postgres.NewConnection()
+	if err != nil {
+		log.Print(err)
+		return exitError
+	}
+	defer db.Close()
+
+	migrator := postgres.NewMigrator(db)
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			log.Print(err)
+			return exitError
+		}
+		for _, migration := range applied {
+			log.Printf("Applied migration %d_%s", migration.Version, migration.Name)
+		}
+		log.Printf("%d migrations applied", len(applied))
+	case "down":
+		reverted, err := migrator.Down(ctx, *steps)
+		if err != nil {
+			log.Print(err)
+			return exitError
+		}
+		for _, migration := range reverted {
+			log.Printf("Reverted migration %d_%s", migration.Version, migration.Name)
+		}
+		log.Printf("%d migrations reverted", len(reverted))
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Print(err)
+			return exitError
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if !status.AppliedAt.IsZero() {
+				appliedAt = status.AppliedAt.UTC().Format(time.RFC3339)
+			}
+			fmt.Fprintf(writer, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		if err := writer.Flush(); err != nil {
+			log.Print(err)
+			return exitError
+		}
+	}
+
+	return 0
+}
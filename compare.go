@@ -0,0 +1,236 @@
+package pensiondata
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// MaxComparedFunds is the maximum number of funds that can be compared at once
+const MaxComparedFunds = 10
+
+// rebaseValue is the value of every series at the start date of a comparison
+var rebaseValue = decimal.NewFromInt(100)
+
+// rebasePrecision is the number of decimal places kept for rebased values
+const rebasePrecision = 4
+
+// Comparison is the price series of several funds aligned on their common dates and rebased to 100
+type Comparison struct {
+	Dates       []time.Time
+	Series      []ComparisonSeries
+	Performance []Performance
+	Errors      []ComparisonError
+}
+
+// ComparisonSeries is the rebased series of a fund, Values are aligned with the Comparison dates
+type ComparisonSeries struct {
+	Isin   string
+	Name   string
+	Values []decimal.Decimal
+}
+
+// ComparisonError is a fund that could not be compared, such as an unknown isin
+type ComparisonError struct {
+	Isin    string
+	Message string
+}
+
+// CompareService handle the use cases for comparing funds
+type CompareService interface {
+	Compare(context.Context, []string, time.Time) (PublicComparison, error)
+}
+
+// CompareServiceImpl is the implementation of CompareService
+type CompareServiceImpl struct {
+	fundRepo  FundRepository
+	quoteRepo QuoteRepository
+}
+
+// NewCompareService return a new, fully functional, implementation of CompareService
+func NewCompareService(fundRepo FundRepository, quoteRepo QuoteRepository) *CompareServiceImpl {
+	return &CompareServiceImpl{fundRepo: fundRepo, quoteRepo: quoteRepo}
+}
+
+// Compare return the comparison of the funds for the given isins from the given date.
+// Unknown funds and funds without quotes are reported in the errors of the comparison.
+func (s CompareServiceImpl) Compare(ctx context.Context, isins []string, from time.Time) (PublicComparison, error) {
+	if len(isins) == 0 || len(isins) > MaxComparedFunds {
+		return PublicComparison{}, fmt.Errorf("%w: between 1 and %d funds can be compared", ErrInvalidComparison, MaxComparedFunds)
+	}
+
+	var comparison Comparison
+	var funds []Fund
+	var histories [][]Quote
+	seen := make(map[string]bool)
+
+	for _, isin := range isins {
+		if seen[isin] {
+			continue
+		}
+		seen[isin] = true
+
+		fund, err := s.fundRepo.FindByISIN(ctx, isin)
+		if err == ErrFundNotFound {
+			comparison.Errors = append(comparison.Errors, ComparisonError{Isin: isin, Message: "The fund was not found"})
+			continue
+		} else if err != nil {
+			return PublicComparison{}, err
+		}
+
+		quotes, err := s.quoteRepo.FindAll(ctx, isin)
+		if err != nil {
+			return PublicComparison{}, err
+		}
+		if len(quotes) == 0 {
+			comparison.Errors = append(comparison.Errors, ComparisonError{Isin: isin, Message: "No quotes are available for the fund"})
+			continue
+		}
+
+		funds = append(funds, fund)
+		histories = append(histories, sortQuotesAsc(quotes))
+		comparison.Performance = append(comparison.Performance, computePerformance(fund, quotes))
+	}
+
+	comparison.Dates, comparison.Series = rebaseOnCommonDates(funds, histories, truncateToDay(from))
+
+	return newPublicComparison(comparison), nil
+}
+
+// rebaseOnCommonDates return the days on or after from where every fund has a quote, and the series of
+// every fund rebased to 100 on the first of these days. Histories must be sorted by date asc.
+func rebaseOnCommonDates(funds []Fund, histories [][]Quote, from time.Time) ([]time.Time, []ComparisonSeries) {
+	var dates []time.Time
+	series := make([]ComparisonSeries, len(funds))
+	for i, fund := range funds {
+		series[i] = ComparisonSeries{Isin: fund.Isin, Name: fund.Name, Values: []decimal.Decimal{}}
+	}
+
+	if len(funds) == 0 {
+		return dates, series
+	}
+
+	prices := make([]map[time.Time]decimal.Decimal, len(histories))
+	for i, quotes := range histories {
+		prices[i] = make(map[time.Time]decimal.Decimal, len(quotes))
+		for _, quote := range quotes {
+			prices[i][truncateToDay(quote.Date)] = quote.Price
+		}
+	}
+
+	bases := make([]decimal.Decimal, len(funds))
+	for _, quote := range histories[0] {
+		day := truncateToDay(quote.Date)
+		if day.Before(from) {
+			continue
+		}
+
+		common := true
+		for i := range prices {
+			if price, ok := prices[i][day]; !ok || !price.IsPositive() {
+				common = false
+				break
+			}
+		}
+		if !common {
+			continue
+		}
+
+		if len(dates) > 0 && dates[len(dates)-1].Equal(day) {
+			continue
+		}
+
+		for i := range series {
+			if len(dates) == 0 {
+				bases[i] = prices[i][day]
+			}
+			value := prices[i][day].Mul(rebaseValue).DivRound(bases[i], rebasePrecision)
+			series[i].Values = append(series[i].Values, value)
+		}
+		dates = append(dates, day)
+	}
+
+	return dates, series
+}
+
+// PublicComparison is Comparison's representation to be returned by the API
+type PublicComparison struct {
+	StartDate   string                   `json:"start_date"`
+	Dates       []string                 `json:"dates"`
+	Series      []PublicComparisonSeries `json:"series"`
+	Performance []PublicPerformance      `json:"performance"`
+	Errors      []PublicComparisonError  `json:"errors"`
+}
+
+// PublicComparisonSeries is ComparisonSeries's representation to be returned by the API
+type PublicComparisonSeries struct {
+	Isin   string   `json:"isin"`
+	Name   string   `json:"name"`
+	Values []Number `json:"values"`
+}
+
+// PublicComparisonError is ComparisonError's representation to be returned by the API
+type PublicComparisonError struct {
+	Isin    string `json:"isin"`
+	Message string `json:"message"`
+}
+
+// ExactDecimals return the comparison with its values and returns written as exact decimal strings
+func (c PublicComparison) ExactDecimals() PublicComparison {
+	series := make([]PublicComparisonSeries, len(c.Series))
+	for i, publicSeries := range c.Series {
+		values := make([]Number, len(publicSeries.Values))
+		for j, value := range publicSeries.Values {
+			value.Exact = true
+			values[j] = value
+		}
+		publicSeries.Values = values
+		series[i] = publicSeries
+	}
+	c.Series = series
+
+	performance := make([]PublicPerformance, len(c.Performance))
+	for i, publicPerformance := range c.Performance {
+		performance[i] = publicPerformance.ExactDecimals()
+	}
+	c.Performance = performance
+
+	return c
+}
+
+// newPublicComparison return a PublicComparison based on a Comparison
+func newPublicComparison(comparison Comparison) PublicComparison {
+	publicComparison := PublicComparison{
+		Dates:       []string{},
+		Series:      []PublicComparisonSeries{},
+		Performance: []PublicPerformance{},
+		Errors:      []PublicComparisonError{},
+	}
+
+	for _, date := range comparison.Dates {
+		publicComparison.Dates = append(publicComparison.Dates, date.Format("2006-01-02"))
+	}
+	if len(publicComparison.Dates) > 0 {
+		publicComparison.StartDate = publicComparison.Dates[0]
+	}
+
+	for _, series := range comparison.Series {
+		publicSeries := PublicComparisonSeries{Isin: series.Isin, Name: series.Name, Values: []Number{}}
+		for _, value := range series.Values {
+			publicSeries.Values = append(publicSeries.Values, NewNumber(value))
+		}
+		publicComparison.Series = append(publicComparison.Series, publicSeries)
+	}
+
+	for _, performance := range comparison.Performance {
+		publicComparison.Performance = append(publicComparison.Performance, newPublicPerformance(performance))
+	}
+
+	for _, comparisonError := range comparison.Errors {
+		publicComparison.Errors = append(publicComparison.Errors, PublicComparisonError(comparisonError))
+	}
+
+	return publicComparison
+}
@@ -0,0 +1,16 @@
+package pensiondata
+
+import (
+	"context"
+	"time"
+)
+
+// CompareServiceMock used for tests
+type CompareServiceMock struct {
+	CompareFn func(context.Context, []string, time.Time) (PublicComparison, error)
+}
+
+// Compare mock
+func (s CompareServiceMock) Compare(ctx context.Context, isins []string, from time.Time) (PublicComparison, error) {
+	return s.CompareFn(ctx, isins, from)
+}
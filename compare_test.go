@@ -0,0 +1,117 @@
+package pensiondata
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCompare(t *testing.T) {
+	t.Run("return rebased series on common dates", func(t *testing.T) {
+		s := NewCompareService(testCompareFundRepo(), testCompareQuoteRepo())
+		got, err := s.Compare(context.Background(), []string{"BE123", "LU123", "BE123"}, time.Time{})
+		if err != nil {
+			t.Fatalf("want no error, got %s", err)
+		}
+
+		wantDates := []string{"2020-01-02", "2020-01-03"}
+		if len(got.Dates) != len(wantDates) || got.Dates[0] != wantDates[0] || got.Dates[1] != wantDates[1] {
+			t.Fatalf("want %v, got %v", wantDates, got.Dates)
+		}
+		if got.StartDate != "2020-01-02" {
+			t.Errorf("want %s, got %s", "2020-01-02", got.StartDate)
+		}
+		if len(got.Series) != 2 {
+			t.Fatalf("want %d, got %d", 2, len(got.Series))
+		}
+		if got.Series[0].Values[0].Float64() != 100 || got.Series[0].Values[1].Float64() != 109.0909 {
+			t.Errorf("want [100 109.0909], got %v", got.Series[0].Values)
+		}
+		if got.Series[1].Values[0].Float64() != 100 || got.Series[1].Values[1].Float64() != 110 {
+			t.Errorf("want [100 110], got %v", got.Series[1].Values)
+		}
+		if len(got.Performance) != 2 {
+			t.Errorf("want %d, got %d", 2, len(got.Performance))
+		}
+	})
+
+	t.Run("report unknown funds individually", func(t *testing.T) {
+		s := NewCompareService(testCompareFundRepo(), testCompareQuoteRepo())
+		got, err := s.Compare(context.Background(), []string{"BE123", "XX000"}, time.Time{})
+		if err != nil {
+			t.Fatalf("want no error, got %s", err)
+		}
+
+		if len(got.Series) != 1 {
+			t.Errorf("want %d, got %d", 1, len(got.Series))
+		}
+		if len(got.Errors) != 1 || got.Errors[0].Isin != "XX000" {
+			t.Errorf("want error for XX000, got %v", got.Errors)
+		}
+	})
+
+	t.Run("start on the from date", func(t *testing.T) {
+		from, _ := time.Parse("2006-01-02", "2020-01-03")
+
+		s := NewCompareService(testCompareFundRepo(), testCompareQuoteRepo())
+		got, _ := s.Compare(context.Background(), []string{"BE123", "LU123"}, from)
+
+		if len(got.Dates) != 1 || got.Series[0].Values[0].Float64() != 100 {
+			t.Errorf("want a single date rebased to 100, got %v and %v", got.Dates, got.Series)
+		}
+	})
+
+	t.Run("return error for invalid number of funds", func(t *testing.T) {
+		s := NewCompareService(testCompareFundRepo(), testCompareQuoteRepo())
+
+		if _, err := s.Compare(context.Background(), []string{}, time.Time{}); !errors.Is(err, ErrInvalidComparison) {
+			t.Errorf("want %s, got %v", ErrInvalidComparison, err)
+		}
+	})
+
+	t.Run("return error for fund", func(t *testing.T) {
+		fundRepo := FundRepositoryMock{}
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return Fund{}, errors.New("error")
+		}
+
+		s := NewCompareService(fundRepo, testCompareQuoteRepo())
+		_, err := s.Compare(context.Background(), []string{"BE123"}, time.Time{})
+
+		if err == nil {
+			t.Errorf("want error")
+		}
+	})
+}
+
+func testCompareFundRepo() FundRepositoryMock {
+	fundRepo := FundRepositoryMock{}
+	fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+		if isin == "XX000" {
+			return Fund{}, ErrFundNotFound
+		}
+		return Fund{Isin: isin, Name: isin + " Fund"}, nil
+	}
+
+	return fundRepo
+}
+
+func testCompareQuoteRepo() QuoteRepositoryMock {
+	quote := func(date string, price float64) Quote {
+		d, _ := time.Parse("2006-01-02", date)
+		return Quote{Date: d, Price: decimal.NewFromFloat(price)}
+	}
+
+	quoteRepo := QuoteRepositoryMock{}
+	quoteRepo.FindAllFn = func(ctx context.Context, isin string) ([]Quote, error) {
+		if isin == "LU123" {
+			return []Quote{quote("2020-01-04", 60), quote("2020-01-03", 55), quote("2020-01-02", 50)}, nil
+		}
+		return []Quote{quote("2020-01-03", 120), quote("2020-01-02", 110), quote("2020-01-01", 100)}, nil
+	}
+
+	return quoteRepo
+}
@@ -0,0 +1,165 @@
+package pensiondata
+
+import (
+	"context"
+	"time"
+)
+
+// Default parameters of the data quality report
+const (
+	DefaultDataQualityDays         = 90
+	DefaultDataQualityStaleAfter   = 3
+	DefaultDataQualityGapTolerance = 1
+)
+
+// pricingIntervals is the number of business days expected between two quotes for each pricing frequency
+var pricingIntervals = map[string]int{
+	PricingFrequencyDaily:   1,
+	PricingFrequencyWeekly:  5,
+	PricingFrequencyMonthly: 23,
+}
+
+// DataQualityCriteria are the parameters of the data quality report.
+// Gaps are searched in the quotes since From. A fund is stale when its latest quote is more than StaleAfter
+// business days late and a gap is reported when more than GapTolerance business days are missing,
+// which tolerates bank holidays.
+type DataQualityCriteria struct {
+	From         time.Time
+	StaleAfter   int
+	GapTolerance int
+}
+
+// DataQualityService handle the use cases for the quality of the quotes
+type DataQualityService interface {
+	GetDataQualityReport(context.Context, DataQualityCriteria) (PublicDataQualityReport, error)
+}
+
+// DataQualityServiceImpl is the implementation of DataQualityService
+type DataQualityServiceImpl struct {
+	fundRepo  FundRepository
+	quoteRepo QuoteRepository
+	now       func() time.Time
+}
+
+// NewDataQualityService return a new, fully functional, implementation of DataQualityService
+func NewDataQualityService(fundRepo FundRepository, quoteRepo QuoteRepository) *DataQualityServiceImpl {
+	return &DataQualityServiceImpl{fundRepo: fundRepo, quoteRepo: quoteRepo, now: time.Now}
+}
+
+// GetDataQualityReport return the stale funds and the gaps in the quotes of every fund
+func (s DataQualityServiceImpl) GetDataQualityReport(ctx context.Context, criteria DataQualityCriteria) (PublicDataQualityReport, error) {
+	today := truncateToDay(s.now().UTC())
+	if criteria.From.IsZero() {
+		criteria.From = today.AddDate(0, 0, -DefaultDataQualityDays)
+	}
+
+	if criteria.From.After(today) || criteria.StaleAfter < 0 || criteria.GapTolerance < 0 {
+		return PublicDataQualityReport{}, ErrInvalidCriteria
+	}
+
+	funds, err := s.fundRepo.FindAll(ctx)
+	if err != nil {
+		return PublicDataQualityReport{}, err
+	}
+
+	report := PublicDataQualityReport{
+		AsOf:       today.Format("2006-01-02"),
+		StaleFunds: []PublicStaleFund{},
+		Gaps:       []PublicQuoteGap{},
+	}
+
+	for _, fund := range funds {
+		interval := pricingInterval(fund)
+
+		latest, err := s.quoteRepo.FindByDateDesc(ctx, fund.Isin)
+		if err != nil && err != ErrQuoteNotFound {
+			return PublicDataQualityReport{}, err
+		}
+
+		if err == ErrQuoteNotFound {
+			report.StaleFunds = append(report.StaleFunds, PublicStaleFund{Isin: fund.Isin, PricingFrequency: fund.PricingFrequency})
+			continue
+		}
+
+		// The next quote is expected interval business days after the latest one
+		late := businessDaysBetween(truncateToDay(latest.Date), today.AddDate(0, 0, 1)) - interval
+		if late > criteria.StaleAfter {
+			report.StaleFunds = append(report.StaleFunds, PublicStaleFund{
+				Isin:             fund.Isin,
+				PricingFrequency: fund.PricingFrequency,
+				LatestQuote:      truncateToDay(latest.Date).Format("2006-01-02"),
+				BusinessDaysLate: late,
+			})
+		}
+
+		quotes, err := s.quoteRepo.FindByCriteria(ctx, fund.Isin, QuoteCriteria{From: criteria.From, To: today, Order: OrderAsc})
+		if err != nil {
+			return PublicDataQualityReport{}, err
+		}
+
+		for i := 1; i < len(quotes); i++ {
+			from, to := truncateToDay(quotes[i-1].Date), truncateToDay(quotes[i].Date)
+			missing := businessDaysBetween(from, to) - (interval - 1)
+			if missing > criteria.GapTolerance {
+				report.Gaps = append(report.Gaps, PublicQuoteGap{
+					Isin:                fund.Isin,
+					PricingFrequency:    fund.PricingFrequency,
+					From:                from.Format("2006-01-02"),
+					To:                  to.Format("2006-01-02"),
+					MissingBusinessDays: missing,
+				})
+			}
+		}
+	}
+
+	report.HasIssues = len(report.StaleFunds) > 0 || len(report.Gaps) > 0
+
+	return report, nil
+}
+
+// pricingInterval return the number of business days expected between two quotes of the fund, daily by default
+func pricingInterval(fund Fund) int {
+	if interval, ok := pricingIntervals[fund.PricingFrequency]; ok {
+		return interval
+	}
+
+	return pricingIntervals[PricingFrequencyDaily]
+}
+
+// businessDaysBetween return the number of days from Monday to Friday strictly between the from and to days
+func businessDaysBetween(from, to time.Time) int {
+	count := 0
+	for day := from.AddDate(0, 0, 1); day.Before(to); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() != time.Saturday && day.Weekday() != time.Sunday {
+			count++
+		}
+	}
+
+	return count
+}
+
+// PublicDataQualityReport is the data quality report to be returned by the API
+type PublicDataQualityReport struct {
+	AsOf       string            `json:"as_of"`
+	HasIssues  bool              `json:"has_issues"`
+	StaleFunds []PublicStaleFund `json:"stale_funds"`
+	Gaps       []PublicQuoteGap  `json:"gaps"`
+}
+
+// PublicStaleFund is a fund whose latest quote is late, LatestQuote is empty when the fund has no quote
+type PublicStaleFund struct {
+	Isin             string `json:"isin"`
+	PricingFrequency string `json:"pricing_frequency"`
+	LatestQuote      string `json:"latest_quote,omitempty"`
+	BusinessDaysLate int    `json:"business_days_late"`
+}
+
+// PublicQuoteGap is a gap between two consecutive quotes of a fund.
+// MissingBusinessDays is the number of business days between the quotes beyond the pricing interval of the fund.
+type PublicQuoteGap struct {
+	Isin                string `json:"isin"`
+	PricingFrequency    string `json:"pricing_frequency"`
+	From                string `json:"from"`
+	To                  string `json:"to"`
+	MissingBusinessDays int    `json:"missing_business_days"`
+}
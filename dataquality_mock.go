@@ -0,0 +1,13 @@
+package pensiondata
+
+import "context"
+
+// DataQualityServiceMock used for tests
+type DataQualityServiceMock struct {
+	GetDataQualityReportFn func(context.Context, DataQualityCriteria) (PublicDataQualityReport, error)
+}
+
+// GetDataQualityReport mock
+func (s DataQualityServiceMock) GetDataQualityReport(ctx context.Context, criteria DataQualityCriteria) (PublicDataQualityReport, error) {
+	return s.GetDataQualityReportFn(ctx, criteria)
+}
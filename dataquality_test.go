@@ -0,0 +1,135 @@
+package pensiondata
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testDataQualityService(funds []Fund, quotes map[string][]Quote) *DataQualityServiceImpl {
+	fundRepo := FundRepositoryMock{}
+	fundRepo.FindAllFn = func(ctx context.Context) ([]Fund, error) {
+		return funds, nil
+	}
+
+	quoteRepo := QuoteRepositoryMock{}
+	quoteRepo.FindByDateDescFn = func(ctx context.Context, isin string) (Quote, error) {
+		if len(quotes[isin]) == 0 {
+			return Quote{}, ErrQuoteNotFound
+		}
+		return quotes[isin][len(quotes[isin])-1], nil
+	}
+	quoteRepo.FindByCriteriaFn = func(ctx context.Context, isin string, criteria QuoteCriteria) ([]Quote, error) {
+		return quotes[isin], nil
+	}
+
+	s := NewDataQualityService(fundRepo, quoteRepo)
+	s.now = func() time.Time {
+		// Wednesday
+		return time.Date(2020, time.July, 15, 9, 0, 0, 0, time.UTC)
+	}
+
+	return s
+}
+
+func TestGetDataQualityReport(t *testing.T) {
+	criteria := DataQualityCriteria{StaleAfter: 3, GapTolerance: 1}
+
+	t.Run("return no issues for complete and recent quotes", func(t *testing.T) {
+		funds := []Fund{
+			{Isin: "BE123", PricingFrequency: PricingFrequencyDaily},
+			{Isin: "BE456", PricingFrequency: PricingFrequencyWeekly},
+		}
+		quotes := map[string][]Quote{
+			// Friday to Tuesday with a single missing Monday
+			"BE123": {testQuote("2020-07-09", 100), testQuote("2020-07-10", 101), testQuote("2020-07-14", 102)},
+			"BE456": {testQuote("2020-06-26", 100), testQuote("2020-07-03", 101), testQuote("2020-07-10", 102)},
+		}
+
+		got, err := testDataQualityService(funds, quotes).GetDataQualityReport(context.Background(), criteria)
+
+		want := PublicDataQualityReport{AsOf: "2020-07-15", StaleFunds: []PublicStaleFund{}, Gaps: []PublicQuoteGap{}}
+		if err != nil || !reflect.DeepEqual(want, got) {
+			t.Errorf("want %v, got %v (%v)", want, got, err)
+		}
+	})
+
+	t.Run("return stale funds and gaps", func(t *testing.T) {
+		funds := []Fund{
+			{Isin: "BE123", PricingFrequency: PricingFrequencyDaily},
+			{Isin: "BE456", PricingFrequency: PricingFrequencyWeekly},
+			{Isin: "BE789", PricingFrequency: PricingFrequencyDaily},
+		}
+		quotes := map[string][]Quote{
+			"BE123": {testQuote("2020-06-26", 100), testQuote("2020-07-02", 101), testQuote("2020-07-08", 102)},
+			"BE456": {testQuote("2020-06-12", 100), testQuote("2020-07-03", 101)},
+		}
+
+		got, err := testDataQualityService(funds, quotes).GetDataQualityReport(context.Background(), criteria)
+
+		want := PublicDataQualityReport{
+			AsOf:      "2020-07-15",
+			HasIssues: true,
+			StaleFunds: []PublicStaleFund{
+				{Isin: "BE123", PricingFrequency: PricingFrequencyDaily, LatestQuote: "2020-07-08", BusinessDaysLate: 4},
+				{Isin: "BE789", PricingFrequency: PricingFrequencyDaily},
+			},
+			Gaps: []PublicQuoteGap{
+				{Isin: "BE123", PricingFrequency: PricingFrequencyDaily, From: "2020-06-26", To: "2020-07-02", MissingBusinessDays: 3},
+				{Isin: "BE123", PricingFrequency: PricingFrequencyDaily, From: "2020-07-02", To: "2020-07-08", MissingBusinessDays: 3},
+				{Isin: "BE456", PricingFrequency: PricingFrequencyWeekly, From: "2020-06-12", To: "2020-07-03", MissingBusinessDays: 10},
+			},
+		}
+		if err != nil {
+			t.Fatalf("want no error, got %s", err)
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("return error for from date in the future", func(t *testing.T) {
+		_, err := testDataQualityService(nil, nil).GetDataQualityReport(context.Background(), DataQualityCriteria{From: time.Now().AddDate(1, 0, 0)})
+
+		if err != ErrInvalidCriteria {
+			t.Errorf("want %s, got %v", ErrInvalidCriteria, err)
+		}
+	})
+
+	t.Run("return error for funds", func(t *testing.T) {
+		fundRepo := FundRepositoryMock{}
+		fundRepo.FindAllFn = func(ctx context.Context) ([]Fund, error) {
+			return nil, errors.New("error")
+		}
+
+		_, err := NewDataQualityService(fundRepo, QuoteRepositoryMock{}).GetDataQualityReport(context.Background(), criteria)
+
+		if err == nil {
+			t.Errorf("want error")
+		}
+	})
+}
+
+func TestBusinessDaysBetween(t *testing.T) {
+	friday := time.Date(2020, time.July, 10, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		to   time.Time
+		want int
+	}{
+		{name: "same day", to: friday, want: 0},
+		{name: "next business day", to: friday.AddDate(0, 0, 3), want: 0},
+		{name: "next week", to: friday.AddDate(0, 0, 7), want: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := businessDaysBetween(friday, tt.to); got != tt.want {
+				t.Errorf("want %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
@@ -7,3 +7,66 @@ var ErrFundNotFound = errors.New("found not found")
 
 // ErrQuoteNotFound is returned when a quote was not found
 var ErrQuoteNotFound = errors.New("quote not found")
+
+// ErrInvalidWindow is returned when a window is not a valid duration such as 6m, 3y or max
+var ErrInvalidWindow = errors.New("invalid window")
+
+// ErrNotEnoughQuotes is returned when a fund does not have enough quotes for a computation
+var ErrNotEnoughQuotes = errors.New("not enough quotes")
+
+// ErrInvalidCriteria is returned when filters, sort or pagination parameters are invalid
+var ErrInvalidCriteria = errors.New("invalid criteria")
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ErrInvalidSimulation is returned when the parameters of a simulation are invalid
+var ErrInvalidSimulation = errors.New("invalid simulation")
+
+// ErrTaxParametersNotFound is returned when no tax parameters are available
+var ErrTaxParametersNotFound = errors.New("tax parameters not found")
+
+// ErrInvalidBacktest is returned when the parameters of a backtest are invalid
+var ErrInvalidBacktest = errors.New("invalid backtest")
+
+// ErrInvalidComparison is returned when the parameters of a comparison are invalid
+var ErrInvalidComparison = errors.New("invalid comparison")
+
+// ErrInvalidFundDetails is returned when the details of a fund are invalid
+var ErrInvalidFundDetails = errors.New("invalid fund details")
+
+// ErrFundDetailsAlreadyExist is returned when the details of a fund already exist for a date
+var ErrFundDetailsAlreadyExist = errors.New("fund details already exist")
+
+// ErrInvalidBatch is returned when a batch of quotes is empty or too large
+var ErrInvalidBatch = errors.New("invalid batch")
+
+// ErrInvalidQuote is returned when the date or price of a quote is invalid
+var ErrInvalidQuote = errors.New("invalid quote")
+
+// ErrQuoteConflict is returned when a quote already exists for the same fund and day with a different price
+var ErrQuoteConflict = errors.New("quote conflict")
+
+// ErrQuoteQuarantined is returned when a suspicious quote is put in quarantine instead of being created
+var ErrQuoteQuarantined = errors.New("quote quarantined")
+
+// ErrQuarantinedQuoteNotFound is returned when a quarantined quote was not found
+var ErrQuarantinedQuoteNotFound = errors.New("quarantined quote not found")
+
+// ErrQuarantinedQuoteReviewed is returned when a quarantined quote has already been accepted or rejected
+var ErrQuarantinedQuoteReviewed = errors.New("quarantined quote already reviewed")
+
+// ErrInvalidQuarantineStatus is returned when the status used to list quarantined quotes is unknown
+var ErrInvalidQuarantineStatus = errors.New("invalid quarantine status")
+
+// ErrAPIKeyNotFound is returned when an API key was not found
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// ErrInvalidAPIKey is returned when an API key is unknown, revoked or expired
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// ErrInvalidAPIKeyParameters is returned when the parameters of an API key to create are invalid
+var ErrInvalidAPIKeyParameters = errors.New("invalid api key parameters")
+
+// ErrInvalidInput is returned when the parameters of a request are malformed, such as an ISIN or a date
+var ErrInvalidInput = errors.New("invalid input")
@@ -1,28 +1,91 @@
 package pensiondata
 
 import (
+	"context"
+	"fmt"
 	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Bounds of the SRI risk indicator
+const (
+	MinRiskClass = 1
+	MaxRiskClass = 7
+)
+
+// Fields funds can be sorted by
+const (
+	FundSortName       = "name"
+	FundSortBank       = "bank"
+	FundSortLaunchDate = "launch_date"
+	FundSortCurrency   = "currency"
+	FundSortRiskClass  = "risk_class"
 )
 
-// Fund is Fund's representation in the database
+// Pricing frequencies of the funds, the frequency at which their net asset value is published
+const (
+	PricingFrequencyDaily   = "daily"
+	PricingFrequencyWeekly  = "weekly"
+	PricingFrequencyMonthly = "monthly"
+)
+
+// FundCriteria are the filters and sort used to search funds. Zero values mean no filter.
+// Query is a full-text search on the name of the funds, insensitive to case and accents.
+type FundCriteria struct {
+	Bank           string
+	Currency       string
+	LaunchedBefore time.Time
+	LaunchedAfter  time.Time
+	RiskClass      int
+	Query          string
+	Sort           string
+	Order          string
+}
+
+// Fund is Fund's representation in the database.
+// PricePrecision is the number of decimals the fund declares for its net asset value.
 type Fund struct {
-	Isin       string
-	Name       string
-	Bank       string
-	LaunchDate time.Time
-	Currency   string
+	Isin             string
+	Name             string
+	Bank             string
+	LaunchDate       time.Time
+	Currency         string
+	PricingFrequency string
+	PricePrecision   int32
+	Details          FundDetails
+}
+
+// FundDetails are the characteristics of a fund that change over time, applicable from ValidFrom.
+// Fees and allocations are fractions (0.03 is 3%), RiskClass is the SRI indicator or 0 when unknown.
+type FundDetails struct {
+	ValidFrom         time.Time
+	EntryFee          decimal.NullDecimal
+	OngoingCharges    decimal.NullDecimal
+	RiskClass         int
+	EquityAllocation  decimal.NullDecimal
+	BondAllocation    decimal.NullDecimal
+	ManagementCompany string
+	Benchmark         string
 }
 
 // FundRepository handle data access operations on fund
 type FundRepository interface {
-	FindByISIN(string) (Fund, error)
-	FindAll() ([]Fund, error)
+	FindByISIN(context.Context, string) (Fund, error)
+	FindAll(context.Context) ([]Fund, error)
+	FindByCriteria(context.Context, FundCriteria) ([]Fund, error)
+	StreamByCriteria(context.Context, FundCriteria, func(Fund) error) error
+	FindDetailsHistory(context.Context, string) ([]FundDetails, error)
+	CreateDetails(context.Context, string, FundDetails) (FundDetails, error)
 }
 
 // FundService is the use cases for Fund
 type FundService interface {
-	GetFundByISIN(string) (PublicFund, error)
-	GetFunds() ([]PublicFund, error)
+	GetFundByISIN(context.Context, string) (PublicFund, error)
+	SearchFunds(context.Context, FundCriteria) ([]PublicFund, error)
+	StreamFunds(context.Context, FundCriteria, func(PublicFund) error) error
+	GetFundDetailsHistory(context.Context, string) ([]PublicFundDetails, error)
+	CreateFundDetails(context.Context, string, ScraperCreateFundDetails) (PublicFundDetails, error)
 }
 
 // FundServiceImpl is the implementation of FundService
@@ -36,8 +99,8 @@ func NewFundService(repo FundRepository) *FundServiceImpl {
 }
 
 // GetFundByISIN return the fund for the given isin
-func (s FundServiceImpl) GetFundByISIN(isin string) (PublicFund, error) {
-	fund, err := s.repo.FindByISIN(isin)
+func (s FundServiceImpl) GetFundByISIN(ctx context.Context, isin string) (PublicFund, error) {
+	fund, err := s.repo.FindByISIN(ctx, isin)
 	if err != nil {
 		return PublicFund{}, err
 	}
@@ -45,14 +108,25 @@ func (s FundServiceImpl) GetFundByISIN(isin string) (PublicFund, error) {
 	return newPublicFund(fund), nil
 }
 
-// GetFunds return all funds
-func (s FundServiceImpl) GetFunds() ([]PublicFund, error) {
-	funds, err := s.repo.FindAll()
+// SearchFunds return the funds matching the given criteria
+func (s FundServiceImpl) SearchFunds(ctx context.Context, criteria FundCriteria) ([]PublicFund, error) {
+	if err := criteria.validate(); err != nil {
+		return []PublicFund{}, err
+	}
+
+	if criteria.Sort == "" {
+		criteria.Sort = FundSortName
+	}
+	if criteria.Order == "" {
+		criteria.Order = OrderAsc
+	}
+
+	funds, err := s.repo.FindByCriteria(ctx, criteria)
 	if err != nil {
 		return []PublicFund{}, err
 	}
 
-	var publicFunds []PublicFund
+	publicFunds := []PublicFund{}
 	for _, fund := range funds {
 		publicFunds = append(publicFunds, newPublicFund(fund))
 	}
@@ -60,22 +134,215 @@ func (s FundServiceImpl) GetFunds() ([]PublicFund, error) {
 	return publicFunds, nil
 }
 
-// PublicFund is Fund's representation to be returned by the API
+// StreamFunds call fn with each fund matching the given criteria as it is read from the repository,
+// in the same order as SearchFunds. It stops at the first error returned by fn, or when ctx is done, and return it.
+func (s FundServiceImpl) StreamFunds(ctx context.Context, criteria FundCriteria, fn func(PublicFund) error) error {
+	if err := criteria.validate(); err != nil {
+		return err
+	}
+
+	if criteria.Sort == "" {
+		criteria.Sort = FundSortName
+	}
+	if criteria.Order == "" {
+		criteria.Order = OrderAsc
+	}
+
+	return s.repo.StreamByCriteria(ctx, criteria, func(fund Fund) error {
+		return fn(newPublicFund(fund))
+	})
+}
+
+// GetFundDetailsHistory return every version of the details of the fund for the given isin, latest first
+func (s FundServiceImpl) GetFundDetailsHistory(ctx context.Context, isin string) ([]PublicFundDetails, error) {
+	if _, err := s.repo.FindByISIN(ctx, isin); err != nil {
+		return []PublicFundDetails{}, err
+	}
+
+	history, err := s.repo.FindDetailsHistory(ctx, isin)
+	if err != nil {
+		return []PublicFundDetails{}, err
+	}
+
+	publicHistory := []PublicFundDetails{}
+	for _, details := range history {
+		publicHistory = append(publicHistory, newPublicFundDetails(details))
+	}
+
+	return publicHistory, nil
+}
+
+// CreateFundDetails return the created version of the details of the fund for the given isin
+func (s FundServiceImpl) CreateFundDetails(ctx context.Context, isin string, scraperDetails ScraperCreateFundDetails) (PublicFundDetails, error) {
+	details, err := scraperDetails.toFundDetails()
+	if err != nil {
+		return PublicFundDetails{}, err
+	}
+
+	if _, err := s.repo.FindByISIN(ctx, isin); err != nil {
+		return PublicFundDetails{}, err
+	}
+
+	createdDetails, err := s.repo.CreateDetails(ctx, isin, details)
+	if err != nil {
+		return PublicFundDetails{}, err
+	}
+
+	return newPublicFundDetails(createdDetails), nil
+}
+
+// validate return ErrInvalidCriteria if the criteria cannot be used to search funds
+func (c FundCriteria) validate() error {
+	switch c.Sort {
+	case "", FundSortName, FundSortBank, FundSortLaunchDate, FundSortCurrency, FundSortRiskClass:
+	default:
+		return ErrInvalidCriteria
+	}
+
+	if c.Order != "" && c.Order != OrderAsc && c.Order != OrderDesc {
+		return ErrInvalidCriteria
+	}
+
+	if c.RiskClass != 0 && (c.RiskClass < MinRiskClass || c.RiskClass > MaxRiskClass) {
+		return ErrInvalidCriteria
+	}
+
+	return nil
+}
+
+// PublicFund is Fund's representation to be returned by the API.
+// Details are the ones applicable today and are null when unknown.
 type PublicFund struct {
-	Isin       string `json:"isin"`
-	Name       string `json:"name"`
-	Bank       string `json:"bank"`
-	LaunchDate string `json:"launch_date"`
-	Currency   string `json:"currency"`
+	Isin              string   `json:"isin"`
+	Name              string   `json:"name"`
+	Bank              string   `json:"bank"`
+	LaunchDate        string   `json:"launch_date"`
+	Currency          string   `json:"currency"`
+	PricingFrequency  string   `json:"pricing_frequency"`
+	PricePrecision    int32    `json:"price_precision"`
+	EntryFee          *float64 `json:"entry_fee"`
+	OngoingCharges    *float64 `json:"ongoing_charges"`
+	RiskClass         *int     `json:"risk_class"`
+	EquityAllocation  *float64 `json:"equity_allocation"`
+	BondAllocation    *float64 `json:"bond_allocation"`
+	ManagementCompany *string  `json:"management_company"`
+	Benchmark         *string  `json:"benchmark"`
+}
+
+// PublicFundDetails is FundDetails's representation to be returned by the API
+type PublicFundDetails struct {
+	ValidFrom         string   `json:"valid_from"`
+	EntryFee          *float64 `json:"entry_fee"`
+	OngoingCharges    *float64 `json:"ongoing_charges"`
+	RiskClass         *int     `json:"risk_class"`
+	EquityAllocation  *float64 `json:"equity_allocation"`
+	BondAllocation    *float64 `json:"bond_allocation"`
+	ManagementCompany *string  `json:"management_company"`
+	Benchmark         *string  `json:"benchmark"`
+}
+
+// ScraperCreateFundDetails is FundDetails's representation send by the scraper to be created
+type ScraperCreateFundDetails struct {
+	ValidFrom         string              `json:"valid_from"`
+	EntryFee          decimal.NullDecimal `json:"entry_fee"`
+	OngoingCharges    decimal.NullDecimal `json:"ongoing_charges"`
+	RiskClass         int                 `json:"risk_class"`
+	EquityAllocation  decimal.NullDecimal `json:"equity_allocation"`
+	BondAllocation    decimal.NullDecimal `json:"bond_allocation"`
+	ManagementCompany string              `json:"management_company"`
+	Benchmark         string              `json:"benchmark"`
+}
+
+// toFundDetails return the FundDetails, or an error wrapping ErrInvalidFundDetails
+func (d ScraperCreateFundDetails) toFundDetails() (FundDetails, error) {
+	validFrom, err := time.Parse("2006-01-02", d.ValidFrom)
+	if err != nil {
+		return FundDetails{}, fmt.Errorf("%w: the valid from date must use the YYYY-MM-DD format", ErrInvalidFundDetails)
+	}
+
+	if d.RiskClass != 0 && (d.RiskClass < MinRiskClass || d.RiskClass > MaxRiskClass) {
+		return FundDetails{}, fmt.Errorf("%w: the risk class must be between %d and %d", ErrInvalidFundDetails, MinRiskClass, MaxRiskClass)
+	}
+
+	one := decimal.NewFromInt(1)
+	for _, fraction := range []decimal.NullDecimal{d.EntryFee, d.OngoingCharges, d.EquityAllocation, d.BondAllocation} {
+		if fraction.Valid && (fraction.Decimal.IsNegative() || fraction.Decimal.GreaterThan(one)) {
+			return FundDetails{}, fmt.Errorf("%w: fees and allocations must be fractions between 0 and 1", ErrInvalidFundDetails)
+		}
+	}
+
+	if d.EquityAllocation.Decimal.Add(d.BondAllocation.Decimal).GreaterThan(one) {
+		return FundDetails{}, fmt.Errorf("%w: the equity and bond allocations cannot exceed 1", ErrInvalidFundDetails)
+	}
+
+	return FundDetails{
+		ValidFrom:         validFrom,
+		EntryFee:          d.EntryFee,
+		OngoingCharges:    d.OngoingCharges,
+		RiskClass:         d.RiskClass,
+		EquityAllocation:  d.EquityAllocation,
+		BondAllocation:    d.BondAllocation,
+		ManagementCompany: d.ManagementCompany,
+		Benchmark:         d.Benchmark,
+	}, nil
 }
 
 // newPublicFund return a PublicFund based on a Fund
 func newPublicFund(fund Fund) PublicFund {
+	details := newPublicFundDetails(fund.Details)
+
 	return PublicFund{
-		Isin:       fund.Isin,
-		Name:       fund.Name,
-		Bank:       fund.Bank,
-		LaunchDate: fund.LaunchDate.Format("2006-01-02"),
-		Currency:   fund.Currency,
+		Isin:              fund.Isin,
+		Name:              fund.Name,
+		Bank:              fund.Bank,
+		LaunchDate:        fund.LaunchDate.Format("2006-01-02"),
+		Currency:          fund.Currency,
+		PricingFrequency:  fund.PricingFrequency,
+		PricePrecision:    fund.PricePrecision,
+		EntryFee:          details.EntryFee,
+		OngoingCharges:    details.OngoingCharges,
+		RiskClass:         details.RiskClass,
+		EquityAllocation:  details.EquityAllocation,
+		BondAllocation:    details.BondAllocation,
+		ManagementCompany: details.ManagementCompany,
+		Benchmark:         details.Benchmark,
+	}
+}
+
+// newPublicFundDetails return a PublicFundDetails based on a FundDetails
+func newPublicFundDetails(details FundDetails) PublicFundDetails {
+	publicDetails := PublicFundDetails{
+		EntryFee:         nullDecimalToFloat(details.EntryFee),
+		OngoingCharges:   nullDecimalToFloat(details.OngoingCharges),
+		EquityAllocation: nullDecimalToFloat(details.EquityAllocation),
+		BondAllocation:   nullDecimalToFloat(details.BondAllocation),
 	}
+
+	if !details.ValidFrom.IsZero() {
+		publicDetails.ValidFrom = details.ValidFrom.Format("2006-01-02")
+	}
+	if details.RiskClass != 0 {
+		riskClass := details.RiskClass
+		publicDetails.RiskClass = &riskClass
+	}
+	if details.ManagementCompany != "" {
+		managementCompany := details.ManagementCompany
+		publicDetails.ManagementCompany = &managementCompany
+	}
+	if details.Benchmark != "" {
+		benchmark := details.Benchmark
+		publicDetails.Benchmark = &benchmark
+	}
+
+	return publicDetails
+}
+
+// nullDecimalToFloat return the float value of d, or nil when d is null
+func nullDecimalToFloat(d decimal.NullDecimal) *float64 {
+	if !d.Valid {
+		return nil
+	}
+
+	f, _ := d.Decimal.Float64()
+	return &f
 }
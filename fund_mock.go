@@ -1,33 +1,77 @@
 package pensiondata
 
+import "context"
+
 // FundRepositoryMock for tests
 type FundRepositoryMock struct {
-	FindByISINFn func(string) (Fund, error)
-	FindAllFn    func() ([]Fund, error)
+	FindByISINFn         func(context.Context, string) (Fund, error)
+	FindAllFn            func(context.Context) ([]Fund, error)
+	FindByCriteriaFn     func(context.Context, FundCriteria) ([]Fund, error)
+	StreamByCriteriaFn   func(context.Context, FundCriteria, func(Fund) error) error
+	FindDetailsHistoryFn func(context.Context, string) ([]FundDetails, error)
+	CreateDetailsFn      func(context.Context, string, FundDetails) (FundDetails, error)
 }
 
 // FundServiceMock for tests
 type FundServiceMock struct {
-	GetFundByISINFn func(string) (PublicFund, error)
-	GetFundsFn      func() ([]PublicFund, error)
+	GetFundByISINFn         func(context.Context, string) (PublicFund, error)
+	SearchFundsFn           func(context.Context, FundCriteria) ([]PublicFund, error)
+	StreamFundsFn           func(context.Context, FundCriteria, func(PublicFund) error) error
+	GetFundDetailsHistoryFn func(context.Context, string) ([]PublicFundDetails, error)
+	CreateFundDetailsFn     func(context.Context, string, ScraperCreateFundDetails) (PublicFundDetails, error)
 }
 
 // FindByISIN mock
-func (r FundRepositoryMock) FindByISIN(isin string) (Fund, error) {
-	return r.FindByISINFn(isin)
+func (r FundRepositoryMock) FindByISIN(ctx context.Context, isin string) (Fund, error) {
+	return r.FindByISINFn(ctx, isin)
 }
 
 // FindAll mock
-func (r FundRepositoryMock) FindAll() ([]Fund, error) {
-	return r.FindAllFn()
+func (r FundRepositoryMock) FindAll(ctx context.Context) ([]Fund, error) {
+	return r.FindAllFn(ctx)
+}
+
+// FindByCriteria mock
+func (r FundRepositoryMock) FindByCriteria(ctx context.Context, criteria FundCriteria) ([]Fund, error) {
+	return r.FindByCriteriaFn(ctx, criteria)
+}
+
+// StreamByCriteria mock
+func (r FundRepositoryMock) StreamByCriteria(ctx context.Context, criteria FundCriteria, fn func(Fund) error) error {
+	return r.StreamByCriteriaFn(ctx, criteria, fn)
+}
+
+// FindDetailsHistory mock
+func (r FundRepositoryMock) FindDetailsHistory(ctx context.Context, isin string) ([]FundDetails, error) {
+	return r.FindDetailsHistoryFn(ctx, isin)
+}
+
+// CreateDetails mock
+func (r FundRepositoryMock) CreateDetails(ctx context.Context, isin string, details FundDetails) (FundDetails, error) {
+	return r.CreateDetailsFn(ctx, isin, details)
 }
 
 // GetFundByISIN mock
-func (s FundServiceMock) GetFundByISIN(isin string) (PublicFund, error) {
-	return s.GetFundByISINFn(isin)
+func (s FundServiceMock) GetFundByISIN(ctx context.Context, isin string) (PublicFund, error) {
+	return s.GetFundByISINFn(ctx, isin)
+}
+
+// SearchFunds mock
+func (s FundServiceMock) SearchFunds(ctx context.Context, criteria FundCriteria) ([]PublicFund, error) {
+	return s.SearchFundsFn(ctx, criteria)
+}
+
+// StreamFunds mock
+func (s FundServiceMock) StreamFunds(ctx context.Context, criteria FundCriteria, fn func(PublicFund) error) error {
+	return s.StreamFundsFn(ctx, criteria, fn)
+}
+
+// GetFundDetailsHistory mock
+func (s FundServiceMock) GetFundDetailsHistory(ctx context.Context, isin string) ([]PublicFundDetails, error) {
+	return s.GetFundDetailsHistoryFn(ctx, isin)
 }
 
-// GetFunds mock
-func (s FundServiceMock) GetFunds() ([]PublicFund, error) {
-	return s.GetFundsFn()
+// CreateFundDetails mock
+func (s FundServiceMock) CreateFundDetails(ctx context.Context, isin string, details ScraperCreateFundDetails) (PublicFundDetails, error) {
+	return s.CreateFundDetailsFn(ctx, isin, details)
 }
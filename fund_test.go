@@ -1,15 +1,18 @@
 package pensiondata
 
 import (
+	"context"
 	"errors"
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 func TestGetFundByISIN(t *testing.T) {
 	t.Run("return fund successfully", func(t *testing.T) {
-		date, _ := time.Parse("2006-02-01", "2020-07-07")
+		date, _ := time.Parse("2006-01-02", "2020-07-07")
 		want := Fund{
 			Isin:       "BE123",
 			Name:       "First Fund",
@@ -19,12 +22,12 @@ func TestGetFundByISIN(t *testing.T) {
 		}
 
 		r := FundRepositoryMock{}
-		r.FindByISINFn = func(isin string) (Fund, error) {
+		r.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
 			return want, nil
 		}
 
 		fundService := NewFundService(r)
-		got, _ := fundService.GetFundByISIN("BE123")
+		got, _ := fundService.GetFundByISIN(context.Background(), "BE123")
 
 		if !reflect.DeepEqual(newPublicFund(want), got) {
 			t.Errorf("want %v, got %v", newPublicFund(want), got)
@@ -33,12 +36,12 @@ func TestGetFundByISIN(t *testing.T) {
 
 	t.Run("return error", func(t *testing.T) {
 		r := FundRepositoryMock{}
-		r.FindByISINFn = func(isin string) (Fund, error) {
+		r.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
 			return Fund{}, errors.New("error")
 		}
 
 		fundService := NewFundService(r)
-		_, err := fundService.GetFundByISIN("BE123")
+		_, err := fundService.GetFundByISIN(context.Background(), "BE123")
 
 		if err == nil {
 			t.Errorf("want error")
@@ -46,44 +49,47 @@ func TestGetFundByISIN(t *testing.T) {
 	})
 }
 
-func TestGetFunds(t *testing.T) {
-	t.Run("return funds successfully", func(t *testing.T) {
-		date, _ := time.Parse("2006-02-01", "2020-07-07")
-		wants := []Fund{
-			{Isin: "BE123", Name: "First Fund", Bank: "Banka", LaunchDate: date, Currency: "EUR"},
-			{Isin: "LU123", Name: "Second Fund", Bank: "Banko", LaunchDate: date, Currency: "EUR"},
-		}
-
+func TestSearchFunds(t *testing.T) {
+	t.Run("return funds with default sort", func(t *testing.T) {
+		var got FundCriteria
 		r := FundRepositoryMock{}
-		r.FindAllFn = func() ([]Fund, error) {
-			return wants, nil
+		r.FindByCriteriaFn = func(ctx context.Context, criteria FundCriteria) ([]Fund, error) {
+			got = criteria
+			return []Fund{{Isin: "BE123"}}, nil
 		}
 
 		fundService := NewFundService(r)
-		got, _ := fundService.GetFunds()
-
-		if len(wants) != len(got) {
-			t.Errorf("want %d, got %d", len(wants), len(got))
+		funds, err := fundService.SearchFunds(context.Background(), FundCriteria{Bank: "Banka"})
+		if err != nil {
+			t.Fatalf("want no error, got %s", err)
 		}
 
-		var wantPublicFunds []PublicFund
-		for _, want := range wants {
-			wantPublicFunds = append(wantPublicFunds, newPublicFund(want))
+		if len(funds) != 1 {
+			t.Errorf("want %d, got %d", 1, len(funds))
+		}
+		if got.Bank != "Banka" || got.Sort != FundSortName || got.Order != OrderAsc {
+			t.Errorf("want Banka sorted by name asc, got %s sorted by %s %s", got.Bank, got.Sort, got.Order)
 		}
+	})
 
-		if !reflect.DeepEqual(wantPublicFunds, got) {
-			t.Errorf("want %v, got %v", wantPublicFunds, got)
+	t.Run("return error for invalid criteria", func(t *testing.T) {
+		fundService := NewFundService(FundRepositoryMock{})
+
+		for _, criteria := range []FundCriteria{{Sort: "price"}, {Order: "up"}, {RiskClass: 9}} {
+			if _, err := fundService.SearchFunds(context.Background(), criteria); err != ErrInvalidCriteria {
+				t.Errorf("want %s, got %v", ErrInvalidCriteria, err)
+			}
 		}
 	})
 
 	t.Run("return error", func(t *testing.T) {
 		r := FundRepositoryMock{}
-		r.FindAllFn = func() ([]Fund, error) {
+		r.FindByCriteriaFn = func(ctx context.Context, criteria FundCriteria) ([]Fund, error) {
 			return []Fund{}, errors.New("error")
 		}
 
 		fundService := NewFundService(r)
-		_, err := fundService.GetFunds()
+		_, err := fundService.SearchFunds(context.Background(), FundCriteria{})
 
 		if err == nil {
 			t.Errorf("want error")
@@ -91,6 +97,168 @@ func TestGetFunds(t *testing.T) {
 	})
 }
 
+func TestStreamFunds(t *testing.T) {
+	t.Run("stream funds with default sort", func(t *testing.T) {
+		var got FundCriteria
+		r := FundRepositoryMock{}
+		r.StreamByCriteriaFn = func(ctx context.Context, criteria FundCriteria, fn func(Fund) error) error {
+			got = criteria
+			for _, isin := range []string{"BE123", "LU123"} {
+				if err := fn(Fund{Isin: isin}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		var isins []string
+		fundService := NewFundService(r)
+		err := fundService.StreamFunds(context.Background(), FundCriteria{Bank: "Banka"}, func(fund PublicFund) error {
+			isins = append(isins, fund.Isin)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("want no error, got %s", err)
+		}
+
+		if !reflect.DeepEqual([]string{"BE123", "LU123"}, isins) {
+			t.Errorf("want %v, got %v", []string{"BE123", "LU123"}, isins)
+		}
+		if got.Bank != "Banka" || got.Sort != FundSortName || got.Order != OrderAsc {
+			t.Errorf("want Banka sorted by name asc, got %s sorted by %s %s", got.Bank, got.Sort, got.Order)
+		}
+	})
+
+	t.Run("stop at the first error of the callback", func(t *testing.T) {
+		r := FundRepositoryMock{}
+		r.StreamByCriteriaFn = func(ctx context.Context, criteria FundCriteria, fn func(Fund) error) error {
+			return fn(Fund{Isin: "BE123"})
+		}
+
+		want := errors.New("client gone")
+		fundService := NewFundService(r)
+		err := fundService.StreamFunds(context.Background(), FundCriteria{}, func(fund PublicFund) error {
+			return want
+		})
+
+		if err != want {
+			t.Errorf("want %v, got %v", want, err)
+		}
+	})
+
+	t.Run("return error for invalid criteria", func(t *testing.T) {
+		fundService := NewFundService(FundRepositoryMock{})
+
+		if err := fundService.StreamFunds(context.Background(), FundCriteria{Sort: "price"}, nil); err != ErrInvalidCriteria {
+			t.Errorf("want %s, got %v", ErrInvalidCriteria, err)
+		}
+	})
+}
+
+func TestGetFundDetailsHistory(t *testing.T) {
+	t.Run("return details history successfully", func(t *testing.T) {
+		date, _ := time.Parse("2006-01-02", "2021-01-01")
+		history := []FundDetails{
+			{ValidFrom: date, RiskClass: 4},
+			{ValidFrom: date.AddDate(-1, 0, 0), RiskClass: 3},
+		}
+
+		r := FundRepositoryMock{}
+		r.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return Fund{Isin: isin}, nil
+		}
+		r.FindDetailsHistoryFn = func(ctx context.Context, isin string) ([]FundDetails, error) {
+			return history, nil
+		}
+
+		fundService := NewFundService(r)
+		got, _ := fundService.GetFundDetailsHistory(context.Background(), "BE123")
+
+		if len(got) != 2 || got[0].ValidFrom != "2021-01-01" || *got[1].RiskClass != 3 {
+			t.Errorf("want %v, got %v", history, got)
+		}
+	})
+
+	t.Run("return error for fund", func(t *testing.T) {
+		r := FundRepositoryMock{}
+		r.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return Fund{}, ErrFundNotFound
+		}
+
+		fundService := NewFundService(r)
+		_, err := fundService.GetFundDetailsHistory(context.Background(), "BE123")
+
+		if err != ErrFundNotFound {
+			t.Errorf("want %s, got %v", ErrFundNotFound, err)
+		}
+	})
+}
+
+func TestCreateFundDetails(t *testing.T) {
+	t.Run("create details successfully", func(t *testing.T) {
+		r := FundRepositoryMock{}
+		r.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return Fund{Isin: isin}, nil
+		}
+		r.CreateDetailsFn = func(ctx context.Context, isin string, details FundDetails) (FundDetails, error) {
+			return details, nil
+		}
+
+		fundService := NewFundService(r)
+		got, err := fundService.CreateFundDetails(context.Background(), "BE123", ScraperCreateFundDetails{
+			ValidFrom:        "2021-01-01",
+			OngoingCharges:   decimal.NullDecimal{Decimal: decimal.NewFromFloat(0.0125), Valid: true},
+			RiskClass:        4,
+			EquityAllocation: decimal.NullDecimal{Decimal: decimal.NewFromFloat(0.7), Valid: true},
+			BondAllocation:   decimal.NullDecimal{Decimal: decimal.NewFromFloat(0.3), Valid: true},
+		})
+		if err != nil {
+			t.Fatalf("want no error, got %s", err)
+		}
+
+		if got.ValidFrom != "2021-01-01" || *got.OngoingCharges != 0.0125 || got.EntryFee != nil {
+			t.Errorf("want details valid from 2021-01-01, got %v", got)
+		}
+	})
+
+	t.Run("return error for invalid details", func(t *testing.T) {
+		fundService := NewFundService(FundRepositoryMock{})
+
+		invalidDetails := []ScraperCreateFundDetails{
+			{ValidFrom: "01/01/2021"},
+			{ValidFrom: "2021-01-01", RiskClass: 8},
+			{ValidFrom: "2021-01-01", EntryFee: decimal.NullDecimal{Decimal: decimal.NewFromInt(3), Valid: true}},
+			{
+				ValidFrom:        "2021-01-01",
+				EquityAllocation: decimal.NullDecimal{Decimal: decimal.NewFromFloat(0.8), Valid: true},
+				BondAllocation:   decimal.NullDecimal{Decimal: decimal.NewFromFloat(0.3), Valid: true},
+			},
+		}
+		for _, details := range invalidDetails {
+			if _, err := fundService.CreateFundDetails(context.Background(), "BE123", details); !errors.Is(err, ErrInvalidFundDetails) {
+				t.Errorf("want %s, got %v", ErrInvalidFundDetails, err)
+			}
+		}
+	})
+
+	t.Run("return error for details", func(t *testing.T) {
+		r := FundRepositoryMock{}
+		r.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return Fund{Isin: isin}, nil
+		}
+		r.CreateDetailsFn = func(ctx context.Context, isin string, details FundDetails) (FundDetails, error) {
+			return FundDetails{}, ErrFundDetailsAlreadyExist
+		}
+
+		fundService := NewFundService(r)
+		_, err := fundService.CreateFundDetails(context.Background(), "BE123", ScraperCreateFundDetails{ValidFrom: "2021-01-01"})
+
+		if err != ErrFundDetailsAlreadyExist {
+			t.Errorf("want %s, got %v", ErrFundDetailsAlreadyExist, err)
+		}
+	})
+}
+
 func TestNewPublicFund(t *testing.T) {
 	t.Run("return correctly formatted PublicFund", func(t *testing.T) {
 		date, _ := time.Parse("2006-01-02", "2020-06-27")
@@ -119,5 +287,34 @@ func TestNewPublicFund(t *testing.T) {
 		if want.Currency != got.Currency {
 			t.Errorf("want %s, got %s", want.Currency, got.Currency)
 		}
+		if got.RiskClass != nil || got.EntryFee != nil || got.ManagementCompany != nil {
+			t.Errorf("want null details, got %v", got)
+		}
+	})
+
+	t.Run("return PublicFund with current details", func(t *testing.T) {
+		want := Fund{
+			Isin: "BE123",
+			Details: FundDetails{
+				EntryFee:          decimal.NullDecimal{Decimal: decimal.NewFromFloat(0.03), Valid: true},
+				RiskClass:         3,
+				ManagementCompany: "Banka Asset Management",
+			},
+		}
+
+		got := newPublicFund(want)
+
+		if got.EntryFee == nil || *got.EntryFee != 0.03 {
+			t.Errorf("want %f, got %v", 0.03, got.EntryFee)
+		}
+		if got.RiskClass == nil || *got.RiskClass != 3 {
+			t.Errorf("want %d, got %v", 3, got.RiskClass)
+		}
+		if got.ManagementCompany == nil || *got.ManagementCompany != want.Details.ManagementCompany {
+			t.Errorf("want %s, got %v", want.Details.ManagementCompany, got.ManagementCompany)
+		}
+		if got.OngoingCharges != nil {
+			t.Errorf("want null ongoing charges, got %v", *got.OngoingCharges)
+		}
 	})
 }
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+)
+
+// APIKeyHandler handle all the HTTP requests for the management of the API keys
+type APIKeyHandler struct {
+	s pensiondata.APIKeyService
+}
+
+// InitAPIKeyHandler initialize a new APIKeyHandler and register routes
+func InitAPIKeyHandler(router gin.IRouter, service pensiondata.APIKeyService) {
+	h := &APIKeyHandler{s: service}
+
+	router.GET("/admin/api-keys", ScopeRequired(pensiondata.ScopeAdmin), h.GetAPIKeys())
+	router.POST("/admin/api-keys", ScopeRequired(pensiondata.ScopeAdmin), h.CreateAPIKey())
+	router.DELETE("/admin/api-keys/:id", ScopeRequired(pensiondata.ScopeAdmin), h.RevokeAPIKey())
+}
+
+// GetAPIKeys return all API keys, without their secret
+func (h APIKeyHandler) GetAPIKeys() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		publicAPIKeys, err := h.s.GetAPIKeys(context.Request.Context())
+		if err != nil {
+			writeError(context, err, nil)
+			return
+		}
+
+		render(context, http.StatusOK, publicAPIKeys)
+	}
+}
+
+// CreateAPIKey create a new API key and return it along with the key, which is only returned once
+func (h APIKeyHandler) CreateAPIKey() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		var createAPIKey pensiondata.AdminCreateAPIKey
+		if err := context.ShouldBindJSON(&createAPIKey); err != nil {
+			writeProblem(context, problemInvalidBody, "The request body must be a valid API key with a name and scopes")
+			return
+		}
+
+		publicCreatedAPIKey, err := h.s.CreateAPIKey(context.Request.Context(), createAPIKey)
+		if err != nil {
+			writeError(context, err, nil)
+			return
+		}
+
+		log.Printf("API key %s created by %s", publicCreatedAPIKey.Prefix, context.GetString(identityKey))
+		render(context, http.StatusCreated, publicCreatedAPIKey)
+	}
+}
+
+// RevokeAPIKey revoke the API key for the given id
+func (h APIKeyHandler) RevokeAPIKey() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		id, err := strconv.ParseInt(context.Params.ByName("id"), 10, 64)
+		if err != nil {
+			writeProblem(context, problemInvalidParameter, fmt.Sprintf("The id %s must be a number", context.Params.ByName("id")))
+			return
+		}
+
+		if err := h.s.RevokeAPIKey(context.Request.Context(), id); err != nil {
+			writeError(context, err, errorDetails{
+				pensiondata.ErrAPIKeyNotFound: fmt.Sprintf("The API key %d was not found", id),
+			})
+			return
+		}
+
+		log.Printf("API key %d revoked by %s", id, context.GetString(identityKey))
+		context.Status(http.StatusNoContent)
+	}
+}
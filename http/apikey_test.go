@@ -0,0 +1,156 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+)
+
+func TestGetAPIKeys(t *testing.T) {
+	t.Run("return API keys successfully", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		apiKeyService := pensiondata.APIKeyServiceMock{}
+		apiKeyService.GetAPIKeysFn = func(ctx context.Context) ([]pensiondata.PublicAPIKey, error) {
+			return []pensiondata.PublicAPIKey{{ID: 1, Name: "scraper", Prefix: "0a1b2c3d"}}, nil
+		}
+
+		InitAPIKeyHandler(r, apiKeyService)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/admin/api-keys", nil)
+		req.Header.Add("Authorization", "Bearer "+testAdminKey)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusOK != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusOK, resp.Code)
+		}
+		if contentTypeJson != resp.Header().Get("Content-Type") {
+			t.Errorf("want %s, got %s", contentTypeJson, resp.Header().Get("Content-Type"))
+		}
+	})
+
+	t.Run("return forbidden error without admin scope", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		InitAPIKeyHandler(r, pensiondata.APIKeyServiceMock{})
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/admin/api-keys", nil)
+		req.Header.Add("Authorization", "Bearer "+testScraperKey)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusForbidden != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusForbidden, resp.Code)
+		}
+	})
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		createFn func(context.Context, pensiondata.AdminCreateAPIKey) (pensiondata.PublicCreatedAPIKey, error)
+		want     int
+	}{
+		{
+			name: "create API key successfully",
+			body: `{"name":"scraper","scopes":["quotes:write"]}`,
+			createFn: func(ctx context.Context, createAPIKey pensiondata.AdminCreateAPIKey) (pensiondata.PublicCreatedAPIKey, error) {
+				if createAPIKey.Name != "scraper" || len(createAPIKey.Scopes) != 1 {
+					return pensiondata.PublicCreatedAPIKey{}, errors.New("unexpected API key")
+				}
+				return pensiondata.PublicCreatedAPIKey{Key: testScraperKey}, nil
+			},
+			want: http.StatusCreated,
+		},
+		{
+			name: "return bad request error for invalid body",
+			body: `{"name":`,
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "return bad request error for invalid parameters",
+			body: `{"name":"scraper","scopes":["quotes:read"]}`,
+			createFn: func(ctx context.Context, createAPIKey pensiondata.AdminCreateAPIKey) (pensiondata.PublicCreatedAPIKey, error) {
+				return pensiondata.PublicCreatedAPIKey{}, fmt.Errorf("%w: the scope quotes:read is unknown", pensiondata.ErrInvalidAPIKeyParameters)
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "return internal error",
+			body: `{"name":"scraper","scopes":["quotes:write"]}`,
+			createFn: func(ctx context.Context, createAPIKey pensiondata.AdminCreateAPIKey) (pensiondata.PublicCreatedAPIKey, error) {
+				return pensiondata.PublicCreatedAPIKey{}, errors.New("internal error")
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := testRouter()
+
+			InitAPIKeyHandler(r, pensiondata.APIKeyServiceMock{CreateAPIKeyFn: tt.createFn})
+
+			resp := httptest.NewRecorder()
+
+			req, _ := http.NewRequest(http.MethodPost, "/admin/api-keys", bytes.NewBufferString(tt.body))
+			req.Header.Add("Authorization", "Bearer "+testAdminKey)
+
+			r.ServeHTTP(resp, req)
+
+			if tt.want != resp.Code {
+				t.Errorf("want %d, got %d", tt.want, resp.Code)
+			}
+		})
+	}
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		revokeFn func(context.Context, int64) error
+		want     int
+	}{
+		{name: "revoke API key successfully", path: "/admin/api-keys/1", revokeFn: func(ctx context.Context, id int64) error { return nil }, want: http.StatusNoContent},
+		{name: "return bad request error for invalid id", path: "/admin/api-keys/abc", want: http.StatusBadRequest},
+		{name: "return not found error for API key", path: "/admin/api-keys/1", revokeFn: func(ctx context.Context, id int64) error { return pensiondata.ErrAPIKeyNotFound }, want: http.StatusNotFound},
+		{name: "return internal error", path: "/admin/api-keys/1", revokeFn: func(ctx context.Context, id int64) error { return errors.New("internal error") }, want: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := testRouter()
+
+			InitAPIKeyHandler(r, pensiondata.APIKeyServiceMock{RevokeAPIKeyFn: tt.revokeFn})
+
+			resp := httptest.NewRecorder()
+
+			req, _ := http.NewRequest(http.MethodDelete, tt.path, nil)
+			req.Header.Add("Authorization", "Bearer "+testAdminKey)
+
+			r.ServeHTTP(resp, req)
+
+			if tt.want != resp.Code {
+				t.Errorf("want %d, got %d", tt.want, resp.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+	"github.com/shopspring/decimal"
+)
+
+// BacktestHandler handle all the HTTP requests for savings plan backtests
+type BacktestHandler struct {
+	s pensiondata.BacktestService
+}
+
+// InitBacktestHandler initialize a new BacktestHandler and register routes
+func InitBacktestHandler(router gin.IRouter, service pensiondata.BacktestService) {
+	h := &BacktestHandler{s: service}
+
+	router.GET("/funds/:isin/backtest", h.Backtest())
+}
+
+// Backtest return the result of the monthly savings plan given in query for the given fund
+func (h BacktestHandler) Backtest() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		isin, ok := isinParam(context)
+		if !ok {
+			return
+		}
+
+		request, err := parseBacktestRequest(context)
+		if err != nil {
+			writeProblem(context, problemInvalidParameter, fmt.Sprintf("Invalid query parameter: %s", err))
+			return
+		}
+
+		publicBacktest, err := h.s.Backtest(context.Request.Context(), isin, request)
+		if err != nil {
+			writeError(context, err, errorDetails{
+				pensiondata.ErrFundNotFound:  fmt.Sprintf("The fund %s was not found", isin),
+				pensiondata.ErrQuoteNotFound: fmt.Sprintf("No quotes are available for fund %s over this period", isin),
+			})
+			return
+		}
+
+		render(context, http.StatusOK, publicBacktest)
+	}
+}
+
+// parseBacktestRequest return the BacktestRequest from the monthly, from and to query parameters
+func parseBacktestRequest(context *gin.Context) (pensiondata.BacktestRequest, error) {
+	var request pensiondata.BacktestRequest
+	var err error
+
+	monthly := context.Query("monthly")
+	if request.Monthly, err = decimal.NewFromString(monthly); err != nil {
+		return pensiondata.BacktestRequest{}, fmt.Errorf("the monthly amount %s must be a number", monthly)
+	}
+
+	from := context.Query("from")
+	if request.From, err = time.Parse("2006-01", from); err != nil {
+		return pensiondata.BacktestRequest{}, fmt.Errorf("the from month %s must use the YYYY-MM format", from)
+	}
+
+	to := context.Query("to")
+	if to == "" {
+		now := time.Now().UTC()
+		request.To = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	} else if request.To, err = time.Parse("2006-01", to); err != nil {
+		return pensiondata.BacktestRequest{}, fmt.Errorf("the to month %s must use the YYYY-MM format", to)
+	}
+
+	return request, nil
+}
@@ -0,0 +1,107 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+)
+
+func TestBacktest(t *testing.T) {
+	t.Run("return backtest successfully", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := gin.Default()
+
+		var got pensiondata.BacktestRequest
+		s := pensiondata.BacktestServiceMock{}
+		s.BacktestFn = func(ctx context.Context, isin string, request pensiondata.BacktestRequest) (pensiondata.PublicBacktest, error) {
+			got = request
+			return pensiondata.PublicBacktest{Isin: isin}, nil
+		}
+
+		InitBacktestHandler(r, s)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/backtest?monthly=100&from=2010-01&to=2020-12", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusOK != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusOK, resp.Code)
+		}
+		if contentTypeJson != resp.Header().Get("Content-Type") {
+			t.Errorf("want %s, got %s", contentTypeJson, resp.Header().Get("Content-Type"))
+		}
+		if got.Monthly.String() != "100" || got.From.Format("2006-01-02") != "2010-01-01" || got.To.Format("2006-01-02") != "2020-12-01" {
+			t.Errorf("want 100 from 2010-01-01 to 2020-12-01, got %s from %s to %s", got.Monthly, got.From, got.To)
+		}
+	})
+
+	t.Run("return bad request error for invalid query parameters", func(t *testing.T) {
+		for _, query := range []string{"monthly=abc&from=2010-01", "monthly=100", "monthly=100&from=2010-01&to=2020"} {
+			gin.SetMode(gin.TestMode)
+			r := gin.Default()
+
+			InitBacktestHandler(r, pensiondata.BacktestServiceMock{})
+
+			resp := httptest.NewRecorder()
+
+			req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/backtest?"+query, nil)
+
+			r.ServeHTTP(resp, req)
+
+			if http.StatusBadRequest != resp.Code {
+				t.Errorf("%s: want %d, got %d", query, http.StatusBadRequest, resp.Code)
+			}
+		}
+	})
+
+	t.Run("return not found error for fund", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := gin.Default()
+
+		s := pensiondata.BacktestServiceMock{}
+		s.BacktestFn = func(ctx context.Context, isin string, request pensiondata.BacktestRequest) (pensiondata.PublicBacktest, error) {
+			return pensiondata.PublicBacktest{}, pensiondata.ErrFundNotFound
+		}
+
+		InitBacktestHandler(r, s)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/backtest?monthly=100&from=2010-01", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusNotFound != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusNotFound, resp.Code)
+		}
+	})
+
+	t.Run("return internal error", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := gin.Default()
+
+		s := pensiondata.BacktestServiceMock{}
+		s.BacktestFn = func(ctx context.Context, isin string, request pensiondata.BacktestRequest) (pensiondata.PublicBacktest, error) {
+			return pensiondata.PublicBacktest{}, errors.New("internal error")
+		}
+
+		InitBacktestHandler(r, s)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/backtest?monthly=100&from=2010-01", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusInternalServerError != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusInternalServerError, resp.Code)
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+)
+
+// CompareHandler handle all the HTTP requests for fund comparisons
+type CompareHandler struct {
+	s pensiondata.CompareService
+}
+
+// InitCompareHandler initialize a new CompareHandler and register routes
+func InitCompareHandler(router gin.IRouter, service pensiondata.CompareService) {
+	h := &CompareHandler{s: service}
+
+	router.GET("/compare", h.Compare())
+}
+
+// Compare return the comparison of the funds given in query
+func (h CompareHandler) Compare() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		var isins []string
+		for _, isin := range strings.Split(context.Query("isins"), ",") {
+			if isin = strings.ToUpper(strings.TrimSpace(isin)); isin != "" {
+				isins = append(isins, isin)
+			}
+		}
+
+		var from time.Time
+		if fromQuery := context.Query("from"); fromQuery != "" {
+			var err error
+			if from, err = time.Parse("2006-01-02", fromQuery); err != nil {
+				writeProblem(context, problemInvalidParameter, fmt.Sprintf("Invalid query parameter: the from date %s must use the YYYY-MM-DD format", fromQuery))
+				return
+			}
+		}
+
+		publicComparison, err := h.s.Compare(context.Request.Context(), isins, from)
+		if err != nil {
+			writeError(context, err, nil)
+			return
+		}
+
+		render(context, http.StatusOK, publicComparison)
+	}
+}
@@ -0,0 +1,112 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+)
+
+func TestCompare(t *testing.T) {
+	t.Run("return comparison successfully", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := gin.Default()
+
+		var gotIsins []string
+		var gotFrom time.Time
+		s := pensiondata.CompareServiceMock{}
+		s.CompareFn = func(ctx context.Context, isins []string, from time.Time) (pensiondata.PublicComparison, error) {
+			gotIsins, gotFrom = isins, from
+			return pensiondata.PublicComparison{}, nil
+		}
+
+		InitCompareHandler(r, s)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/compare?isins=be0948502365,+LU0123456781,&from=2020-01-01", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusOK != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusOK, resp.Code)
+		}
+		if contentTypeJson != resp.Header().Get("Content-Type") {
+			t.Errorf("want %s, got %s", contentTypeJson, resp.Header().Get("Content-Type"))
+		}
+		if want := []string{"BE0948502365", "LU0123456781"}; !reflect.DeepEqual(want, gotIsins) {
+			t.Errorf("want %v, got %v", want, gotIsins)
+		}
+		if gotFrom.Format("2006-01-02") != "2020-01-01" {
+			t.Errorf("want %s, got %s", "2020-01-01", gotFrom.Format("2006-01-02"))
+		}
+	})
+
+	t.Run("return bad request error for invalid from date", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := gin.Default()
+
+		InitCompareHandler(r, pensiondata.CompareServiceMock{})
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/compare?isins=BE0948502365&from=2020", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusBadRequest != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusBadRequest, resp.Code)
+		}
+	})
+
+	t.Run("return bad request error for invalid comparison", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := gin.Default()
+
+		s := pensiondata.CompareServiceMock{}
+		s.CompareFn = func(ctx context.Context, isins []string, from time.Time) (pensiondata.PublicComparison, error) {
+			return pensiondata.PublicComparison{}, fmt.Errorf("%w: too many funds", pensiondata.ErrInvalidComparison)
+		}
+
+		InitCompareHandler(r, s)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/compare", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusBadRequest != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusBadRequest, resp.Code)
+		}
+	})
+
+	t.Run("return internal error", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := gin.Default()
+
+		s := pensiondata.CompareServiceMock{}
+		s.CompareFn = func(ctx context.Context, isins []string, from time.Time) (pensiondata.PublicComparison, error) {
+			return pensiondata.PublicComparison{}, errors.New("internal error")
+		}
+
+		InitCompareHandler(r, s)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/compare?isins=BE0948502365", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusInternalServerError != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusInternalServerError, resp.Code)
+		}
+	})
+}
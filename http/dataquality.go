@@ -0,0 +1,73 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+)
+
+// DataQualityHandler handle all the HTTP requests for the quality of the quotes
+type DataQualityHandler struct {
+	s pensiondata.DataQualityService
+}
+
+// InitDataQualityHandler initialize a new DataQualityHandler and register routes
+func InitDataQualityHandler(router gin.IRouter, service pensiondata.DataQualityService) {
+	h := &DataQualityHandler{s: service}
+
+	router.GET("/admin/data-quality", ScopeRequired(pensiondata.ScopeAdmin), h.GetDataQualityReport())
+}
+
+// GetDataQualityReport return the stale funds and the gaps in the quotes
+func (h DataQualityHandler) GetDataQualityReport() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		criteria, err := parseDataQualityCriteria(context)
+		if err != nil {
+			writeProblem(context, problemInvalidParameter, fmt.Sprintf("Invalid query parameter: %s", err))
+			return
+		}
+
+		report, err := h.s.GetDataQualityReport(context.Request.Context(), criteria)
+		if err != nil {
+			writeError(context, err, errorDetails{
+				pensiondata.ErrInvalidCriteria: "The from date must not be in the future",
+			})
+			return
+		}
+
+		render(context, http.StatusOK, report)
+	}
+}
+
+// parseDataQualityCriteria return the DataQualityCriteria from the from, stale_after and gap_tolerance query parameters
+func parseDataQualityCriteria(context *gin.Context) (pensiondata.DataQualityCriteria, error) {
+	criteria := pensiondata.DataQualityCriteria{
+		StaleAfter:   pensiondata.DefaultDataQualityStaleAfter,
+		GapTolerance: pensiondata.DefaultDataQualityGapTolerance,
+	}
+	var err error
+
+	if from := context.Query("from"); from != "" {
+		if criteria.From, err = time.Parse("2006-01-02", from); err != nil {
+			return pensiondata.DataQualityCriteria{}, fmt.Errorf("the from date %s must use the YYYY-MM-DD format", from)
+		}
+	}
+
+	if staleAfter := context.Query("stale_after"); staleAfter != "" {
+		if criteria.StaleAfter, err = strconv.Atoi(staleAfter); err != nil || criteria.StaleAfter < 0 {
+			return pensiondata.DataQualityCriteria{}, fmt.Errorf("the stale_after %s must be a number of business days", staleAfter)
+		}
+	}
+
+	if gapTolerance := context.Query("gap_tolerance"); gapTolerance != "" {
+		if criteria.GapTolerance, err = strconv.Atoi(gapTolerance); err != nil || criteria.GapTolerance < 0 {
+			return pensiondata.DataQualityCriteria{}, fmt.Errorf("the gap_tolerance %s must be a number of business days", gapTolerance)
+		}
+	}
+
+	return criteria, nil
+}
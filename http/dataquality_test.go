@@ -0,0 +1,103 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+)
+
+func TestGetDataQualityReport(t *testing.T) {
+	t.Run("return data quality report successfully", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		var got pensiondata.DataQualityCriteria
+		dataQualityService := pensiondata.DataQualityServiceMock{}
+		dataQualityService.GetDataQualityReportFn = func(ctx context.Context, criteria pensiondata.DataQualityCriteria) (pensiondata.PublicDataQualityReport, error) {
+			got = criteria
+			return pensiondata.PublicDataQualityReport{AsOf: "2020-07-15"}, nil
+		}
+
+		InitDataQualityHandler(r, dataQualityService)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/admin/data-quality?from=2020-06-01&stale_after=5", nil)
+		req.Header.Add("Authorization", "Bearer "+testAdminKey)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusOK != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusOK, resp.Code)
+		}
+		if contentTypeJson != resp.Header().Get("Content-Type") {
+			t.Errorf("want %s, got %s", contentTypeJson, resp.Header().Get("Content-Type"))
+		}
+		if got.From.Format("2006-01-02") != "2020-06-01" || got.StaleAfter != 5 || got.GapTolerance != pensiondata.DefaultDataQualityGapTolerance {
+			t.Errorf("want criteria from query parameters and defaults, got %v", got)
+		}
+	})
+
+	t.Run("return unauthorized error without admin key", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		InitDataQualityHandler(r, pensiondata.DataQualityServiceMock{})
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/admin/data-quality", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusUnauthorized != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusUnauthorized, resp.Code)
+		}
+	})
+
+	t.Run("return bad request error for invalid query parameter", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		InitDataQualityHandler(r, pensiondata.DataQualityServiceMock{})
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/admin/data-quality?gap_tolerance=-1", nil)
+		req.Header.Add("Authorization", "Bearer "+testAdminKey)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusBadRequest != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusBadRequest, resp.Code)
+		}
+	})
+
+	t.Run("return internal error", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		dataQualityService := pensiondata.DataQualityServiceMock{}
+		dataQualityService.GetDataQualityReportFn = func(ctx context.Context, criteria pensiondata.DataQualityCriteria) (pensiondata.PublicDataQualityReport, error) {
+			return pensiondata.PublicDataQualityReport{}, errors.New("internal error")
+		}
+
+		InitDataQualityHandler(r, dataQualityService)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/admin/data-quality", nil)
+		req.Header.Add("Authorization", "Bearer "+testAdminKey)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusInternalServerError != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusInternalServerError, resp.Code)
+		}
+	})
+}
@@ -1,4 +1,217 @@
 package http
 
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+)
+
 const internalErrorMessage = "An internal error occurred, please try again later. " +
 	"If the problem persists drop us a line at hello@pensiondata.eu"
+
+const timeoutMessage = "The request could not be served in time, please try again later or narrow it down"
+
+const serviceUnavailableMessage = "The service is temporarily unavailable, please try again later"
+
+// statusClientClosedRequest is the status of the requests canceled by the client, recorded but never received
+const statusClientClosedRequest = 499
+
+// serviceUnavailableRetryAfter is the delay in seconds advised to the clients when the database is unavailable
+const serviceUnavailableRetryAfter = "30"
+
+// problemContentType is the media type of the error responses, defined by RFC 7807
+const problemContentType = "application/problem+json"
+
+// problemTypeBaseURI is the base of the URIs identifying the types of problem
+const problemTypeBaseURI = "https://api.pensiondata.eu/problems/"
+
+// Problem is the RFC 7807 representation of the errors returned by the API.
+// Type is the URI identifying the type of problem and Code its machine readable name, Instance is the request URI.
+// Errors are the errors of each invalid field of the request, if any.
+type Problem struct {
+	Type     string                   `json:"type"`
+	Title    string                   `json:"title"`
+	Status   int                      `json:"status"`
+	Detail   string                   `json:"detail,omitempty"`
+	Instance string                   `json:"instance,omitempty"`
+	Code     string                   `json:"code"`
+	Errors   []pensiondata.FieldError `json:"errors,omitempty"`
+}
+
+// problemType is a type of problem, shared by the errors having the same meaning for the clients
+type problemType struct {
+	code   string
+	title  string
+	status int
+}
+
+// The types of problem returned by the API
+var (
+	problemInvalidInput             = problemType{"invalid_input", "Invalid input", http.StatusBadRequest}
+	problemInvalidParameter         = problemType{"invalid_parameter", "Invalid parameter", http.StatusBadRequest}
+	problemInvalidBody              = problemType{"invalid_body", "Invalid request body", http.StatusBadRequest}
+	problemInvalidCriteria          = problemType{"invalid_criteria", "Invalid criteria", http.StatusBadRequest}
+	problemInvalidWindow            = problemType{"invalid_window", "Invalid window", http.StatusBadRequest}
+	problemInvalidSimulation        = problemType{"invalid_simulation", "Invalid simulation", http.StatusBadRequest}
+	problemInvalidBacktest          = problemType{"invalid_backtest", "Invalid backtest", http.StatusBadRequest}
+	problemInvalidComparison        = problemType{"invalid_comparison", "Invalid comparison", http.StatusBadRequest}
+	problemInvalidFundDetails       = problemType{"invalid_fund_details", "Invalid fund details", http.StatusBadRequest}
+	problemInvalidBatch             = problemType{"invalid_batch", "Invalid batch", http.StatusBadRequest}
+	problemInvalidQuote             = problemType{"invalid_quote", "Invalid quote", http.StatusBadRequest}
+	problemInvalidQuarantineStatus  = problemType{"invalid_quarantine_status", "Invalid quarantine status", http.StatusBadRequest}
+	problemInvalidAPIKeyParameters  = problemType{"invalid_api_key_parameters", "Invalid API key parameters", http.StatusBadRequest}
+	problemAPIKeyRequired           = problemType{"api_key_required", "API key required", http.StatusUnauthorized}
+	problemInvalidAPIKey            = problemType{"invalid_api_key", "Invalid API key", http.StatusUnauthorized}
+	problemMissingScope             = problemType{"missing_scope", "Missing scope", http.StatusForbidden}
+	problemRouteNotFound            = problemType{"route_not_found", "Route not found", http.StatusNotFound}
+	problemFundNotFound             = problemType{"fund_not_found", "Fund not found", http.StatusNotFound}
+	problemQuoteNotFound            = problemType{"quote_not_found", "Quote not found", http.StatusNotFound}
+	problemQuarantinedQuoteNotFound = problemType{"quarantined_quote_not_found", "Quarantined quote not found", http.StatusNotFound}
+	problemAPIKeyNotFound           = problemType{"api_key_not_found", "API key not found", http.StatusNotFound}
+	problemQuoteConflict            = problemType{"quote_conflict", "Quote conflict", http.StatusConflict}
+	problemFundDetailsAlreadyExist  = problemType{"fund_details_already_exist", "Fund details already exist", http.StatusConflict}
+	problemQuarantinedQuoteReviewed = problemType{"quarantined_quote_reviewed", "Quarantined quote already reviewed", http.StatusConflict}
+	problemNotEnoughQuotes          = problemType{"not_enough_quotes", "Not enough quotes", http.StatusUnprocessableEntity}
+	problemTooManyRequests          = problemType{"too_many_requests", "Too many requests", http.StatusTooManyRequests}
+	problemInternalError            = problemType{"internal_error", "Internal error", http.StatusInternalServerError}
+	problemServiceUnavailable       = problemType{"service_unavailable", "Service unavailable", http.StatusServiceUnavailable}
+	problemTimeout                  = problemType{"timeout", "Timeout", http.StatusGatewayTimeout}
+)
+
+// errorProblems map the domain errors, wrapped or not, to their type of problem
+var errorProblems = []struct {
+	err     error
+	problem problemType
+}{
+	{pensiondata.ErrInvalidInput, problemInvalidInput},
+	{pensiondata.ErrInvalidCriteria, problemInvalidCriteria},
+	{pensiondata.ErrInvalidCursor, problemInvalidParameter},
+	{pensiondata.ErrInvalidWindow, problemInvalidWindow},
+	{pensiondata.ErrInvalidSimulation, problemInvalidSimulation},
+	{pensiondata.ErrInvalidBacktest, problemInvalidBacktest},
+	{pensiondata.ErrInvalidComparison, problemInvalidComparison},
+	{pensiondata.ErrInvalidFundDetails, problemInvalidFundDetails},
+	{pensiondata.ErrInvalidBatch, problemInvalidBatch},
+	{pensiondata.ErrInvalidQuote, problemInvalidQuote},
+	{pensiondata.ErrInvalidQuarantineStatus, problemInvalidQuarantineStatus},
+	{pensiondata.ErrInvalidAPIKeyParameters, problemInvalidAPIKeyParameters},
+	{pensiondata.ErrInvalidAPIKey, problemInvalidAPIKey},
+	{pensiondata.ErrFundNotFound, problemFundNotFound},
+	{pensiondata.ErrQuoteNotFound, problemQuoteNotFound},
+	{pensiondata.ErrQuarantinedQuoteNotFound, problemQuarantinedQuoteNotFound},
+	{pensiondata.ErrAPIKeyNotFound, problemAPIKeyNotFound},
+	{pensiondata.ErrQuoteConflict, problemQuoteConflict},
+	{pensiondata.ErrFundDetailsAlreadyExist, problemFundDetailsAlreadyExist},
+	{pensiondata.ErrQuarantinedQuoteReviewed, problemQuarantinedQuoteReviewed},
+	{pensiondata.ErrNotEnoughQuotes, problemNotEnoughQuotes},
+}
+
+// errorDetails are the details explaining the domain errors to the client, for the request being served
+type errorDetails map[error]string
+
+// writeProblem abort the request with a problem of the given type, detail explaining this occurrence to the client
+// along with the errors of the invalid fields
+func writeProblem(c *gin.Context, problem problemType, detail string, fields ...pensiondata.FieldError) {
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(problem.status, Problem{
+		Type:     problemTypeBaseURI + strings.ReplaceAll(problem.code, "_", "-"),
+		Title:    problem.title,
+		Status:   problem.status,
+		Detail:   detail,
+		Instance: c.Request.URL.RequestURI(),
+		Code:     problem.code,
+		Errors:   fields,
+	})
+}
+
+// writeError translate the error to its problem and abort the request with it.
+// The detail of the error comes from details, or from the message of a wrapped error such as the validation errors,
+// the errors of the fields of a pensiondata.ValidationError are added to the problem.
+// Requests exceeding their deadline are timeouts and requests failing to reach the database are unavailable,
+// the requests canceled by the client are aborted without problem.
+// Errors without a type of problem are logged and written as internal errors, their message is not leaked.
+func writeError(c *gin.Context, err error, details errorDetails) {
+	for _, mapping := range errorProblems {
+		if !errors.Is(err, mapping.err) {
+			continue
+		}
+
+		detail, ok := details[mapping.err]
+		if !ok && err != mapping.err {
+			detail = err.Error()
+		}
+
+		var validationErr pensiondata.ValidationError
+		if errors.As(err, &validationErr) {
+			writeProblem(c, mapping.problem, detail, validationErr.Fields...)
+			return
+		}
+		writeProblem(c, mapping.problem, detail)
+		return
+	}
+
+	switch {
+	case errors.Is(c.Request.Context().Err(), context.Canceled):
+		c.AbortWithStatus(statusClientClosedRequest)
+		return
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(c.Request.Context().Err(), context.DeadlineExceeded):
+		log.Printf("Timeout while serving %s %s: %s", c.Request.Method, c.Request.URL.Path, err)
+		writeProblem(c, problemTimeout, timeoutMessage)
+		return
+	case isUnavailable(err):
+		log.Printf("Database unavailable while serving %s %s: %s", c.Request.Method, c.Request.URL.Path, err)
+		c.Header("Retry-After", serviceUnavailableRetryAfter)
+		writeProblem(c, problemServiceUnavailable, serviceUnavailableMessage)
+		return
+	}
+
+	log.Printf("Error while serving %s %s: %s", c.Request.Method, c.Request.URL.Path, err)
+	writeProblem(c, problemInternalError, internalErrorMessage)
+}
+
+// isUnavailable return true when the error comes from a database that cannot be reached
+func isUnavailable(err error) bool {
+	var opErr *net.OpError
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) || errors.As(err, &opErr)
+}
+
+// RouteNotFound return the problem of the requests matching no route
+func RouteNotFound() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writeProblem(c, problemRouteNotFound, "No route matches "+c.Request.Method+" "+c.Request.URL.Path)
+	}
+}
+
+// Recovery is a middleware to write the problem of the requests whose handler panicked.
+// http.ErrAbortHandler is panicked again for the server to drop the connection, as is any panic once the response
+// has started, since a truncated response must not end as a complete one.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			if err != http.ErrAbortHandler {
+				log.Printf("Panic while serving %s %s: %v\n%s", c.Request.Method, c.Request.URL.Path, err, debug.Stack())
+			}
+			if err == http.ErrAbortHandler || c.Writer.Written() {
+				c.Abort()
+				panic(http.ErrAbortHandler)
+			}
+
+			writeProblem(c, problemInternalError, internalErrorMessage)
+		}()
+
+		c.Next()
+	}
+}
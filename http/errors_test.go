@@ -0,0 +1,172 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+)
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		details errorDetails
+		want    Problem
+	}{
+		{
+			name:    "translate domain error with details",
+			err:     pensiondata.ErrFundNotFound,
+			details: errorDetails{pensiondata.ErrFundNotFound: "The fund BE0948502365 was not found"},
+			want: Problem{
+				Type:     "https://api.pensiondata.eu/problems/fund-not-found",
+				Title:    "Fund not found",
+				Status:   http.StatusNotFound,
+				Detail:   "The fund BE0948502365 was not found",
+				Instance: "/test?q=1",
+				Code:     "fund_not_found",
+			},
+		},
+		{
+			name: "translate wrapped validation error with its message",
+			err:  fmt.Errorf("%w: the price must be positive", pensiondata.ErrInvalidQuote),
+			want: Problem{
+				Type:     "https://api.pensiondata.eu/problems/invalid-quote",
+				Title:    "Invalid quote",
+				Status:   http.StatusBadRequest,
+				Detail:   "invalid quote: the price must be positive",
+				Instance: "/test?q=1",
+				Code:     "invalid_quote",
+			},
+		},
+		{
+			name: "translate domain error without details",
+			err:  pensiondata.ErrQuoteConflict,
+			want: Problem{
+				Type:     "https://api.pensiondata.eu/problems/quote-conflict",
+				Title:    "Quote conflict",
+				Status:   http.StatusConflict,
+				Instance: "/test?q=1",
+				Code:     "quote_conflict",
+			},
+		},
+		{
+			name: "translate exceeded deadline to timeout",
+			err:  fmt.Errorf("error while streaming quotes: %w", context.DeadlineExceeded),
+			want: Problem{
+				Type:     "https://api.pensiondata.eu/problems/timeout",
+				Title:    "Timeout",
+				Status:   http.StatusGatewayTimeout,
+				Detail:   timeoutMessage,
+				Instance: "/test?q=1",
+				Code:     "timeout",
+			},
+		},
+		{
+			name: "translate unreachable database to service unavailable",
+			err:  &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connect: connection refused")},
+			want: Problem{
+				Type:     "https://api.pensiondata.eu/problems/service-unavailable",
+				Title:    "Service unavailable",
+				Status:   http.StatusServiceUnavailable,
+				Detail:   serviceUnavailableMessage,
+				Instance: "/test?q=1",
+				Code:     "service_unavailable",
+			},
+		},
+		{
+			name: "hide unknown error",
+			err:  errors.New("pq: connection refused"),
+			want: Problem{
+				Type:     "https://api.pensiondata.eu/problems/internal-error",
+				Title:    "Internal error",
+				Status:   http.StatusInternalServerError,
+				Detail:   internalErrorMessage,
+				Instance: "/test?q=1",
+				Code:     "internal_error",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.GET("/test", func(c *gin.Context) {
+				writeError(c, tt.err, tt.details)
+			})
+
+			resp := httptest.NewRecorder()
+
+			req, _ := http.NewRequest(http.MethodGet, "/test?q=1", nil)
+
+			r.ServeHTTP(resp, req)
+
+			if tt.want.Status != resp.Code {
+				t.Errorf("want %d, got %d", tt.want.Status, resp.Code)
+			}
+			if contentTypeProblem != resp.Header().Get("Content-Type") {
+				t.Errorf("want %s, got %s", contentTypeProblem, resp.Header().Get("Content-Type"))
+			}
+
+			var got Problem
+			if err := json.Unmarshal(resp.Body.Bytes(), &got); err != nil {
+				t.Fatalf("want problem, got %s", resp.Body.String())
+			}
+			if !reflect.DeepEqual(tt.want, got) {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWriteErrorCanceled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/test", func(c *gin.Context) {
+		writeError(c, errors.New("pq: canceling statement due to user request"), nil)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	resp := httptest.NewRecorder()
+
+	req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+
+	r.ServeHTTP(resp, req.WithContext(ctx))
+
+	if statusClientClosedRequest != resp.Code {
+		t.Errorf("want %d, got %d", statusClientClosedRequest, resp.Code)
+	}
+	if resp.Body.Len() != 0 {
+		t.Errorf("want no problem, got %s", resp.Body.String())
+	}
+}
+
+func TestRouteNotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.NoRoute(RouteNotFound())
+
+	resp := httptest.NewRecorder()
+
+	req, _ := http.NewRequest(http.MethodGet, "/unknown", nil)
+
+	r.ServeHTTP(resp, req)
+
+	if http.StatusNotFound != resp.Code {
+		t.Errorf("want %d, got %d", http.StatusNotFound, resp.Code)
+	}
+	if contentTypeProblem != resp.Header().Get("Content-Type") {
+		t.Errorf("want %s, got %s", contentTypeProblem, resp.Header().Get("Content-Type"))
+	}
+}
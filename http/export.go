@@ -0,0 +1,550 @@
+package http
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+)
+
+// Formats the collections can be exported to, as a stream of JSON values or as downloadable files,
+// selected with the format query parameter or the Accept header
+const (
+	ExportFormatNDJSON = "ndjson"
+	ExportFormatCSV    = "csv"
+	ExportFormatXLSX   = "xlsx"
+)
+
+// Media types of the exported collections
+const (
+	ndjsonContentType = "application/x-ndjson"
+	csvContentType    = "text/csv"
+	xlsxContentType   = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// utf8BOM is written at the start of the CSV files so spreadsheets read them as UTF-8
+const utf8BOM = "\ufeff"
+
+// Date formats of the exported files
+var exportDateLayouts = map[string]string{
+	"iso": "2006-01-02",
+	"dmy": "02/01/2006",
+}
+
+// exportFormat return the format requested with the format query parameter or, when absent, with the Accept header.
+// The format is empty when the collection is to be rendered as JSON, ok is false when the requested format is
+// unknown, in which case the problem has already been written.
+func exportFormat(c *gin.Context) (format string, ok bool) {
+	switch format = requestedExportFormat(c); format {
+	case "", "json":
+		return "", true
+	case ExportFormatNDJSON, ExportFormatCSV, ExportFormatXLSX:
+		return format, true
+	default:
+		writeProblem(c, problemInvalidParameter, fmt.Sprintf("Invalid query parameter: the format %s must be json, %s, %s or %s",
+			format, ExportFormatNDJSON, ExportFormatCSV, ExportFormatXLSX))
+		return "", false
+	}
+}
+
+// requestedExportFormat return the format requested with the format query parameter or the Accept header, unchecked
+func requestedExportFormat(c *gin.Context) string {
+	if format, ok := c.GetQuery("format"); ok {
+		return format
+	}
+
+	return acceptedExportFormat(c.GetHeader("Accept"))
+}
+
+// isExport return true when the request asks for a collection exported as NDJSON, CSV or XLSX
+func isExport(c *gin.Context) bool {
+	switch requestedExportFormat(c) {
+	case ExportFormatNDJSON, ExportFormatCSV, ExportFormatXLSX:
+		return true
+	}
+
+	return false
+}
+
+// acceptedExportFormat return the export format of the first accepted media type of an exported collection, if any
+func acceptedExportFormat(accept string) string {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(mediaRange)
+		if err != nil {
+			continue
+		}
+
+		switch mediaType {
+		case ndjsonContentType:
+			return ExportFormatNDJSON
+		case csvContentType:
+			return ExportFormatCSV
+		case xlsxContentType:
+			return ExportFormatXLSX
+		}
+	}
+
+	return ""
+}
+
+// exportLocale are the options of the exported files matching the locale of the spreadsheet opening them.
+// With a decimal comma the values of the CSV files are separated by semicolons, as expected by Excel in Belgium.
+type exportLocale struct {
+	decimalComma bool
+	dateLayout   string
+}
+
+// parseExportLocale return the exportLocale from the decimal_separator (point or comma) and date_format
+// (iso for YYYY-MM-DD or dmy for DD/MM/YYYY) query parameters
+func parseExportLocale(c *gin.Context) (exportLocale, error) {
+	locale := exportLocale{dateLayout: exportDateLayouts["iso"]}
+
+	switch separator := c.Query("decimal_separator"); separator {
+	case "", "point":
+	case "comma":
+		locale.decimalComma = true
+	default:
+		return exportLocale{}, fmt.Errorf("the decimal separator %s must be point or comma", separator)
+	}
+
+	if dateFormat := c.Query("date_format"); dateFormat != "" {
+		layout, ok := exportDateLayouts[dateFormat]
+		if !ok {
+			return exportLocale{}, fmt.Errorf("the date format %s must be iso or dmy", dateFormat)
+		}
+		locale.dateLayout = layout
+	}
+
+	return locale, nil
+}
+
+// number return the decimal number with the decimal separator of the locale
+func (l exportLocale) number(value string) string {
+	if l.decimalComma {
+		return strings.Replace(value, ".", ",", 1)
+	}
+
+	return value
+}
+
+// date return the YYYY-MM-DD date in the date format of the locale
+func (l exportLocale) date(value string) string {
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return value
+	}
+
+	return date.Format(l.dateLayout)
+}
+
+// cellKind is the kind of value of an exportCell
+type cellKind int
+
+// Kinds of value of the cells, numbers are decimals using a point and dates use the YYYY-MM-DD format
+const (
+	cellText cellKind = iota
+	cellNumber
+	cellDate
+)
+
+// exportCell is a cell of an exported row, an empty value being an empty cell
+type exportCell struct {
+	kind  cellKind
+	value string
+}
+
+// textCells return the cells of the given texts, such as the names of the columns
+func textCells(texts []string) []exportCell {
+	cells := make([]exportCell, len(texts))
+	for i, text := range texts {
+		cells[i] = exportCell{kind: cellText, value: text}
+	}
+
+	return cells
+}
+
+// optionalTextCell return the cell of the text, empty when nil
+func optionalTextCell(text *string) exportCell {
+	if text == nil {
+		return exportCell{kind: cellText}
+	}
+
+	return exportCell{kind: cellText, value: *text}
+}
+
+// floatCell return the cell of the number, empty when nil
+func floatCell(number *float64) exportCell {
+	if number == nil {
+		return exportCell{kind: cellNumber}
+	}
+
+	return exportCell{kind: cellNumber, value: strconv.FormatFloat(*number, 'f', -1, 64)}
+}
+
+// intCell return the cell of the number, empty when nil
+func intCell(number *int) exportCell {
+	if number == nil {
+		return exportCell{kind: cellNumber}
+	}
+
+	return exportCell{kind: cellNumber, value: strconv.Itoa(*number)}
+}
+
+// quoteColumns are the columns of the exported quotes
+var quoteColumns = []string{"date", "price"}
+
+// quoteRow return the exported row of a pensiondata.PublicQuote, the price having the precision of the fund
+func quoteRow(value interface{}) []exportCell {
+	quote := value.(pensiondata.PublicQuote)
+	return []exportCell{{kind: cellDate, value: quote.Date}, {kind: cellNumber, value: quote.Price.String()}}
+}
+
+// fundQuoteColumns are the columns of the exported quotes of every fund
+var fundQuoteColumns = []string{"isin", "date", "price"}
+
+// fundQuoteRow return the exported row of a pensiondata.PublicFundQuote
+func fundQuoteRow(value interface{}) []exportCell {
+	quote := value.(pensiondata.PublicFundQuote)
+	return append([]exportCell{{kind: cellText, value: quote.Isin}}, quoteRow(quote.PublicQuote)...)
+}
+
+// fundColumns are the columns of the exported funds
+var fundColumns = []string{"isin", "name", "bank", "launch_date", "currency", "pricing_frequency", "price_precision",
+	"entry_fee", "ongoing_charges", "risk_class", "equity_allocation", "bond_allocation", "management_company", "benchmark"}
+
+// fundRow return the exported row of a pensiondata.PublicFund
+func fundRow(value interface{}) []exportCell {
+	fund := value.(pensiondata.PublicFund)
+	return []exportCell{
+		{kind: cellText, value: fund.Isin},
+		{kind: cellText, value: fund.Name},
+		{kind: cellText, value: fund.Bank},
+		{kind: cellDate, value: fund.LaunchDate},
+		{kind: cellText, value: fund.Currency},
+		{kind: cellText, value: fund.PricingFrequency},
+		{kind: cellNumber, value: strconv.Itoa(int(fund.PricePrecision))},
+		floatCell(fund.EntryFee),
+		floatCell(fund.OngoingCharges),
+		intCell(fund.RiskClass),
+		floatCell(fund.EquityAllocation),
+		floatCell(fund.BondAllocation),
+		optionalTextCell(fund.ManagementCompany),
+		optionalTextCell(fund.Benchmark),
+	}
+}
+
+// exportTable is a collection to be exported as NDJSON or as a table in a downloadable file.
+// Name is the name of the file without extension and of the sheet of the XLSX files,
+// row return the cells of a value of the collection.
+type exportTable struct {
+	name    string
+	columns []string
+	row     func(interface{}) []exportCell
+}
+
+// tableWriter writes the rows of an exported file, Close must be called once every row has been written
+type tableWriter interface {
+	WriteRow([]exportCell) error
+	Close() error
+}
+
+// writeExport write the values streamed by stream in the given format, as NDJSON or as the rows of a downloadable file.
+// The response is only started with the first value, so the errors returned by stream before it are written as problems
+// translated with the given details. Once started, the response can only be interrupted.
+// Writing a value fails when the client has disconnected, which stops the stream.
+func writeExport(c *gin.Context, format string, table exportTable, stream func(write func(interface{}) error) error, details errorDetails) {
+	if format == ExportFormatNDJSON {
+		writeNDJSON(c, stream, details)
+		return
+	}
+
+	locale, err := parseExportLocale(c)
+	if err != nil {
+		writeProblem(c, problemInvalidParameter, fmt.Sprintf("Invalid query parameter: %s", err))
+		return
+	}
+
+	var w tableWriter
+	start := func() error {
+		if w != nil {
+			return nil
+		}
+
+		contentType := xlsxContentType
+		if format == ExportFormatCSV {
+			contentType = csvContentType + "; charset=utf-8"
+		}
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, table.name, format))
+		c.Status(http.StatusOK)
+
+		var err error
+		if format == ExportFormatCSV {
+			w, err = newCSVTableWriter(c.Writer, locale)
+		} else {
+			w, err = newXLSXTableWriter(c.Writer, locale, table.name)
+		}
+		if err != nil {
+			return err
+		}
+
+		return w.WriteRow(textCells(table.columns))
+	}
+
+	err = stream(func(value interface{}) error {
+		if err := c.Request.Context().Err(); err != nil {
+			return err
+		}
+		if err := start(); err != nil {
+			return err
+		}
+		return w.WriteRow(table.row(value))
+	})
+	if err == nil {
+		err = start()
+	}
+	if err != nil && w == nil {
+		writeError(c, err, details)
+		return
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		abortStream(c, err)
+	}
+}
+
+// writeNDJSON write the values streamed by stream as NDJSON, each value being flushed on its own line as soon as
+// it is written, in the representation of the version and the price format of the request
+func writeNDJSON(c *gin.Context, stream func(write func(interface{}) error) error, details errorDetails) {
+	started := false
+	start := func() {
+		if !started {
+			started = true
+			c.Header("Content-Type", ndjsonContentType)
+			c.Status(http.StatusOK)
+			c.Writer.WriteHeaderNow()
+		}
+	}
+
+	err := stream(func(value interface{}) error {
+		if err := c.Request.Context().Err(); err != nil {
+			return err
+		}
+
+		line, err := marshal(c, value)
+		if err != nil {
+			return err
+		}
+
+		start()
+		if _, err := c.Writer.Write(append(line, '\n')); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil && !started {
+		writeError(c, err, details)
+		return
+	}
+	if err != nil {
+		abortStream(c, err)
+		return
+	}
+	start()
+}
+
+// abortStream log the error interrupting a started stream and drop the connection, so that the client cannot take
+// the truncated response for a complete one. The connection of a client that disconnected is already gone.
+func abortStream(c *gin.Context, err error) {
+	c.Abort()
+	if c.Request.Context().Err() == context.Canceled {
+		return
+	}
+
+	log.Printf("Error while streaming %s %s: %s", c.Request.Method, c.Request.URL.Path, err)
+	panic(http.ErrAbortHandler)
+}
+
+// csvTableWriter writes the rows of a CSV file
+type csvTableWriter struct {
+	w      *csv.Writer
+	locale exportLocale
+}
+
+// newCSVTableWriter return a tableWriter of a CSV file using the decimal separator and date format of the locale
+func newCSVTableWriter(w io.Writer, locale exportLocale) (tableWriter, error) {
+	if _, err := io.WriteString(w, utf8BOM); err != nil {
+		return nil, err
+	}
+
+	csvWriter := csv.NewWriter(w)
+	if locale.decimalComma {
+		csvWriter.Comma = ';'
+	}
+
+	return &csvTableWriter{w: csvWriter, locale: locale}, nil
+}
+
+// WriteRow write the row as a record of the CSV file
+func (w *csvTableWriter) WriteRow(cells []exportCell) error {
+	record := make([]string, len(cells))
+	for i, cell := range cells {
+		switch cell.kind {
+		case cellNumber:
+			record[i] = w.locale.number(cell.value)
+		case cellDate:
+			record[i] = w.locale.date(cell.value)
+		default:
+			record[i] = cell.value
+		}
+	}
+
+	return w.w.Write(record)
+}
+
+// Close flush the records buffered by the CSV writer
+func (w *csvTableWriter) Close() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+// Parts of the XLSX files, a workbook of a single sheet whose rows are written as they come
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+	xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+	xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+)
+
+// xlsxTableWriter writes the rows of the sheet of an XLSX file.
+// Numbers are numeric cells, whatever the locale, and dates are texts in the date format of the locale.
+type xlsxTableWriter struct {
+	zip    *zip.Writer
+	sheet  io.Writer
+	locale exportLocale
+	rows   int
+}
+
+// newXLSXTableWriter return a tableWriter of an XLSX file with a single sheet of the given name
+func newXLSXTableWriter(w io.Writer, locale exportLocale, sheetName string) (tableWriter, error) {
+	zipWriter := zip.NewWriter(w)
+
+	var name strings.Builder
+	if err := xml.EscapeText(&name, []byte(sheetName)); err != nil {
+		return nil, err
+	}
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, name.String())},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, part := range parts {
+		partWriter, err := zipWriter.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(partWriter, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := zipWriter.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(sheet, xlsxSheetStart); err != nil {
+		return nil, err
+	}
+
+	return &xlsxTableWriter{zip: zipWriter, sheet: sheet, locale: locale}, nil
+}
+
+// WriteRow write the row in the sheet, empty cells are left out
+func (w *xlsxTableWriter) WriteRow(cells []exportCell) error {
+	w.rows++
+
+	var row strings.Builder
+	fmt.Fprintf(&row, `<row r="%d">`, w.rows)
+	for i, cell := range cells {
+		if cell.value == "" {
+			continue
+		}
+
+		reference := xlsxColumn(i) + strconv.Itoa(w.rows)
+		if cell.kind == cellNumber {
+			fmt.Fprintf(&row, `<c r="%s"><v>%s</v></c>`, reference, cell.value)
+			continue
+		}
+
+		value := cell.value
+		if cell.kind == cellDate {
+			value = w.locale.date(value)
+		}
+		fmt.Fprintf(&row, `<c r="%s" t="inlineStr"><is><t>`, reference)
+		if err := xml.EscapeText(&row, []byte(value)); err != nil {
+			return err
+		}
+		row.WriteString(`</t></is></c>`)
+	}
+	row.WriteString(`</row>`)
+
+	_, err := io.WriteString(w.sheet, row.String())
+	return err
+}
+
+// Close end the sheet and write the central directory of the XLSX file
+func (w *xlsxTableWriter) Close() error {
+	if _, err := io.WriteString(w.sheet, xlsxSheetEnd); err != nil {
+		return err
+	}
+
+	return w.zip.Close()
+}
+
+// xlsxColumn return the name of the column at the given index, A for 0 up to Z, then AA and so on
+func xlsxColumn(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+
+	return name
+}
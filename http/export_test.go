@@ -0,0 +1,365 @@
+package http
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+)
+
+func TestExportQuotes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := testRouter()
+
+	var gotCriteria pensiondata.QuoteCriteria
+	quoteService := pensiondata.QuoteServiceMock{}
+	quoteService.StreamQuotesFn = func(ctx context.Context, isin string, criteria pensiondata.QuoteCriteria, fn func(pensiondata.PublicQuote) error) error {
+		gotCriteria = criteria
+		if isin == "LU0123456781" {
+			return pensiondata.ErrFundNotFound
+		}
+		for _, quote := range testPublicQuotes() {
+			if err := fn(quote); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	InitQuoteHandler(r, quoteService)
+
+	tests := []struct {
+		name        string
+		path        string
+		accept      string
+		code        int
+		contentType string
+		want        string
+	}{
+		{
+			name:        "export csv requested with the accept header",
+			path:        "/funds/BE0948502365/quotes?limit=1",
+			accept:      "text/csv",
+			code:        http.StatusOK,
+			contentType: "text/csv; charset=utf-8",
+			want:        "\ufeffdate,price\n2020-06-28,5.9900\n2020-06-27,5.9900\n",
+		},
+		{
+			name:        "export csv with the belgian locale",
+			path:        "/funds/BE0948502365/quotes?format=csv&decimal_separator=comma&date_format=dmy",
+			code:        http.StatusOK,
+			contentType: "text/csv; charset=utf-8",
+			want:        "\ufeffdate;price\n28/06/2020;5,9900\n27/06/2020;5,9900\n",
+		},
+		{
+			name:        "return error for an unknown format",
+			path:        "/funds/BE0948502365/quotes?format=pdf",
+			code:        http.StatusBadRequest,
+			contentType: contentTypeProblem,
+		},
+		{
+			name:        "return error for an unknown decimal separator",
+			path:        "/funds/BE0948502365/quotes?format=csv&decimal_separator=dot",
+			code:        http.StatusBadRequest,
+			contentType: contentTypeProblem,
+		},
+		{
+			name:        "return error before the export is started",
+			path:        "/funds/LU0123456781/quotes?format=csv",
+			code:        http.StatusNotFound,
+			contentType: contentTypeProblem,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := httptest.NewRecorder()
+
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			r.ServeHTTP(resp, req)
+
+			if tt.code != resp.Code {
+				t.Errorf("want %d, got %d", tt.code, resp.Code)
+			}
+			if tt.contentType != resp.Header().Get("Content-Type") {
+				t.Errorf("want %s, got %s", tt.contentType, resp.Header().Get("Content-Type"))
+			}
+			if tt.want != "" && tt.want != resp.Body.String() {
+				t.Errorf("want %q, got %q", tt.want, resp.Body.String())
+			}
+		})
+	}
+
+	t.Run("export every quote whatever the pagination", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/quotes?format=csv&limit=1&from=2020-06-01", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if gotCriteria.Limit != 0 || gotCriteria.From.Format("2006-01-02") != "2020-06-01" {
+			t.Errorf("want quotes from 2020-06-01 without limit, got %v", gotCriteria)
+		}
+		want := `attachment; filename="BE0948502365-quotes.csv"`
+		if want != resp.Header().Get("Content-Disposition") {
+			t.Errorf("want %s, got %s", want, resp.Header().Get("Content-Disposition"))
+		}
+	})
+
+	t.Run("export xlsx", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/quotes?format=xlsx", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusOK != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusOK, resp.Code)
+		}
+		if xlsxContentType != resp.Header().Get("Content-Type") {
+			t.Errorf("want %s, got %s", xlsxContentType, resp.Header().Get("Content-Type"))
+		}
+
+		sheet := readXLSXSheet(t, resp.Body.Bytes())
+		for _, want := range []string{
+			`<row r="1"><c r="A1" t="inlineStr"><is><t>date</t></is></c><c r="B1" t="inlineStr"><is><t>price</t></is></c></row>`,
+			`<row r="2"><c r="A2" t="inlineStr"><is><t>2020-06-28</t></is></c><c r="B2"><v>5.9900</v></c></row>`,
+		} {
+			if !strings.Contains(sheet, want) {
+				t.Errorf("want sheet containing %s, got %s", want, sheet)
+			}
+		}
+	})
+}
+
+func TestExportFunds(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := testRouter()
+
+	riskClass := 3
+	name := `Fonds "Épargne"`
+	fundService := pensiondata.FundServiceMock{}
+	fundService.StreamFundsFn = func(ctx context.Context, criteria pensiondata.FundCriteria, fn func(pensiondata.PublicFund) error) error {
+		return fn(pensiondata.PublicFund{Isin: "BE0948502365", Name: name, Bank: "Banka", LaunchDate: "2000-01-31",
+			Currency: "EUR", PricingFrequency: "daily", PricePrecision: 2, RiskClass: &riskClass})
+	}
+
+	InitFundHandler(r, fundService)
+
+	resp := httptest.NewRecorder()
+
+	req, _ := http.NewRequest(http.MethodGet, "/funds?format=csv", nil)
+
+	r.ServeHTTP(resp, req)
+
+	if http.StatusOK != resp.Code {
+		t.Errorf("want %d, got %d", http.StatusOK, resp.Code)
+	}
+	want := "\ufeffisin,name,bank,launch_date,currency,pricing_frequency,price_precision,entry_fee,ongoing_charges," +
+		"risk_class,equity_allocation,bond_allocation,management_company,benchmark\n" +
+		"BE0948502365,\"Fonds \"\"Épargne\"\"\",Banka,2000-01-31,EUR,daily,2,,,3,,,,\n"
+	if want != resp.Body.String() {
+		t.Errorf("want %q, got %q", want, resp.Body.String())
+	}
+	if `attachment; filename="funds.csv"` != resp.Header().Get("Content-Disposition") {
+		t.Errorf("want funds.csv attachment, got %s", resp.Header().Get("Content-Disposition"))
+	}
+}
+
+func TestStreamNDJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := testRouter()
+
+	quoteService := pensiondata.QuoteServiceMock{}
+	quoteService.StreamAllQuotesFn = func(ctx context.Context, fn func(pensiondata.PublicFundQuote) error) error {
+		for _, isin := range []string{"BE0948502365", "LU0123456781"} {
+			if err := fn(pensiondata.PublicFundQuote{Isin: isin, PublicQuote: testPublicQuote()}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	quoteService.StreamQuotesFn = func(ctx context.Context, isin string, criteria pensiondata.QuoteCriteria, fn func(pensiondata.PublicQuote) error) error {
+		return pensiondata.ErrFundNotFound
+	}
+
+	InitQuoteHandler(r.Group("/v1", Version(V1)), quoteService)
+
+	tests := []struct {
+		name   string
+		path   string
+		accept string
+		code   int
+		want   string
+	}{
+		{
+			name: "stream the quotes of every fund by default",
+			path: "/v1/quotes",
+			code: http.StatusOK,
+			want: `{"isin":"BE0948502365","date":"2020-06-28","price":5.99}` + "\n" +
+				`{"isin":"LU0123456781","date":"2020-06-28","price":5.99}` + "\n",
+		},
+		{
+			name:   "stream in the price format of the request",
+			path:   "/v1/quotes?price_format=string",
+			accept: ndjsonContentType,
+			code:   http.StatusOK,
+			want: `{"isin":"BE0948502365","date":"2020-06-28","price":"5.9900"}` + "\n" +
+				`{"isin":"LU0123456781","date":"2020-06-28","price":"5.9900"}` + "\n",
+		},
+		{
+			name:   "return error before the stream is started",
+			path:   "/v1/funds/BE0948502365/quotes",
+			accept: ndjsonContentType,
+			code:   http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := httptest.NewRecorder()
+
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			r.ServeHTTP(resp, req)
+
+			if tt.code != resp.Code {
+				t.Errorf("want %d, got %d", tt.code, resp.Code)
+			}
+			if tt.want == "" {
+				return
+			}
+			if ndjsonContentType != resp.Header().Get("Content-Type") {
+				t.Errorf("want %s, got %s", ndjsonContentType, resp.Header().Get("Content-Type"))
+			}
+			if tt.want != resp.Body.String() {
+				t.Errorf("want %s, got %s", tt.want, resp.Body.String())
+			}
+			if !resp.Flushed {
+				t.Errorf("want rows flushed")
+			}
+		})
+	}
+}
+
+func TestStreamStopsOnDisconnect(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := testRouter()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var gotErr error
+	quoteService := pensiondata.QuoteServiceMock{}
+	quoteService.StreamQuotesFn = func(ctx context.Context, isin string, criteria pensiondata.QuoteCriteria, fn func(pensiondata.PublicQuote) error) error {
+		if err := fn(testPublicQuote()); err != nil {
+			return err
+		}
+		cancel()
+		gotErr = fn(testPublicQuote())
+		if ctx.Err() == nil {
+			t.Errorf("want the context of the request")
+		}
+		return gotErr
+	}
+
+	InitQuoteHandler(r, quoteService)
+
+	resp := httptest.NewRecorder()
+
+	req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/quotes?format=ndjson", nil)
+
+	r.ServeHTTP(resp, req.WithContext(ctx))
+
+	if gotErr != context.Canceled {
+		t.Errorf("want %v, got %v", context.Canceled, gotErr)
+	}
+	if want := `{"date":"2020-06-28","price":5.99}` + "\n"; want != resp.Body.String() {
+		t.Errorf("want %s, got %s", want, resp.Body.String())
+	}
+}
+
+func TestStreamDropsConnectionOnError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Recovery())
+
+	quoteService := pensiondata.QuoteServiceMock{}
+	quoteService.StreamQuotesFn = func(ctx context.Context, isin string, criteria pensiondata.QuoteCriteria, fn func(pensiondata.PublicQuote) error) error {
+		if err := fn(testPublicQuote()); err != nil {
+			return err
+		}
+		return errors.New("internal error")
+	}
+
+	InitQuoteHandler(r, quoteService)
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	for _, format := range []string{ExportFormatNDJSON, ExportFormatCSV} {
+		t.Run(format, func(t *testing.T) {
+			resp, err := http.Get(server.URL + "/funds/BE0948502365/quotes?format=" + format)
+			if err != nil {
+				return
+			}
+			defer resp.Body.Close()
+
+			if _, err := ioutil.ReadAll(resp.Body); err == nil {
+				t.Errorf("want the truncated response to fail")
+			}
+		})
+	}
+}
+
+func TestXLSXColumn(t *testing.T) {
+	for index, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if got := xlsxColumn(index); want != got {
+			t.Errorf("want %s, got %s", want, got)
+		}
+	}
+}
+
+// readXLSXSheet return the content of the sheet of the XLSX file
+func readXLSXSheet(t *testing.T, data []byte) string {
+	t.Helper()
+
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("want zip, got %s", err)
+	}
+
+	for _, file := range reader.File {
+		if file.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("want sheet, got %s", err)
+		}
+		defer rc.Close()
+		content, err := ioutil.ReadAll(rc)
+		if err != nil {
+			t.Fatalf("want sheet, got %s", err)
+		}
+		return string(content)
+	}
+
+	t.Fatalf("want sheet in the XLSX file")
+	return ""
+}
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/obawi/pensiondata-api"
@@ -16,47 +18,149 @@ type FundHandler struct {
 }
 
 // InitFundHandler initialize a new FundHandler and register routes
-func InitFundHandler(router *gin.Engine, service pensiondata.FundService) {
+func InitFundHandler(router gin.IRouter, service pensiondata.FundService) {
 	h := &FundHandler{s: service}
 
 	// setup routes
 	router.GET("/funds", h.GetFunds())
 	router.GET("/funds/:isin", h.GetFundByISIN())
+	router.GET("/funds/:isin/details", h.GetFundDetailsHistory())
+	router.POST("/funds/:isin/details", ScopeRequired(pensiondata.ScopeFundsWrite), h.CreateFundDetails())
 }
 
-// GetFunds return all funds
+// GetFunds return the funds matching the filters and sort given in query.
+// The funds are streamed as NDJSON or exported as a CSV or XLSX file when requested with the format query parameter
+// or the Accept header.
 func (h FundHandler) GetFunds() gin.HandlerFunc {
 	return func(context *gin.Context) {
-		publicFunds, err := h.s.GetFunds()
+		criteria, err := parseFundCriteria(context)
 		if err != nil {
-			log.Printf("Error while listing funds: %s", err)
-			context.JSON(http.StatusInternalServerError, gin.H{
-				"error":   http.StatusInternalServerError,
-				"message": internalErrorMessage,
-			})
+			writeProblem(context, problemInvalidParameter, fmt.Sprintf("Invalid query parameter: %s", err))
+			return
+		}
+
+		format, ok := exportFormat(context)
+		if !ok {
+			return
+		}
+
+		details := errorDetails{
+			pensiondata.ErrInvalidCriteria: fmt.Sprintf("The sort must be one of name, bank, launch_date, currency or risk_class, "+
+				"the order asc or desc and the risk class between %d and %d", pensiondata.MinRiskClass, pensiondata.MaxRiskClass),
+		}
+
+		if format != "" {
+			table := exportTable{name: "funds", columns: fundColumns, row: fundRow}
+			writeExport(context, format, table, func(write func(interface{}) error) error {
+				return h.s.StreamFunds(context.Request.Context(), criteria, func(fund pensiondata.PublicFund) error {
+					return write(fund)
+				})
+			}, details)
+			return
+		}
+
+		publicFunds, err := h.s.SearchFunds(context.Request.Context(), criteria)
+		if err != nil {
+			writeError(context, err, details)
 			return
 		}
-		context.JSON(http.StatusOK, publicFunds)
+		render(context, http.StatusOK, publicFunds)
 	}
 }
 
+// parseFundCriteria return the FundCriteria from the bank, currency, launched_before, launched_after,
+// risk_class, q, sort and order query parameters
+func parseFundCriteria(context *gin.Context) (pensiondata.FundCriteria, error) {
+	criteria := pensiondata.FundCriteria{
+		Bank:     context.Query("bank"),
+		Currency: context.Query("currency"),
+		Query:    context.Query("q"),
+		Sort:     strings.ToLower(context.Query("sort")),
+		Order:    strings.ToLower(context.Query("order")),
+	}
+	var err error
+
+	if launchedBefore := context.Query("launched_before"); launchedBefore != "" {
+		if criteria.LaunchedBefore, err = time.Parse("2006-01-02", launchedBefore); err != nil {
+			return pensiondata.FundCriteria{}, fmt.Errorf("the launched_before date %s must use the YYYY-MM-DD format", launchedBefore)
+		}
+	}
+
+	if launchedAfter := context.Query("launched_after"); launchedAfter != "" {
+		if criteria.LaunchedAfter, err = time.Parse("2006-01-02", launchedAfter); err != nil {
+			return pensiondata.FundCriteria{}, fmt.Errorf("the launched_after date %s must use the YYYY-MM-DD format", launchedAfter)
+		}
+	}
+
+	if riskClass := context.Query("risk_class"); riskClass != "" {
+		if criteria.RiskClass, err = strconv.Atoi(riskClass); err != nil {
+			return pensiondata.FundCriteria{}, fmt.Errorf("the risk class %s must be a number", riskClass)
+		}
+	}
+
+	return criteria, nil
+}
+
 // GetFundByISIN return the fund for the given isin
 func (h FundHandler) GetFundByISIN() gin.HandlerFunc {
 	return func(context *gin.Context) {
-		isin := strings.ToUpper(context.Params.ByName("isin"))
-		publicFund, err := h.s.GetFundByISIN(isin)
+		isin, ok := isinParam(context)
+		if !ok {
+			return
+		}
+		publicFund, err := h.s.GetFundByISIN(context.Request.Context(), isin)
+		if err != nil {
+			writeError(context, err, errorDetails{
+				pensiondata.ErrFundNotFound: fmt.Sprintf("The fund %s was not found", isin),
+			})
+			return
+		}
+		render(context, http.StatusOK, publicFund)
+	}
+}
+
+// GetFundDetailsHistory return every version of the details of the given fund
+func (h FundHandler) GetFundDetailsHistory() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		isin, ok := isinParam(context)
+		if !ok {
+			return
+		}
+		publicHistory, err := h.s.GetFundDetailsHistory(context.Request.Context(), isin)
 		if err != nil {
-			if err == pensiondata.ErrFundNotFound {
-				context.JSON(http.StatusNotFound, gin.H{
-					"error":   http.StatusNotFound,
-					"message": fmt.Sprintf("The fund %s was not found", isin),
-				})
-				return
-			}
-			log.Printf("Error while getting fund with isin %s: %s", isin, err)
-			context.JSON(http.StatusInternalServerError, gin.H{"message": internalErrorMessage})
+			writeError(context, err, errorDetails{
+				pensiondata.ErrFundNotFound: fmt.Sprintf("The fund %s was not found", isin),
+			})
+			return
+		}
+		render(context, http.StatusOK, publicHistory)
+	}
+}
+
+// CreateFundDetails create a new version of the details of the given fund
+func (h FundHandler) CreateFundDetails() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		isin, ok := isinParam(context)
+		if !ok {
+			return
+		}
+
+		var createDetails pensiondata.ScraperCreateFundDetails
+		if err := context.ShouldBindJSON(&createDetails); err != nil {
+			log.Printf("Error while binding request body to ScraperCreateFundDetails struct %v: %s", createDetails, err)
+			writeProblem(context, problemInvalidBody, "The request body must be valid fund details")
 			return
 		}
-		context.JSON(http.StatusOK, publicFund)
+
+		publicDetails, err := h.s.CreateFundDetails(context.Request.Context(), isin, createDetails)
+		if err != nil {
+			writeError(context, err, errorDetails{
+				pensiondata.ErrFundNotFound:            fmt.Sprintf("The fund %s was not found", isin),
+				pensiondata.ErrFundDetailsAlreadyExist: fmt.Sprintf("The details of fund %s already exist from %s", isin, createDetails.ValidFrom),
+			})
+			return
+		}
+
+		render(context, http.StatusCreated, publicDetails)
 	}
 }
@@ -1,9 +1,13 @@
 package http
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -12,13 +16,15 @@ import (
 
 const contentTypeJson = "application/json; charset=utf-8"
 
+const contentTypeProblem = "application/problem+json"
+
 func TestGetFunds(t *testing.T) {
 	t.Run("return list of funds successfully", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
-		r := gin.Default()
+		r := testRouter()
 
 		s := pensiondata.FundServiceMock{}
-		s.GetFundsFn = func() ([]pensiondata.PublicFund, error) {
+		s.SearchFundsFn = func(ctx context.Context, criteria pensiondata.FundCriteria) ([]pensiondata.PublicFund, error) {
 			return testPublicFunds(), nil
 		}
 
@@ -41,10 +47,10 @@ func TestGetFunds(t *testing.T) {
 
 	t.Run("return internal error", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
-		r := gin.Default()
+		r := testRouter()
 
 		s := pensiondata.FundServiceMock{}
-		s.GetFundsFn = func() ([]pensiondata.PublicFund, error) {
+		s.SearchFundsFn = func(ctx context.Context, criteria pensiondata.FundCriteria) ([]pensiondata.PublicFund, error) {
 			return []pensiondata.PublicFund{}, errors.New("internal error")
 		}
 
@@ -60,8 +66,85 @@ func TestGetFunds(t *testing.T) {
 		if http.StatusInternalServerError != resp.Code {
 			t.Errorf("want %d, got %d", http.StatusInternalServerError, resp.Code)
 		}
-		if contentTypeJson != resp.Header().Get("Content-Type") {
-			t.Errorf("want %s, got %s", contentTypeJson, resp.Header().Get("Content-Type"))
+		if contentTypeProblem != resp.Header().Get("Content-Type") {
+			t.Errorf("want %s, got %s", contentTypeProblem, resp.Header().Get("Content-Type"))
+		}
+	})
+}
+
+func TestGetFundsSearch(t *testing.T) {
+	t.Run("pass criteria from query", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		var got pensiondata.FundCriteria
+		s := pensiondata.FundServiceMock{}
+		s.SearchFundsFn = func(ctx context.Context, criteria pensiondata.FundCriteria) ([]pensiondata.PublicFund, error) {
+			got = criteria
+			return testPublicFunds(), nil
+		}
+
+		InitFundHandler(r, s)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/funds?bank=Banka&currency=eur&launched_before=2020-01-01"+
+			"&launched_after=2010-01-01&risk_class=3&q=%C3%A9pargne+pension&sort=LAUNCH_DATE&order=desc", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusOK != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusOK, resp.Code)
+		}
+		if got.Bank != "Banka" || got.Currency != "eur" || got.RiskClass != 3 || got.Query != "épargne pension" {
+			t.Errorf("want Banka, eur, 3 and épargne pension, got %s, %s, %d and %s", got.Bank, got.Currency, got.RiskClass, got.Query)
+		}
+		if got.LaunchedBefore.Format("2006-01-02") != "2020-01-01" || got.LaunchedAfter.Format("2006-01-02") != "2010-01-01" {
+			t.Errorf("want 2010-01-01 to 2020-01-01, got %s to %s", got.LaunchedAfter, got.LaunchedBefore)
+		}
+		if got.Sort != pensiondata.FundSortLaunchDate || got.Order != pensiondata.OrderDesc {
+			t.Errorf("want launch_date desc, got %s %s", got.Sort, got.Order)
+		}
+	})
+
+	t.Run("return bad request error for invalid query parameters", func(t *testing.T) {
+		for _, query := range []string{"launched_before=2020", "launched_after=yesterday", "risk_class=high"} {
+			gin.SetMode(gin.TestMode)
+			r := testRouter()
+
+			InitFundHandler(r, pensiondata.FundServiceMock{})
+
+			resp := httptest.NewRecorder()
+
+			req, _ := http.NewRequest(http.MethodGet, "/funds?"+query, nil)
+
+			r.ServeHTTP(resp, req)
+
+			if http.StatusBadRequest != resp.Code {
+				t.Errorf("%s: want %d, got %d", query, http.StatusBadRequest, resp.Code)
+			}
+		}
+	})
+
+	t.Run("return bad request error for invalid criteria", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		s := pensiondata.FundServiceMock{}
+		s.SearchFundsFn = func(ctx context.Context, criteria pensiondata.FundCriteria) ([]pensiondata.PublicFund, error) {
+			return []pensiondata.PublicFund{}, pensiondata.ErrInvalidCriteria
+		}
+
+		InitFundHandler(r, s)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/funds?sort=price", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusBadRequest != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusBadRequest, resp.Code)
 		}
 	})
 }
@@ -69,10 +152,10 @@ func TestGetFunds(t *testing.T) {
 func TestGetFundByISIN(t *testing.T) {
 	t.Run("return fund successfully", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
-		r := gin.Default()
+		r := testRouter()
 
 		s := pensiondata.FundServiceMock{}
-		s.GetFundByISINFn = func(isin string) (pensiondata.PublicFund, error) {
+		s.GetFundByISINFn = func(ctx context.Context, isin string) (pensiondata.PublicFund, error) {
 			return testPublicFund(), nil
 		}
 
@@ -81,7 +164,7 @@ func TestGetFundByISIN(t *testing.T) {
 		// Create a response recorder
 		resp := httptest.NewRecorder()
 
-		req, _ := http.NewRequest(http.MethodGet, "/funds/BE123", nil)
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365", nil)
 
 		r.ServeHTTP(resp, req)
 
@@ -95,10 +178,10 @@ func TestGetFundByISIN(t *testing.T) {
 
 	t.Run("return not found error", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
-		r := gin.Default()
+		r := testRouter()
 
 		s := pensiondata.FundServiceMock{}
-		s.GetFundByISINFn = func(isin string) (pensiondata.PublicFund, error) {
+		s.GetFundByISINFn = func(ctx context.Context, isin string) (pensiondata.PublicFund, error) {
 			return pensiondata.PublicFund{}, pensiondata.ErrFundNotFound
 		}
 
@@ -107,24 +190,24 @@ func TestGetFundByISIN(t *testing.T) {
 		// Create a response recorder
 		resp := httptest.NewRecorder()
 
-		req, _ := http.NewRequest(http.MethodGet, "/funds/BE123", nil)
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365", nil)
 
 		r.ServeHTTP(resp, req)
 
 		if http.StatusNotFound != resp.Code {
 			t.Errorf("want %d, got %d", http.StatusNotFound, resp.Code)
 		}
-		if contentTypeJson != resp.Header().Get("Content-Type") {
-			t.Errorf("want %s, got %s", contentTypeJson, resp.Header().Get("Content-Type"))
+		if contentTypeProblem != resp.Header().Get("Content-Type") {
+			t.Errorf("want %s, got %s", contentTypeProblem, resp.Header().Get("Content-Type"))
 		}
 	})
 
 	t.Run("return internal error", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
-		r := gin.Default()
+		r := testRouter()
 
 		s := pensiondata.FundServiceMock{}
-		s.GetFundByISINFn = func(isin string) (pensiondata.PublicFund, error) {
+		s.GetFundByISINFn = func(ctx context.Context, isin string) (pensiondata.PublicFund, error) {
 			return pensiondata.PublicFund{}, errors.New("internal error")
 		}
 
@@ -133,22 +216,139 @@ func TestGetFundByISIN(t *testing.T) {
 		// Create a response recorder
 		resp := httptest.NewRecorder()
 
-		req, _ := http.NewRequest(http.MethodGet, "/funds/BE123", nil)
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365", nil)
 
 		r.ServeHTTP(resp, req)
 
 		if http.StatusInternalServerError != resp.Code {
 			t.Errorf("want %d, got %d", http.StatusInternalServerError, resp.Code)
 		}
+		if contentTypeProblem != resp.Header().Get("Content-Type") {
+			t.Errorf("want %s, got %s", contentTypeProblem, resp.Header().Get("Content-Type"))
+		}
+	})
+
+	t.Run("return bad request error for invalid isin", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		s := pensiondata.FundServiceMock{}
+		s.GetFundByISINFn = func(ctx context.Context, isin string) (pensiondata.PublicFund, error) {
+			t.Errorf("want invalid isin rejected before the service")
+			return pensiondata.PublicFund{}, nil
+		}
+
+		InitFundHandler(r, s)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502366", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusBadRequest != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusBadRequest, resp.Code)
+		}
+		if contentTypeProblem != resp.Header().Get("Content-Type") {
+			t.Errorf("want %s, got %s", contentTypeProblem, resp.Header().Get("Content-Type"))
+		}
+		if !strings.Contains(resp.Body.String(), `"code":"invalid_input","errors":[{"field":"isin"`) {
+			t.Errorf("want error of isin field, got %s", resp.Body.String())
+		}
+	})
+}
+
+func TestGetFundDetailsHistory(t *testing.T) {
+	t.Run("return details history successfully", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		s := pensiondata.FundServiceMock{}
+		s.GetFundDetailsHistoryFn = func(ctx context.Context, isin string) ([]pensiondata.PublicFundDetails, error) {
+			return []pensiondata.PublicFundDetails{{ValidFrom: "2021-01-01"}}, nil
+		}
+
+		InitFundHandler(r, s)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/details", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusOK != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusOK, resp.Code)
+		}
 		if contentTypeJson != resp.Header().Get("Content-Type") {
 			t.Errorf("want %s, got %s", contentTypeJson, resp.Header().Get("Content-Type"))
 		}
 	})
+
+	t.Run("return not found error", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		s := pensiondata.FundServiceMock{}
+		s.GetFundDetailsHistoryFn = func(ctx context.Context, isin string) ([]pensiondata.PublicFundDetails, error) {
+			return []pensiondata.PublicFundDetails{}, pensiondata.ErrFundNotFound
+		}
+
+		InitFundHandler(r, s)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/details", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusNotFound != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusNotFound, resp.Code)
+		}
+	})
+}
+
+func TestCreateFundDetails(t *testing.T) {
+	tests := map[string]struct {
+		err  error
+		want int
+	}{
+		"create details successfully": {nil, http.StatusCreated},
+		"return bad request error":    {fmt.Errorf("%w: invalid risk class", pensiondata.ErrInvalidFundDetails), http.StatusBadRequest},
+		"return not found error":      {pensiondata.ErrFundNotFound, http.StatusNotFound},
+		"return conflict error":       {pensiondata.ErrFundDetailsAlreadyExist, http.StatusConflict},
+		"return internal error":       {errors.New("internal error"), http.StatusInternalServerError},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := testRouter()
+
+			s := pensiondata.FundServiceMock{}
+			s.CreateFundDetailsFn = func(ctx context.Context, isin string, details pensiondata.ScraperCreateFundDetails) (pensiondata.PublicFundDetails, error) {
+				return pensiondata.PublicFundDetails{ValidFrom: details.ValidFrom}, test.err
+			}
+
+			InitFundHandler(r, s)
+
+			resp := httptest.NewRecorder()
+
+			body := bytes.NewBufferString(`{"valid_from": "2021-01-01", "entry_fee": 0.03, "risk_class": 4}`)
+			req, _ := http.NewRequest(http.MethodPost, "/funds/BE0948502365/details", body)
+			req.Header.Add("Authorization", "Bearer "+testScraperKey)
+
+			r.ServeHTTP(resp, req)
+
+			if test.want != resp.Code {
+				t.Errorf("want %d, got %d", test.want, resp.Code)
+			}
+		})
+	}
 }
 
 func testPublicFund() pensiondata.PublicFund {
 	return pensiondata.PublicFund{
-		Isin:       "BE123",
+		Isin:       "BE0948502365",
 		Name:       "First Fund",
 		Bank:       "Banka",
 		LaunchDate: "2020-06-27",
@@ -159,14 +359,14 @@ func testPublicFund() pensiondata.PublicFund {
 func testPublicFunds() []pensiondata.PublicFund {
 	return []pensiondata.PublicFund{
 		{
-			Isin:       "BE123",
+			Isin:       "BE0948502365",
 			Name:       "First Fund",
 			Bank:       "Banka",
 			LaunchDate: "2020-06-27",
 			Currency:   "EUR",
 		},
 		{
-			Isin:       "LU123",
+			Isin:       "LU0123456781",
 			Name:       "Second Fund",
 			Bank:       "Banko",
 			LaunchDate: "2020-06-27",
@@ -1,20 +1,185 @@
 package http
 
 import (
-	"os"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
 )
 
-// ScraperAuthRequired is a middleware to check if the request has been send by the scraper app
-func ScraperAuthRequired() gin.HandlerFunc {
+// identityKey is the key of the gin context holding the identity that authenticated the request
+const identityKey = "identity"
+
+// apiKeyKey is the key of the gin context holding the pensiondata.APIKey that authenticated the request
+const apiKeyKey = "apiKey"
+
+// invalidAPIKeyKey is the key of the gin context set when the request sent an invalid API key
+const invalidAPIKeyKey = "invalidAPIKey"
+
+// Authenticate is a middleware to authenticate the requests sending an API key.
+// The key is read from the Authorization bearer token, the X-API-Key header or the legacy SCRAPER-KEY header.
+// Requests without a key or with an invalid key are anonymous, ScopeRequired restricts the routes needing one
+// and rejects the invalid keys. The failed authentications are limited by client IP with the authentication plan,
+// the keys of a client out of attempts are not looked up.
+func Authenticate(service pensiondata.APIKeyService, rateLimitService pensiondata.RateLimitService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := requestAPIKey(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		client := "auth:" + c.ClientIP()
+		result, err := rateLimitService.Check(client, pensiondata.RateLimitPlanAuthentication)
+		if err != nil {
+			log.Printf("Error while rate limiting %s: %s", client, err)
+		}
+		if err == nil && !result.Allowed {
+			retryAfter := ceilSeconds(result.RetryAfter)
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			writeProblem(c, problemTooManyRequests, fmt.Sprintf("Too many failed authentications, retry in %d seconds", retryAfter))
+			return
+		}
+
+		apiKey, err := service.Authenticate(c.Request.Context(), key)
+		if err == pensiondata.ErrInvalidAPIKey {
+			if _, err := rateLimitService.Allow(client, pensiondata.RateLimitPlanAuthentication); err != nil {
+				log.Printf("Error while rate limiting %s: %s", client, err)
+			}
+			c.Set(invalidAPIKeyKey, true)
+			c.Next()
+			return
+		}
+		if err != nil {
+			writeError(c, err, errorDetails{})
+			return
+		}
+
+		c.Set(apiKeyKey, apiKey)
+		c.Set(identityKey, apiKey.Identity())
+		c.Next()
+	}
+}
+
+// ScopeRequired is a middleware to check if the request has been authenticated with an API key granted the scope
+func ScopeRequired(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiKey, ok := requestAuthenticatedAPIKey(c)
+		if !ok && c.GetBool(invalidAPIKeyKey) {
+			writeProblem(c, problemInvalidAPIKey, "The API key is invalid, expired or revoked")
+			return
+		}
+		if !ok {
+			writeProblem(c, problemAPIKeyRequired, "An API key is required")
+			return
+		}
+
+		if !apiKey.HasScope(scope) {
+			writeProblem(c, problemMissingScope, "The API key is missing the "+scope+" scope")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RateLimit is a middleware to limit the requests of each API key with its plan,
+// or of each client IP with the anonymous plan for the requests without API key. The client IP is read from
+// X-Forwarded-For only for the requests coming from the trusted proxies of the router.
+// It must be registered after Authenticate. The store failing does not block the requests.
+func RateLimit(service pensiondata.RateLimitService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		scraperKey, isScraperKeySet := os.LookupEnv("SCRAPER_KEY")
-		if !isScraperKeySet || c.GetHeader("SCRAPER-KEY") != scraperKey {
-			c.AbortWithStatus(401)
+		client, plan := "ip:"+c.ClientIP(), pensiondata.RateLimitPlanAnonymous
+		if apiKey, ok := requestAuthenticatedAPIKey(c); ok {
+			client, plan = "key:"+apiKey.Prefix, apiKey.Plan
+		}
+
+		result, err := service.Allow(client, plan)
+		if err != nil {
+			log.Printf("Error while rate limiting %s: %s", client, err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			retryAfter := ceilSeconds(result.RetryAfter)
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			writeProblem(c, problemTooManyRequests, fmt.Sprintf("Too many requests, retry in %d seconds", retryAfter))
 			return
 		}
 
 		c.Next()
 	}
 }
+
+// MeterUsage is a middleware to record the key, route, ISIN, status and latency of the requests matching a route.
+// It must be registered after Authenticate, and before RateLimit to record the limited requests.
+func MeterUsage(recorder pensiondata.UsageRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		if c.FullPath() == "" {
+			return
+		}
+
+		// the ISIN of the path is only recorded once valid, the requests for an invalid one are recorded by route
+		isin := strings.ToUpper(c.Param("isin"))
+		if pensiondata.ValidateISIN(isin) != nil {
+			isin = ""
+		}
+
+		record := pensiondata.UsageRecord{
+			Route:   c.FullPath(),
+			Isin:    isin,
+			Status:  c.Writer.Status(),
+			Latency: time.Since(start),
+			At:      start,
+		}
+		if apiKey, ok := requestAuthenticatedAPIKey(c); ok {
+			record.Key = apiKey.Prefix
+		}
+
+		recorder.Record(record)
+	}
+}
+
+// ceilSeconds return the duration in seconds, rounded up so a client waiting for it is not denied again
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// requestAPIKey return the API key sent with the request, empty when there is none
+func requestAPIKey(c *gin.Context) string {
+	if authorization := c.GetHeader("Authorization"); len(authorization) > len("Bearer ") &&
+		strings.EqualFold(authorization[:len("Bearer ")], "Bearer ") {
+		return strings.TrimSpace(authorization[len("Bearer "):])
+	}
+
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+
+	return c.GetHeader("SCRAPER-KEY")
+}
+
+// requestAuthenticatedAPIKey return the API key that authenticated the request, if any
+func requestAuthenticatedAPIKey(c *gin.Context) (pensiondata.APIKey, bool) {
+	value, ok := c.Get(apiKeyKey)
+	if !ok {
+		return pensiondata.APIKey{}, false
+	}
+
+	apiKey, ok := value.(pensiondata.APIKey)
+	return apiKey, ok
+}
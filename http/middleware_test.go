@@ -1,77 +1,412 @@
 package http
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/obawi/pensiondata-api"
-	"github.com/shopspring/decimal"
 )
 
-func TestScraperAuthRequired(t *testing.T) {
-	t.Run("return unauthorized error when SCRAPER-KEY header is not set", func(t *testing.T) {
-		_ = os.Setenv("SCRAPER_KEY", "s3cr3t")
-		gin.SetMode(gin.TestMode)
-		r := gin.Default()
+const (
+	testScraperKey = "pd_0a1b2c3d_scr4p3r"
+	testAdminKey   = "pd_4e5f6a7b_4dm1n"
+)
+
+var (
+	testScraperAPIKey = pensiondata.APIKey{
+		ID: 1, Name: "scraper", Prefix: "0a1b2c3d", Scopes: []string{pensiondata.ScopeQuotesWrite, pensiondata.ScopeFundsWrite},
+	}
+	testAdminAPIKey = pensiondata.APIKey{
+		ID: 2, Name: "admin", Prefix: "4e5f6a7b", Scopes: []string{pensiondata.ScopeAdmin}, Plan: pensiondata.RateLimitPlanStandard,
+	}
+)
 
-		quoteService := pensiondata.QuoteServiceMock{}
-		quoteService.CreateQuoteFn = func(isin string, quote pensiondata.ScraperCreateQuote) (pensiondata.PublicQuote, error) {
-			return pensiondata.PublicQuote{}, errors.New("internal error")
+// testAuthenticationRateLimitService is a RateLimitService never limiting the authentications
+var testAuthenticationRateLimitService = pensiondata.RateLimitServiceMock{
+	AllowFn: func(client, plan string) (pensiondata.RateLimitResult, error) {
+		return pensiondata.RateLimitResult{Allowed: true}, nil
+	},
+	CheckFn: func(client, plan string) (pensiondata.RateLimitResult, error) {
+		return pensiondata.RateLimitResult{Allowed: true}, nil
+	},
+}
+
+// testRouter return a router authenticating the requests with testScraperKey and testAdminKey
+func testRouter() *gin.Engine {
+	apiKeyService := pensiondata.APIKeyServiceMock{}
+	apiKeyService.AuthenticateFn = func(ctx context.Context, key string) (pensiondata.APIKey, error) {
+		switch key {
+		case testScraperKey:
+			return testScraperAPIKey, nil
+		case testAdminKey:
+			return testAdminAPIKey, nil
 		}
+		return pensiondata.APIKey{}, pensiondata.ErrInvalidAPIKey
+	}
 
-		InitQuoteHandler(r, quoteService)
+	r := gin.Default()
+	r.Use(Authenticate(apiKeyService, testAuthenticationRateLimitService))
 
-		resp := httptest.NewRecorder()
+	return r
+}
 
-		scraperCreateQuote := pensiondata.ScraperCreateQuote{
-			Date:  "2020-06-30",
-			Price: decimal.NewFromFloat(7.99),
-		}
-		jsonScraperCreateQuote, _ := json.Marshal(scraperCreateQuote)
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		name         string
+		header       string
+		value        string
+		wantCode     int
+		wantIdentity string
+	}{
+		{name: "authenticate bearer token", header: "Authorization", value: "Bearer " + testScraperKey, wantCode: http.StatusOK, wantIdentity: testScraperAPIKey.Identity()},
+		{name: "authenticate X-API-Key header", header: "X-API-Key", value: testAdminKey, wantCode: http.StatusOK, wantIdentity: testAdminAPIKey.Identity()},
+		{name: "authenticate legacy SCRAPER-KEY header", header: "SCRAPER-KEY", value: testScraperKey, wantCode: http.StatusOK, wantIdentity: testScraperAPIKey.Identity()},
+		{name: "continue anonymously without key", wantCode: http.StatusOK},
+		{name: "continue anonymously with invalid key", header: "Authorization", value: "Bearer s3cr3t", wantCode: http.StatusOK},
+	}
 
-		req, _ := http.NewRequest("POST", "/funds/BE123/quotes", bytes.NewBuffer(jsonScraperCreateQuote))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := testRouter()
 
-		r.ServeHTTP(resp, req)
+			var gotIdentity string
+			r.GET("/", func(c *gin.Context) {
+				gotIdentity = c.GetString(identityKey)
+				c.Status(http.StatusOK)
+			})
 
-		if http.StatusUnauthorized != resp.Code {
-			t.Errorf("want %d, got %d", http.StatusUnauthorized, resp.Code)
-		}
+			resp := httptest.NewRecorder()
 
-		_ = os.Unsetenv("SCRAPER_KEY")
-	})
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Add(tt.header, tt.value)
+			}
 
-	t.Run("return unauthorized error when SCRAPER_KEY env var is not set", func(t *testing.T) {
+			r.ServeHTTP(resp, req)
+
+			if tt.wantCode != resp.Code {
+				t.Errorf("want %d, got %d", tt.wantCode, resp.Code)
+			}
+			if tt.wantIdentity != gotIdentity {
+				t.Errorf("want identity %s, got %s", tt.wantIdentity, gotIdentity)
+			}
+		})
+	}
+
+	t.Run("return internal error", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
 		r := gin.Default()
 
-		quoteService := pensiondata.QuoteServiceMock{}
-		quoteService.CreateQuoteFn = func(isin string, quote pensiondata.ScraperCreateQuote) (pensiondata.PublicQuote, error) {
-			return pensiondata.PublicQuote{}, errors.New("internal error")
+		apiKeyService := pensiondata.APIKeyServiceMock{}
+		apiKeyService.AuthenticateFn = func(ctx context.Context, key string) (pensiondata.APIKey, error) {
+			return pensiondata.APIKey{}, errors.New("internal error")
 		}
-
-		InitQuoteHandler(r, quoteService)
+		r.Use(Authenticate(apiKeyService, testAuthenticationRateLimitService))
+		r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
 
 		resp := httptest.NewRecorder()
 
-		scraperCreateQuote := pensiondata.ScraperCreateQuote{
-			Date:  "2020-06-30",
-			Price: decimal.NewFromFloat(7.99),
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Add("Authorization", "Bearer "+testScraperKey)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusInternalServerError != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusInternalServerError, resp.Code)
 		}
-		jsonScraperCreateQuote, _ := json.Marshal(scraperCreateQuote)
+	})
+}
+
+func TestAuthenticateRateLimit(t *testing.T) {
+	tests := []struct {
+		name        string
+		key         string
+		allowed     bool
+		wantCode    int
+		wantLookup  bool
+		wantCharged bool
+	}{
+		{name: "charge failed authentication to the client IP", key: "s3cr3t", allowed: true, wantCode: http.StatusOK, wantLookup: true, wantCharged: true},
+		{name: "do not charge successful authentication", key: testScraperKey, allowed: true, wantCode: http.StatusOK, wantLookup: true},
+		{name: "return too many requests error without lookup for client out of attempts", key: testScraperKey, wantCode: http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := gin.Default()
+
+			var gotLookup, gotCharged bool
+			apiKeyService := pensiondata.APIKeyServiceMock{}
+			apiKeyService.AuthenticateFn = func(ctx context.Context, key string) (pensiondata.APIKey, error) {
+				gotLookup = true
+				if key == testScraperKey {
+					return testScraperAPIKey, nil
+				}
+				return pensiondata.APIKey{}, pensiondata.ErrInvalidAPIKey
+			}
+			rateLimitService := pensiondata.RateLimitServiceMock{}
+			rateLimitService.CheckFn = func(client, plan string) (pensiondata.RateLimitResult, error) {
+				if client != "auth:192.0.2.1" || plan != pensiondata.RateLimitPlanAuthentication {
+					t.Errorf("want auth:192.0.2.1 checked with authentication plan, got %s with %s plan", client, plan)
+				}
+				return pensiondata.RateLimitResult{Allowed: tt.allowed, RetryAfter: time.Minute}, nil
+			}
+			rateLimitService.AllowFn = func(client, plan string) (pensiondata.RateLimitResult, error) {
+				gotCharged = client == "auth:192.0.2.1" && plan == pensiondata.RateLimitPlanAuthentication
+				return pensiondata.RateLimitResult{Allowed: true}, nil
+			}
+
+			r.Use(Authenticate(apiKeyService, rateLimitService))
+			r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			resp := httptest.NewRecorder()
+
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			req.Header.Add("Authorization", "Bearer "+tt.key)
+
+			r.ServeHTTP(resp, req)
+
+			if tt.wantCode != resp.Code {
+				t.Errorf("want %d, got %d", tt.wantCode, resp.Code)
+			}
+			if tt.wantLookup != gotLookup {
+				t.Errorf("want key looked up %t, got %t", tt.wantLookup, gotLookup)
+			}
+			if tt.wantCharged != gotCharged {
+				t.Errorf("want failed authentication charged %t, got %t", tt.wantCharged, gotCharged)
+			}
+		})
+	}
+}
+
+func TestScopeRequired(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		scope string
+		want  int
+	}{
+		{name: "allow key with scope", key: testScraperKey, scope: pensiondata.ScopeQuotesWrite, want: http.StatusOK},
+		{name: "allow admin key for every scope", key: testAdminKey, scope: pensiondata.ScopeFundsWrite, want: http.StatusOK},
+		{name: "return forbidden error for key without scope", key: testScraperKey, scope: pensiondata.ScopeAdmin, want: http.StatusForbidden},
+		{name: "return unauthorized error without key", scope: pensiondata.ScopeQuotesWrite, want: http.StatusUnauthorized},
+		{name: "return unauthorized error for invalid key", key: "s3cr3t", scope: pensiondata.ScopeQuotesWrite, want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := testRouter()
+			r.GET("/", ScopeRequired(tt.scope), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			resp := httptest.NewRecorder()
+
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			if tt.key != "" {
+				req.Header.Add("Authorization", "Bearer "+tt.key)
+			}
 
-		req, _ := http.NewRequest("POST", "/funds/BE123/quotes", bytes.NewBuffer(jsonScraperCreateQuote))
-		req.Header.Add("SCRAPER-KEY", "s3cr3t")
+			r.ServeHTTP(resp, req)
+
+			if tt.want != resp.Code {
+				t.Errorf("want %d, got %d", tt.want, resp.Code)
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	allowed := func(client, plan string) (pensiondata.RateLimitResult, error) {
+		return pensiondata.RateLimitResult{Allowed: true, Limit: 60, Remaining: 59, Reset: time.Second}, nil
+	}
+
+	tests := []struct {
+		name           string
+		key            string
+		forwarded      string
+		trustedProxies []string
+		allowFn        func(string, string) (pensiondata.RateLimitResult, error)
+		wantClient     string
+		wantPlan       string
+		wantCode       int
+	}{
+		{
+			name:       "limit anonymous request by client IP",
+			allowFn:    allowed,
+			wantClient: "ip:192.0.2.1",
+			wantPlan:   pensiondata.RateLimitPlanAnonymous,
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:       "ignore forwarded address of untrusted remote",
+			forwarded:  "198.51.100.1",
+			allowFn:    allowed,
+			wantClient: "ip:192.0.2.1",
+			wantPlan:   pensiondata.RateLimitPlanAnonymous,
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:           "limit request behind trusted proxy by last address not set by a trusted proxy",
+			forwarded:      "203.0.113.7, 198.51.100.1, 192.0.2.2",
+			trustedProxies: []string{"192.0.2.0/24"},
+			allowFn:        allowed,
+			wantClient:     "ip:198.51.100.1",
+			wantPlan:       pensiondata.RateLimitPlanAnonymous,
+			wantCode:       http.StatusOK,
+		},
+		{
+			name: "limit request by API key with its plan",
+			key:  testAdminKey,
+			allowFn: func(client, plan string) (pensiondata.RateLimitResult, error) {
+				return pensiondata.RateLimitResult{Allowed: true, Limit: 600, Remaining: 599, Reset: 100 * time.Millisecond}, nil
+			},
+			wantClient: "key:" + testAdminAPIKey.Prefix,
+			wantPlan:   pensiondata.RateLimitPlanStandard,
+			wantCode:   http.StatusOK,
+		},
+		{
+			name: "return too many requests error for empty bucket",
+			allowFn: func(client, plan string) (pensiondata.RateLimitResult, error) {
+				return pensiondata.RateLimitResult{Limit: 60, Reset: time.Minute, RetryAfter: 1500 * time.Millisecond}, nil
+			},
+			wantClient: "ip:192.0.2.1",
+			wantPlan:   pensiondata.RateLimitPlanAnonymous,
+			wantCode:   http.StatusTooManyRequests,
+		},
+		{
+			name: "allow request when the store fails",
+			allowFn: func(client, plan string) (pensiondata.RateLimitResult, error) {
+				return pensiondata.RateLimitResult{}, errors.New("internal error")
+			},
+			wantClient: "ip:192.0.2.1",
+			wantPlan:   pensiondata.RateLimitPlanAnonymous,
+			wantCode:   http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := testRouter()
+			if err := r.SetTrustedProxies(tt.trustedProxies); err != nil {
+				t.Fatalf("want no error, got %s", err)
+			}
+
+			var gotClient, gotPlan string
+			r.Use(RateLimit(pensiondata.RateLimitServiceMock{AllowFn: func(client, plan string) (pensiondata.RateLimitResult, error) {
+				gotClient, gotPlan = client, plan
+				return tt.allowFn(client, plan)
+			}}))
+			r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			resp := httptest.NewRecorder()
+
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			if tt.key != "" {
+				req.Header.Add("Authorization", "Bearer "+tt.key)
+			}
+			if tt.forwarded != "" {
+				req.Header.Add("X-Forwarded-For", tt.forwarded)
+			}
+
+			r.ServeHTTP(resp, req)
+
+			if tt.wantCode != resp.Code {
+				t.Errorf("want %d, got %d", tt.wantCode, resp.Code)
+			}
+			if tt.wantClient != gotClient || tt.wantPlan != gotPlan {
+				t.Errorf("want %s limited with %s plan, got %s with %s plan", tt.wantClient, tt.wantPlan, gotClient, gotPlan)
+			}
+		})
+	}
+
+	t.Run("send rate limit headers", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+		r.Use(RateLimit(pensiondata.RateLimitServiceMock{AllowFn: func(client, plan string) (pensiondata.RateLimitResult, error) {
+			return pensiondata.RateLimitResult{Limit: 60, Remaining: 0, Reset: time.Minute, RetryAfter: 1500 * time.Millisecond}, nil
+		}}))
+		r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
 
 		r.ServeHTTP(resp, req)
 
-		if http.StatusUnauthorized != resp.Code {
-			t.Errorf("want %d, got %d", http.StatusUnauthorized, resp.Code)
+		want := map[string]string{"RateLimit-Limit": "60", "RateLimit-Remaining": "0", "RateLimit-Reset": "60", "Retry-After": "2"}
+		for header, value := range want {
+			if got := resp.Header().Get(header); got != value {
+				t.Errorf("want %s %s, got %s", header, value, got)
+			}
+		}
+		if contentTypeProblem != resp.Header().Get("Content-Type") {
+			t.Errorf("want %s, got %s", contentTypeProblem, resp.Header().Get("Content-Type"))
+		}
+		if want := fmt.Sprintf(`{"type":"https://api.pensiondata.eu/problems/too-many-requests","title":"Too many requests",`+
+			`"status":%d,"detail":"Too many requests, retry in 2 seconds","instance":"/","code":"too_many_requests"}`,
+			http.StatusTooManyRequests); resp.Body.String() != want {
+			t.Errorf("want %s, got %s", want, resp.Body.String())
+		}
+	})
+}
+
+func TestMeterUsage(t *testing.T) {
+	t.Run("record request with its route template", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		var got []pensiondata.UsageRecord
+		r.Use(MeterUsage(pensiondata.UsageRecorderMock{RecordFn: func(record pensiondata.UsageRecord) {
+			got = append(got, record)
+		}}))
+		r.GET("/funds/:isin/quotes", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+		for _, path := range []string{"/funds/be0948502365/quotes", "/unknown", "/funds/BE09485023650000/quotes"} {
+			req, _ := http.NewRequest(http.MethodGet, path, nil)
+			req.Header.Add("Authorization", "Bearer "+testScraperKey)
+			r.ServeHTTP(httptest.NewRecorder(), req)
+		}
+
+		if len(got) != 2 {
+			t.Fatalf("want only the requests matching a route recorded, got %v", got)
+		}
+		if got[1].Isin != "" || got[1].Route != "/funds/:isin/quotes" {
+			t.Errorf("want request for an invalid ISIN recorded by route only, got %v", got[1])
+		}
+		if got[0].Key != testScraperAPIKey.Prefix || got[0].Route != "/funds/:isin/quotes" || got[0].Isin != "BE0948502365" ||
+			got[0].Status != http.StatusNoContent {
+			t.Errorf("want request of %s on /funds/:isin/quotes for BE0948502365, got %v", testScraperAPIKey.Prefix, got[0])
 		}
 	})
 }
+
+func TestRecovery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Recovery())
+	r.GET("/", func(c *gin.Context) { panic("internal error") })
+
+	resp := httptest.NewRecorder()
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	r.ServeHTTP(resp, req)
+
+	if http.StatusInternalServerError != resp.Code {
+		t.Errorf("want %d, got %d", http.StatusInternalServerError, resp.Code)
+	}
+	if contentTypeProblem != resp.Header().Get("Content-Type") {
+		t.Errorf("want %s, got %s", contentTypeProblem, resp.Header().Get("Content-Type"))
+	}
+}
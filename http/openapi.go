@@ -0,0 +1,26 @@
+package http
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// openAPI is the OpenAPI 3 document describing every route of the API.
+// TestOpenAPICoversRoutes fails when a route is registered without being described.
+//
+//go:embed openapi.json
+var openAPI []byte
+
+// InitOpenAPIHandler register the route serving the OpenAPI document
+func InitOpenAPIHandler(router gin.IRouter) {
+	router.GET("/openapi.json", GetOpenAPI())
+}
+
+// GetOpenAPI return the OpenAPI document of the API
+func GetOpenAPI() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		context.Data(http.StatusOK, "application/json; charset=utf-8", openAPI)
+	}
+}
@@ -0,0 +1,212 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+)
+
+// openAPIDocument is the part of the OpenAPI document checked by the tests
+type openAPIDocument struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]openAPISchema `json:"schemas"`
+	} `json:"components"`
+}
+
+// openAPISchema is the part of an OpenAPI schema checked by the tests
+type openAPISchema struct {
+	Ref        string                   `json:"$ref"`
+	AllOf      []openAPISchema          `json:"allOf"`
+	Properties map[string]openAPISchema `json:"properties"`
+}
+
+// testOpenAPIRouter return a router with the routes of every handler, registered by InitRoutes like in cmd/api
+func testOpenAPIRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	InitRoutes(r, Services{
+		APIKey:      pensiondata.APIKeyServiceMock{},
+		Usage:       pensiondata.UsageServiceMock{},
+		Fund:        pensiondata.FundServiceMock{},
+		Quote:       pensiondata.QuoteServiceMock{},
+		Quarantine:  pensiondata.QuarantineServiceMock{},
+		DataQuality: pensiondata.DataQualityServiceMock{},
+		Performance: pensiondata.PerformanceServiceMock{},
+		Risk:        pensiondata.RiskServiceMock{},
+		Simulation:  pensiondata.SimulationServiceMock{},
+		Backtest:    pensiondata.BacktestServiceMock{},
+		Compare:     pensiondata.CompareServiceMock{},
+	})
+
+	return r
+}
+
+func testOpenAPIDocument(t *testing.T) openAPIDocument {
+	var document openAPIDocument
+	if err := json.Unmarshal(openAPI, &document); err != nil {
+		t.Fatalf("want valid OpenAPI document, got %s", err)
+	}
+
+	return document
+}
+
+// openAPIPath return the OpenAPI path of a gin route, /funds/:isin becomes /funds/{isin}
+func openAPIPath(route string) string {
+	segments := strings.Split(route, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+func TestGetOpenAPI(t *testing.T) {
+	r := testOpenAPIRouter()
+
+	resp := httptest.NewRecorder()
+
+	req, _ := http.NewRequest(http.MethodGet, "/openapi.json", nil)
+
+	r.ServeHTTP(resp, req)
+
+	if http.StatusOK != resp.Code {
+		t.Errorf("want %d, got %d", http.StatusOK, resp.Code)
+	}
+	if contentTypeJson != resp.Header().Get("Content-Type") {
+		t.Errorf("want %s, got %s", contentTypeJson, resp.Header().Get("Content-Type"))
+	}
+}
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	document := testOpenAPIDocument(t)
+	routes := testOpenAPIRouter().Routes()
+
+	registered := map[string]bool{}
+	for _, route := range routes {
+		path, method := openAPIPath(route.Path), strings.ToLower(route.Method)
+		registered[method+" "+path] = true
+
+		if _, ok := document.Paths[path][method]; !ok {
+			t.Errorf("want %s %s described in openapi.json", route.Method, path)
+		}
+	}
+
+	for path, operations := range document.Paths {
+		for method := range operations {
+			if method == "parameters" {
+				continue
+			}
+			if !registered[method+" "+path] {
+				t.Errorf("want %s %s of openapi.json registered as a route", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+func TestOpenAPISchemas(t *testing.T) {
+	document := testOpenAPIDocument(t)
+
+	types := map[string]interface{}{
+		"PublicFund":                      pensiondata.PublicFund{},
+		"PublicFundDetails":               pensiondata.PublicFundDetails{},
+		"ScraperCreateFundDetails":        pensiondata.ScraperCreateFundDetails{},
+		"PublicQuote":                     pensiondata.PublicQuote{},
+		"PublicQuoteWithProvenance":       pensiondata.PublicQuoteWithProvenance{},
+		"PublicFundQuote":                 pensiondata.PublicFundQuote{},
+		"PublicProvenance":                pensiondata.PublicProvenance{},
+		"ScraperCreateQuote":              pensiondata.ScraperCreateQuote{},
+		"ScraperCorrectQuote":             pensiondata.ScraperCorrectQuote{},
+		"ScraperBatchQuote":               pensiondata.ScraperBatchQuote{},
+		"PublicQuoteBatch":                pensiondata.PublicQuoteBatch{},
+		"PublicQuoteBatchResult":          pensiondata.PublicQuoteBatchResult{},
+		"PublicPerformance":               pensiondata.PublicPerformance{},
+		"PublicPeriodReturn":              pensiondata.PublicPeriodReturn{},
+		"PublicRisk":                      pensiondata.PublicRisk{},
+		"PublicDrawdown":                  pensiondata.PublicDrawdown{},
+		"PublicBacktest":                  pensiondata.PublicBacktest{},
+		"PublicBacktestPurchase":          pensiondata.PublicBacktestPurchase{},
+		"PublicComparison":                pensiondata.PublicComparison{},
+		"PublicComparisonSeries":          pensiondata.PublicComparisonSeries{},
+		"PublicComparisonError":           pensiondata.PublicComparisonError{},
+		"PensionSavingsSimulationRequest": pensiondata.PensionSavingsSimulationRequest{},
+		"PublicPensionSavingsSimulation":  pensiondata.PublicPensionSavingsSimulation{},
+		"PublicPensionSavingsYear":        pensiondata.PublicPensionSavingsYear{},
+		"PublicQuarantinedQuote":          pensiondata.PublicQuarantinedQuote{},
+		"PublicDataQualityReport":         pensiondata.PublicDataQualityReport{},
+		"PublicStaleFund":                 pensiondata.PublicStaleFund{},
+		"PublicQuoteGap":                  pensiondata.PublicQuoteGap{},
+		"AdminCreateAPIKey":               pensiondata.AdminCreateAPIKey{},
+		"PublicAPIKey":                    pensiondata.PublicAPIKey{},
+		"PublicCreatedAPIKey":             pensiondata.PublicCreatedAPIKey{},
+		"PublicUsage":                     pensiondata.PublicUsage{},
+		"Problem":                         Problem{},
+	}
+
+	for name, value := range types {
+		t.Run(name, func(t *testing.T) {
+			schema, ok := document.Components.Schemas[name]
+			if !ok {
+				t.Fatalf("want %s schema in openapi.json", name)
+			}
+
+			want := jsonFields(reflect.TypeOf(value))
+			got := schemaProperties(document, schema)
+			if !reflect.DeepEqual(want, got) {
+				t.Errorf("want properties %v, got %v", want, got)
+			}
+		})
+	}
+}
+
+// jsonFields return the sorted JSON names of the fields of the struct, including its embedded structs
+func jsonFields(structType reflect.Type) []string {
+	var fields []string
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+
+		if field.Anonymous && name == "" {
+			fields = append(fields, jsonFields(field.Type)...)
+			continue
+		}
+		if name == "-" || field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+
+	return fields
+}
+
+// schemaProperties return the sorted properties of the schema, resolving its references and allOf
+func schemaProperties(document openAPIDocument, schema openAPISchema) []string {
+	if schema.Ref != "" {
+		return schemaProperties(document, document.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")])
+	}
+
+	var properties []string
+	for property := range schema.Properties {
+		properties = append(properties, property)
+	}
+	for _, part := range schema.AllOf {
+		properties = append(properties, schemaProperties(document, part)...)
+	}
+	sort.Strings(properties)
+
+	return properties
+}
@@ -0,0 +1,32 @@
+package http
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+)
+
+// isinParam return the upper-cased isin path parameter.
+// The request is aborted with a problem when it is not a valid ISIN, and false returned.
+func isinParam(context *gin.Context) (string, bool) {
+	isin := strings.ToUpper(context.Params.ByName("isin"))
+	if err := pensiondata.ValidateISIN(isin); err != nil {
+		writeError(context, err, nil)
+		return "", false
+	}
+
+	return isin, true
+}
+
+// dateParam return the date path parameter.
+// The request is aborted with a problem when it is not a valid YYYY-MM-DD day, and false returned.
+func dateParam(context *gin.Context) (string, bool) {
+	date := context.Params.ByName("date")
+	if err := pensiondata.ValidateDate("date", date); err != nil {
+		writeError(context, err, nil)
+		return "", false
+	}
+
+	return date, true
+}
@@ -0,0 +1,42 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+)
+
+// PerformanceHandler handle all the HTTP requests for the performance of a fund
+type PerformanceHandler struct {
+	s pensiondata.PerformanceService
+}
+
+// InitPerformanceHandler initialize a new PerformanceHandler and register routes
+func InitPerformanceHandler(router gin.IRouter, service pensiondata.PerformanceService) {
+	h := &PerformanceHandler{s: service}
+
+	router.GET("/funds/:isin/performance", h.GetPerformance())
+}
+
+// GetPerformance return the performance of the given fund
+func (h PerformanceHandler) GetPerformance() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		isin, ok := isinParam(context)
+		if !ok {
+			return
+		}
+		publicPerformance, err := h.s.GetPerformance(context.Request.Context(), isin)
+
+		if err != nil {
+			writeError(context, err, errorDetails{
+				pensiondata.ErrFundNotFound:  fmt.Sprintf("The fund %s was not found", isin),
+				pensiondata.ErrQuoteNotFound: fmt.Sprintf("No quotes are available for fund %s", isin),
+			})
+			return
+		}
+
+		render(context, http.StatusOK, publicPerformance)
+	}
+}
@@ -0,0 +1,108 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+)
+
+func TestGetPerformance(t *testing.T) {
+	t.Run("return performance successfully", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := gin.Default()
+
+		s := pensiondata.PerformanceServiceMock{}
+		s.GetPerformanceFn = func(ctx context.Context, isin string) (pensiondata.PublicPerformance, error) {
+			return pensiondata.PublicPerformance{Isin: "BE0948502365", AsOf: "2020-06-30"}, nil
+		}
+
+		InitPerformanceHandler(r, s)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/performance", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusOK != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusOK, resp.Code)
+		}
+		if contentTypeJson != resp.Header().Get("Content-Type") {
+			t.Errorf("want %s, got %s", contentTypeJson, resp.Header().Get("Content-Type"))
+		}
+	})
+
+	t.Run("return not found error for fund", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := gin.Default()
+
+		s := pensiondata.PerformanceServiceMock{}
+		s.GetPerformanceFn = func(ctx context.Context, isin string) (pensiondata.PublicPerformance, error) {
+			return pensiondata.PublicPerformance{}, pensiondata.ErrFundNotFound
+		}
+
+		InitPerformanceHandler(r, s)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/performance", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusNotFound != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusNotFound, resp.Code)
+		}
+	})
+
+	t.Run("return not found error for quotes", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := gin.Default()
+
+		s := pensiondata.PerformanceServiceMock{}
+		s.GetPerformanceFn = func(ctx context.Context, isin string) (pensiondata.PublicPerformance, error) {
+			return pensiondata.PublicPerformance{}, pensiondata.ErrQuoteNotFound
+		}
+
+		InitPerformanceHandler(r, s)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/performance", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusNotFound != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusNotFound, resp.Code)
+		}
+	})
+
+	t.Run("return internal error", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := gin.Default()
+
+		s := pensiondata.PerformanceServiceMock{}
+		s.GetPerformanceFn = func(ctx context.Context, isin string) (pensiondata.PublicPerformance, error) {
+			return pensiondata.PublicPerformance{}, errors.New("internal error")
+		}
+
+		InitPerformanceHandler(r, s)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/performance", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusInternalServerError != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusInternalServerError, resp.Code)
+		}
+		if contentTypeProblem != resp.Header().Get("Content-Type") {
+			t.Errorf("want %s, got %s", contentTypeProblem, resp.Header().Get("Content-Type"))
+		}
+	})
+}
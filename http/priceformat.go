@@ -0,0 +1,103 @@
+package http
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+)
+
+// Formats of the prices and computed values of the representations.
+// Number writes them as JSON numbers, String as exact decimal strings with the precision declared by the fund.
+const (
+	PriceFormatNumber = "number"
+	PriceFormatString = "string"
+)
+
+// priceFormatKey is the key of the gin context holding the price format requested by the client
+const priceFormatKey = "priceFormat"
+
+// priceFormatParameter is the parameter of the accepted media type selecting the price format,
+// as in Accept: application/json; price-format=string
+const priceFormatParameter = "price-format"
+
+// parsePriceFormat return the price format requested with the price_format query parameter or, when absent,
+// with the price-format parameter of the Accept header. Number is the default format.
+func parsePriceFormat(c *gin.Context) (string, error) {
+	format, ok := c.GetQuery("price_format")
+	if !ok {
+		format = acceptedPriceFormat(c.GetHeader("Accept"))
+	}
+
+	switch format {
+	case "", PriceFormatNumber:
+		return PriceFormatNumber, nil
+	case PriceFormatString:
+		return PriceFormatString, nil
+	default:
+		return "", fmt.Errorf("the price format %s must be %s or %s", format, PriceFormatNumber, PriceFormatString)
+	}
+}
+
+// acceptedPriceFormat return the value of the first price-format parameter of the accepted media types, if any
+func acceptedPriceFormat(accept string) string {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		if _, params, err := mime.ParseMediaType(mediaRange); err == nil {
+			if format, ok := params[priceFormatParameter]; ok {
+				return format
+			}
+		}
+	}
+
+	return ""
+}
+
+// requestPriceFormat return the price format of the request, number when the route does not negotiate it
+func requestPriceFormat(c *gin.Context) string {
+	if format := c.GetString(priceFormatKey); format != "" {
+		return format
+	}
+
+	return PriceFormatNumber
+}
+
+// exactDecimals return the representation with its prices, amounts and computed values written as exact decimal
+// strings, for the string price format. Representations without any are returned unchanged.
+func exactDecimals(value interface{}) interface{} {
+	switch v := value.(type) {
+	case pensiondata.PublicQuote:
+		return v.ExactDecimals()
+	case []pensiondata.PublicQuote:
+		quotes := make([]pensiondata.PublicQuote, len(v))
+		for i, quote := range v {
+			quotes[i] = quote.ExactDecimals()
+		}
+		return quotes
+	case pensiondata.PublicFundQuote:
+		return v.ExactDecimals()
+	case pensiondata.PublicQuoteWithProvenance:
+		return v.ExactDecimals()
+	case pensiondata.PublicQuarantinedQuote:
+		return v.ExactDecimals()
+	case []pensiondata.PublicQuarantinedQuote:
+		quarantinedQuotes := make([]pensiondata.PublicQuarantinedQuote, len(v))
+		for i, quarantinedQuote := range v {
+			quarantinedQuotes[i] = quarantinedQuote.ExactDecimals()
+		}
+		return quarantinedQuotes
+	case pensiondata.PublicBacktest:
+		return v.ExactDecimals()
+	case pensiondata.PublicComparison:
+		return v.ExactDecimals()
+	case pensiondata.PublicPerformance:
+		return v.ExactDecimals()
+	case pensiondata.PublicRisk:
+		return v.ExactDecimals()
+	case pensiondata.PublicPensionSavingsSimulation:
+		return v.ExactDecimals()
+	}
+
+	return value
+}
@@ -0,0 +1,122 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+	"github.com/shopspring/decimal"
+)
+
+func TestPriceFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := testRouter()
+
+	quoteService := pensiondata.QuoteServiceMock{}
+	quoteService.GetQuoteFn = func(ctx context.Context, isin, date string) (pensiondata.PublicQuote, error) {
+		return pensiondata.PublicQuote{Date: date, Price: testPrice("7.99")}, nil
+	}
+
+	InitQuoteHandler(r.Group("/v1", Version(V1)), quoteService)
+
+	tests := []struct {
+		name   string
+		path   string
+		accept string
+		code   int
+		want   string
+	}{
+		{name: "write prices as numbers by default", path: "/v1/funds/BE0948502365/quotes/2020-06-30",
+			code: http.StatusOK, want: `{"date":"2020-06-30","price":7.99}`},
+		{name: "write prices as strings with the query parameter", path: "/v1/funds/BE0948502365/quotes/2020-06-30?price_format=string",
+			code: http.StatusOK, want: `{"date":"2020-06-30","price":"7.9900"}`},
+		{name: "write prices as strings with the accepted media type", path: "/v1/funds/BE0948502365/quotes/2020-06-30",
+			accept: "text/html, application/json; price-format=string", code: http.StatusOK, want: `{"date":"2020-06-30","price":"7.9900"}`},
+		{name: "prefer the query parameter to the accepted media type", path: "/v1/funds/BE0948502365/quotes/2020-06-30?price_format=number",
+			accept: "application/json; price-format=string", code: http.StatusOK, want: `{"date":"2020-06-30","price":7.99}`},
+		{name: "return error for an unknown price format", path: "/v1/funds/BE0948502365/quotes/2020-06-30?price_format=float",
+			code: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := httptest.NewRecorder()
+
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			r.ServeHTTP(resp, req)
+
+			if tt.code != resp.Code {
+				t.Errorf("want %d, got %d", tt.code, resp.Code)
+			}
+			if tt.want != "" && tt.want != resp.Body.String() {
+				t.Errorf("want %s, got %s", tt.want, resp.Body.String())
+			}
+			if tt.code == http.StatusBadRequest && contentTypeProblem != resp.Header().Get("Content-Type") {
+				t.Errorf("want %s, got %s", contentTypeProblem, resp.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestExactDecimals(t *testing.T) {
+	sharpe := pensiondata.NewNumber(decimal.RequireFromString("0.1"))
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{
+			name:  "write the price of embedded quote with the precision of the fund",
+			value: pensiondata.PublicQuoteWithProvenance{PublicQuote: pensiondata.PublicQuote{Date: "2020-06-30", Price: testPrice("7.99")}},
+			want:  `{"date":"2020-06-30","price":"7.9900","provenance":null}`,
+		},
+		{
+			name:  "write the prices of quotes",
+			value: []pensiondata.PublicQuote{{Date: "2020-06-30", Price: testPrice("7.99")}},
+			want:  `[{"date":"2020-06-30","price":"7.9900"}]`,
+		},
+		{
+			name: "write computed values as strings",
+			value: pensiondata.PublicRisk{
+				Observations: 3, AnnualizedReturn: pensiondata.NewNumber(decimal.RequireFromString("0.0525")), Sharpe: &sharpe,
+			},
+			want: `{"isin":"","window":"","start_date":"","end_date":"","observations":3,"risk_free_rate":"0",` +
+				`"annualized_return":"0.0525","volatility":"0","sharpe_ratio":"0.1","sortino_ratio":null,` +
+				`"max_drawdown":{"drawdown":"0","peak_date":"","trough_date":"","recovery_date":null}}`,
+		},
+		{
+			name:  "keep representations without prices",
+			value: pensiondata.PublicQuoteBatch{Created: 1},
+			want:  `{"created":1,"duplicates":0,"quarantined":0,"invalid":0,"results":null}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(exactDecimals(tt.value))
+
+			if err != nil || tt.want != string(got) {
+				t.Errorf("want %s, got %s (%v)", tt.want, got, err)
+			}
+		})
+	}
+
+	t.Run("leave the value returned by the services unchanged", func(t *testing.T) {
+		quotes := []pensiondata.PublicQuote{{Date: "2020-06-30", Price: testPrice("7.99")}}
+
+		exactDecimals(quotes)
+
+		if quotes[0].Price.Exact {
+			t.Errorf("want number price, got exact price")
+		}
+	})
+}
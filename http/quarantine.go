@@ -0,0 +1,66 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+)
+
+// QuarantineHandler handle all the HTTP requests for the review of quarantined quotes
+type QuarantineHandler struct {
+	s pensiondata.QuarantineService
+}
+
+// InitQuarantineHandler initialize a new QuarantineHandler and register routes
+func InitQuarantineHandler(router gin.IRouter, service pensiondata.QuarantineService) {
+	h := &QuarantineHandler{s: service}
+
+	router.GET("/admin/quarantine", ScopeRequired(pensiondata.ScopeAdmin), h.GetQuarantinedQuotes())
+	router.POST("/admin/quarantine/:id/accept", ScopeRequired(pensiondata.ScopeAdmin), h.ReviewQuote(service.AcceptQuote))
+	router.POST("/admin/quarantine/:id/reject", ScopeRequired(pensiondata.ScopeAdmin), h.ReviewQuote(service.RejectQuote))
+}
+
+// GetQuarantinedQuotes return the quarantined quotes with the status query parameter, pending by default
+func (h QuarantineHandler) GetQuarantinedQuotes() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		status := strings.ToLower(context.Query("status"))
+		publicQuarantinedQuotes, err := h.s.GetQuarantinedQuotes(context.Request.Context(), status)
+
+		if err != nil {
+			writeError(context, err, errorDetails{
+				pensiondata.ErrInvalidQuarantineStatus: "The status must be pending, accepted or rejected",
+			})
+			return
+		}
+
+		render(context, http.StatusOK, publicQuarantinedQuotes)
+	}
+}
+
+// ReviewQuote accept or reject the quarantined quote with the given review function
+func (h QuarantineHandler) ReviewQuote(review func(context.Context, int64, string) (pensiondata.PublicQuarantinedQuote, error)) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		id, err := strconv.ParseInt(context.Params.ByName("id"), 10, 64)
+		if err != nil {
+			writeProblem(context, problemInvalidParameter, fmt.Sprintf("The id %s must be a number", context.Params.ByName("id")))
+			return
+		}
+
+		publicQuarantinedQuote, err := review(context.Request.Context(), id, context.GetString(identityKey))
+		if err != nil {
+			writeError(context, err, errorDetails{
+				pensiondata.ErrQuarantinedQuoteNotFound: fmt.Sprintf("The quarantined quote %d was not found", id),
+				pensiondata.ErrQuarantinedQuoteReviewed: fmt.Sprintf("The quarantined quote %d has already been reviewed", id),
+				pensiondata.ErrQuoteConflict:            "A quote with a different price already exists for the fund on this day, use PUT to correct it",
+			})
+			return
+		}
+
+		render(context, http.StatusOK, publicQuarantinedQuote)
+	}
+}
@@ -0,0 +1,197 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+)
+
+func TestGetQuarantinedQuotes(t *testing.T) {
+	t.Run("return quarantined quotes successfully", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		var gotStatus string
+		quarantineService := pensiondata.QuarantineServiceMock{}
+		quarantineService.GetQuarantinedQuotesFn = func(ctx context.Context, status string) ([]pensiondata.PublicQuarantinedQuote, error) {
+			gotStatus = status
+			return []pensiondata.PublicQuarantinedQuote{{ID: 1, Isin: "BE0948502365", Status: status}}, nil
+		}
+
+		InitQuarantineHandler(r, quarantineService)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/admin/quarantine?status=Rejected", nil)
+		req.Header.Add("Authorization", "Bearer "+testAdminKey)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusOK != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusOK, resp.Code)
+		}
+		if contentTypeJson != resp.Header().Get("Content-Type") {
+			t.Errorf("want %s, got %s", contentTypeJson, resp.Header().Get("Content-Type"))
+		}
+		if gotStatus != pensiondata.QuarantineStatusRejected {
+			t.Errorf("want %s, got %s", pensiondata.QuarantineStatusRejected, gotStatus)
+		}
+	})
+
+	t.Run("return forbidden error without admin scope", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		InitQuarantineHandler(r, pensiondata.QuarantineServiceMock{})
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/admin/quarantine", nil)
+		req.Header.Add("Authorization", "Bearer "+testScraperKey)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusForbidden != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusForbidden, resp.Code)
+		}
+	})
+
+	t.Run("return bad request error for invalid status", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		quarantineService := pensiondata.QuarantineServiceMock{}
+		quarantineService.GetQuarantinedQuotesFn = func(ctx context.Context, status string) ([]pensiondata.PublicQuarantinedQuote, error) {
+			return []pensiondata.PublicQuarantinedQuote{}, pensiondata.ErrInvalidQuarantineStatus
+		}
+
+		InitQuarantineHandler(r, quarantineService)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/admin/quarantine?status=deleted", nil)
+		req.Header.Add("Authorization", "Bearer "+testAdminKey)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusBadRequest != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusBadRequest, resp.Code)
+		}
+	})
+
+	t.Run("return internal error", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		quarantineService := pensiondata.QuarantineServiceMock{}
+		quarantineService.GetQuarantinedQuotesFn = func(ctx context.Context, status string) ([]pensiondata.PublicQuarantinedQuote, error) {
+			return []pensiondata.PublicQuarantinedQuote{}, errors.New("internal error")
+		}
+
+		InitQuarantineHandler(r, quarantineService)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/admin/quarantine", nil)
+		req.Header.Add("Authorization", "Bearer "+testAdminKey)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusInternalServerError != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusInternalServerError, resp.Code)
+		}
+	})
+}
+
+func TestReviewQuarantinedQuote(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		acceptFn func(context.Context, int64, string) (pensiondata.PublicQuarantinedQuote, error)
+		rejectFn func(context.Context, int64, string) (pensiondata.PublicQuarantinedQuote, error)
+		want     int
+	}{
+		{
+			name: "accept quote successfully",
+			path: "/admin/quarantine/1/accept",
+			acceptFn: func(ctx context.Context, id int64, reviewer string) (pensiondata.PublicQuarantinedQuote, error) {
+				if id != 1 || reviewer != testAdminAPIKey.Identity() {
+					return pensiondata.PublicQuarantinedQuote{}, errors.New("unexpected review")
+				}
+				return pensiondata.PublicQuarantinedQuote{ID: id, Status: pensiondata.QuarantineStatusAccepted}, nil
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "reject quote successfully",
+			path: "/admin/quarantine/1/reject",
+			rejectFn: func(ctx context.Context, id int64, reviewer string) (pensiondata.PublicQuarantinedQuote, error) {
+				return pensiondata.PublicQuarantinedQuote{ID: id, Status: pensiondata.QuarantineStatusRejected}, nil
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "return bad request error for invalid id",
+			path: "/admin/quarantine/abc/accept",
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "return not found error for quarantined quote",
+			path: "/admin/quarantine/1/accept",
+			acceptFn: func(ctx context.Context, id int64, reviewer string) (pensiondata.PublicQuarantinedQuote, error) {
+				return pensiondata.PublicQuarantinedQuote{}, pensiondata.ErrQuarantinedQuoteNotFound
+			},
+			want: http.StatusNotFound,
+		},
+		{
+			name: "return conflict error for quote already reviewed",
+			path: "/admin/quarantine/1/reject",
+			rejectFn: func(ctx context.Context, id int64, reviewer string) (pensiondata.PublicQuarantinedQuote, error) {
+				return pensiondata.PublicQuarantinedQuote{}, pensiondata.ErrQuarantinedQuoteReviewed
+			},
+			want: http.StatusConflict,
+		},
+		{
+			name: "return internal error",
+			path: "/admin/quarantine/1/accept",
+			acceptFn: func(ctx context.Context, id int64, reviewer string) (pensiondata.PublicQuarantinedQuote, error) {
+				return pensiondata.PublicQuarantinedQuote{}, errors.New("internal error")
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := testRouter()
+
+			quarantineService := pensiondata.QuarantineServiceMock{AcceptQuoteFn: tt.acceptFn, RejectQuoteFn: tt.rejectFn}
+
+			InitQuarantineHandler(r, quarantineService)
+
+			resp := httptest.NewRecorder()
+
+			req, _ := http.NewRequest(http.MethodPost, tt.path, nil)
+			req.Header.Add("Authorization", "Bearer "+testAdminKey)
+
+			r.ServeHTTP(resp, req)
+
+			if tt.want != resp.Code {
+				t.Errorf("want %d, got %d", tt.want, resp.Code)
+			}
+			wantContentType := contentTypeJson
+			if tt.want != http.StatusOK {
+				wantContentType = contentTypeProblem
+			}
+			if wantContentType != resp.Header().Get("Content-Type") {
+				t.Errorf("want %s, got %s", wantContentType, resp.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
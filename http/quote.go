@@ -1,10 +1,13 @@
 package http
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/obawi/pensiondata-api"
@@ -16,107 +19,304 @@ type QuoteHandler struct {
 }
 
 // InitQuoteHandler initialize a new QuoteHandler and register routes
-func InitQuoteHandler(router *gin.Engine, service pensiondata.QuoteService) *QuoteHandler {
+func InitQuoteHandler(router gin.IRouter, service pensiondata.QuoteService) *QuoteHandler {
 	h := &QuoteHandler{s: service}
 
+	router.GET("/quotes", h.GetAllQuotes())
 	router.GET("/funds/:isin/quotes", h.GetQuotes())
 	router.GET("/funds/:isin/quotes/:date", h.GetQuoteByDate())
-	router.POST("/funds/:isin/quotes", ScraperAuthRequired(), h.CreateQuote())
+	router.POST("/funds/:isin/quotes", ScopeRequired(pensiondata.ScopeQuotesWrite), h.CreateQuote())
+	router.PUT("/funds/:isin/quotes/:date", ScopeRequired(pensiondata.ScopeQuotesWrite), h.CorrectQuote())
+	router.POST("/funds/:isin/quotes/batch", ScopeRequired(pensiondata.ScopeQuotesWrite), h.CreateFundQuoteBatch())
+	router.POST("/quotes/batch", ScopeRequired(pensiondata.ScopeQuotesWrite), h.CreateQuoteBatch())
 
 	return h
 }
 
-// GetQuotes return all quotes for the given fund
+// GetQuotes return the quotes for the given fund, filtered and paginated by the query parameters.
+// The quotes are streamed as NDJSON or exported as a CSV or XLSX file when requested with the format query parameter
+// or the Accept header, in which case every quote matching the filters is written whatever the limit and cursor.
 func (h QuoteHandler) GetQuotes() gin.HandlerFunc {
 	return func(context *gin.Context) {
-		isin := strings.ToUpper(context.Params.ByName("isin"))
-		publicQuotes, err := h.s.GetQuotes(isin)
+		isin, ok := isinParam(context)
+		if !ok {
+			return
+		}
 
+		criteria, err := parseQuoteCriteria(context)
 		if err != nil {
-			if err == pensiondata.ErrFundNotFound {
-				context.JSON(http.StatusNotFound, gin.H{
-					"error":   http.StatusNotFound,
-					"message": fmt.Sprintf("The fund %s was not found", isin),
+			writeProblem(context, problemInvalidParameter, fmt.Sprintf("Invalid query parameter: %s", err))
+			return
+		}
+
+		format, ok := exportFormat(context)
+		if !ok {
+			return
+		}
+
+		details := errorDetails{
+			pensiondata.ErrFundNotFound:    fmt.Sprintf("The fund %s was not found", isin),
+			pensiondata.ErrInvalidCriteria: fmt.Sprintf("The order must be asc or desc, the limit between 1 and %d and from before to", pensiondata.MaxQuoteLimit),
+		}
+
+		if format != "" {
+			criteria.Limit, criteria.After = 0, time.Time{}
+			table := exportTable{name: isin + "-quotes", columns: quoteColumns, row: quoteRow}
+			writeExport(context, format, table, func(write func(interface{}) error) error {
+				return h.s.StreamQuotes(context.Request.Context(), isin, criteria, func(quote pensiondata.PublicQuote) error {
+					return write(quote)
 				})
-				return
-			}
+			}, details)
+			return
+		}
 
-			log.Printf("Error while listing quotes: %s", err)
-			context.JSON(http.StatusInternalServerError, gin.H{"message": internalErrorMessage})
+		page, err := h.s.GetQuotePage(context.Request.Context(), isin, criteria)
+
+		if err != nil {
+			writeError(context, err, details)
 			return
 		}
 
-		context.JSON(http.StatusOK, publicQuotes)
+		if page.NextCursor != "" {
+			next := *context.Request.URL
+			query := next.Query()
+			query.Set("cursor", page.NextCursor)
+			next.RawQuery = query.Encode()
+			context.Writer.Header().Add("Link", fmt.Sprintf("<%s>; rel=\"next\"", next.RequestURI()))
+		}
+
+		render(context, http.StatusOK, page.Quotes)
+	}
+}
+
+// GetAllQuotes stream the quotes of every fund, ordered by fund and date, as NDJSON unless exported as
+// a CSV or XLSX file. Whatever the length of the histories, they are written as they are read.
+func (h QuoteHandler) GetAllQuotes() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		format, ok := exportFormat(context)
+		if !ok {
+			return
+		}
+		if format == "" {
+			format = ExportFormatNDJSON
+		}
+
+		table := exportTable{name: "quotes", columns: fundQuoteColumns, row: fundQuoteRow}
+		writeExport(context, format, table, func(write func(interface{}) error) error {
+			return h.s.StreamAllQuotes(context.Request.Context(), func(quote pensiondata.PublicFundQuote) error {
+				return write(quote)
+			})
+		}, errorDetails{})
+	}
+}
+
+// parseQuoteCriteria return the QuoteCriteria from the from, to, limit, cursor and order query parameters
+func parseQuoteCriteria(context *gin.Context) (pensiondata.QuoteCriteria, error) {
+	var criteria pensiondata.QuoteCriteria
+	var err error
+
+	if from := context.Query("from"); from != "" {
+		if criteria.From, err = time.Parse("2006-01-02", from); err != nil {
+			return pensiondata.QuoteCriteria{}, fmt.Errorf("the from date %s must use the YYYY-MM-DD format", from)
+		}
+	}
+
+	if to := context.Query("to"); to != "" {
+		if criteria.To, err = time.Parse("2006-01-02", to); err != nil {
+			return pensiondata.QuoteCriteria{}, fmt.Errorf("the to date %s must use the YYYY-MM-DD format", to)
+		}
+	}
+
+	if limit := context.Query("limit"); limit != "" {
+		if criteria.Limit, err = strconv.Atoi(limit); err != nil || criteria.Limit <= 0 {
+			return pensiondata.QuoteCriteria{}, fmt.Errorf("the limit %s must be a positive number", limit)
+		}
 	}
+
+	if cursor := context.Query("cursor"); cursor != "" {
+		if criteria.After, err = pensiondata.DecodeQuoteCursor(cursor); err != nil {
+			return pensiondata.QuoteCriteria{}, fmt.Errorf("the cursor %s is invalid", cursor)
+		}
+	}
+
+	criteria.Order = strings.ToLower(context.Query("order"))
+
+	return criteria, nil
 }
 
-// GetQuoteByDate return the quote for the given date
+// GetQuoteByDate return the quote for the given date, with its provenance when include=provenance
 func (h QuoteHandler) GetQuoteByDate() gin.HandlerFunc {
 	return func(context *gin.Context) {
-		isin := strings.ToUpper(context.Params.ByName("isin"))
+		isin, ok := isinParam(context)
+		if !ok {
+			return
+		}
+
 		date := context.Params.ByName("date")
+		if date != "latest" {
+			if date, ok = dateParam(context); !ok {
+				return
+			}
+		}
 
-		var publicQuote pensiondata.PublicQuote
-		var err error
+		includeProvenance, err := parseIncludeProvenance(context)
+		if err != nil {
+			writeProblem(context, problemInvalidParameter, fmt.Sprintf("Invalid query parameter: %s", err))
+			return
+		}
+
+		var publicQuote interface{}
 
 		// Hard code the "latest" route to avoid a wildcard route conflict in Gin
 		if date == "latest" {
-			publicQuote, err = h.s.GetLatestQuote(isin)
+			var latestQuote pensiondata.PublicQuote
+			latestQuote, err = h.s.GetLatestQuote(context.Request.Context(), isin)
+			publicQuote = latestQuote
+			if err == nil && includeProvenance {
+				publicQuote, err = h.s.GetQuoteWithProvenance(context.Request.Context(), isin, latestQuote.Date)
+			}
+		} else if includeProvenance {
+			publicQuote, err = h.s.GetQuoteWithProvenance(context.Request.Context(), isin, date)
 		} else {
-			publicQuote, err = h.s.GetQuote(isin, date)
+			publicQuote, err = h.s.GetQuote(context.Request.Context(), isin, date)
 		}
 
 		if err != nil {
-			if err == pensiondata.ErrFundNotFound {
-				context.JSON(http.StatusNotFound, gin.H{
-					"error":   http.StatusNotFound,
-					"message": fmt.Sprintf("The fund %s was not found", isin),
-				})
-				return
-			} else if err == pensiondata.ErrQuoteNotFound {
-				context.JSON(http.StatusNotFound, gin.H{
-					"error":   http.StatusNotFound,
-					"message": fmt.Sprintf("The quote for fund %s on %s was not found", isin, date),
-				})
-				return
-			}
-
-			log.Printf("Error while getting quote by date: %s", err)
-			context.JSON(http.StatusInternalServerError, gin.H{"message": internalErrorMessage})
+			writeError(context, err, errorDetails{
+				pensiondata.ErrFundNotFound:  fmt.Sprintf("The fund %s was not found", isin),
+				pensiondata.ErrQuoteNotFound: fmt.Sprintf("The quote for fund %s on %s was not found", isin, date),
+			})
 			return
 		}
 
-		context.JSON(http.StatusOK, publicQuote)
+		render(context, http.StatusOK, publicQuote)
+	}
+}
+
+// parseIncludeProvenance return true when the comma separated include query parameter contains provenance,
+// the only supported value
+func parseIncludeProvenance(context *gin.Context) (bool, error) {
+	include := context.Query("include")
+	if include == "" {
+		return false, nil
+	}
+
+	for _, value := range strings.Split(include, ",") {
+		if strings.TrimSpace(value) != "provenance" {
+			return false, fmt.Errorf("the include %s is not supported, use provenance", value)
+		}
 	}
+
+	return true, nil
 }
 
 // CreateQuote create a new quote
 func (h QuoteHandler) CreateQuote() gin.HandlerFunc {
 	return func(context *gin.Context) {
-		isin := strings.ToUpper(context.Params.ByName("isin"))
+		isin, ok := isinParam(context)
+		if !ok {
+			return
+		}
 
 		var createQuote pensiondata.ScraperCreateQuote
-		if err := context.BindJSON(&createQuote); err != nil {
+		if err := context.ShouldBindJSON(&createQuote); err != nil {
 			log.Printf("Error while binding request body to ScraperCreateQuote struct %v: %s", createQuote, err)
-			context.JSON(http.StatusBadRequest, gin.H{"message": err})
+			writeProblem(context, problemInvalidBody, "The request body must be a quote with a date and a price")
+			return
+		}
+
+		publicQuote, err := h.s.CreateQuote(context.Request.Context(), isin, createQuote, context.GetString(identityKey))
+		var quarantineErr pensiondata.QuarantineError
+		if errors.As(err, &quarantineErr) {
+			render(context, http.StatusAccepted, quarantineErr.PublicQuote())
+			return
+		} else if err != nil {
+			writeError(context, err, errorDetails{
+				pensiondata.ErrFundNotFound:  fmt.Sprintf("The fund %s was not found", isin),
+				pensiondata.ErrQuoteConflict: fmt.Sprintf("A quote with a different price already exists for fund %s on this day, use PUT to correct it", isin),
+			})
+			return
+		}
+
+		render(context, http.StatusCreated, publicQuote)
+	}
+}
+
+// CorrectQuote create or correct the price of the quote for the given date
+func (h QuoteHandler) CorrectQuote() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		isin, ok := isinParam(context)
+		if !ok {
+			return
+		}
+		date := context.Params.ByName("date")
+
+		var correctQuote pensiondata.ScraperCorrectQuote
+		if err := context.ShouldBindJSON(&correctQuote); err != nil {
+			log.Printf("Error while binding request body to ScraperCorrectQuote struct %v: %s", correctQuote, err)
+			writeProblem(context, problemInvalidBody, "The request body must be a quote with a price")
 			return
 		}
 
-		publicQuote, err := h.s.CreateQuote(isin, createQuote)
+		publicQuote, err := h.s.CorrectQuote(context.Request.Context(), isin, date, correctQuote, context.GetString(identityKey))
 		if err != nil {
-			if err == pensiondata.ErrFundNotFound {
-				context.JSON(http.StatusNotFound, gin.H{
-					"error":   http.StatusNotFound,
-					"message": fmt.Sprintf("The fund %s was not found", isin),
-				})
-				return
-			}
+			writeError(context, err, errorDetails{
+				pensiondata.ErrFundNotFound: fmt.Sprintf("The fund %s was not found", isin),
+			})
+			return
+		}
+
+		render(context, http.StatusOK, publicQuote)
+	}
+}
+
+// CreateFundQuoteBatch create a batch of quotes for the given fund
+func (h QuoteHandler) CreateFundQuoteBatch() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		isin, ok := isinParam(context)
+		if !ok {
+			return
+		}
 
-			log.Printf("Error while creating quote: %s", err)
-			context.JSON(http.StatusInternalServerError, gin.H{"message": internalErrorMessage})
+		var createQuotes []pensiondata.ScraperCreateQuote
+		if err := context.ShouldBindJSON(&createQuotes); err != nil {
+			log.Printf("Error while binding request body to ScraperCreateQuote slice: %s", err)
+			writeProblem(context, problemInvalidBody, "The request body must be an array of quotes with a date and a price")
 			return
 		}
 
-		context.JSON(http.StatusCreated, publicQuote)
+		batchQuotes := make([]pensiondata.ScraperBatchQuote, len(createQuotes))
+		for i, createQuote := range createQuotes {
+			batchQuotes[i] = pensiondata.ScraperBatchQuote{Isin: isin, ScraperCreateQuote: createQuote}
+		}
+
+		h.createQuoteBatch(context, batchQuotes)
 	}
 }
+
+// CreateQuoteBatch create a batch of quotes for any fund, each quote carrying its fund isin
+func (h QuoteHandler) CreateQuoteBatch() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		var batchQuotes []pensiondata.ScraperBatchQuote
+		if err := context.ShouldBindJSON(&batchQuotes); err != nil {
+			log.Printf("Error while binding request body to ScraperBatchQuote slice: %s", err)
+			writeProblem(context, problemInvalidBody, "The request body must be an array of quotes with an isin, a date and a price")
+			return
+		}
+
+		h.createQuoteBatch(context, batchQuotes)
+	}
+}
+
+// createQuoteBatch create the batch of quotes and write the status of each of them
+func (h QuoteHandler) createQuoteBatch(context *gin.Context, batchQuotes []pensiondata.ScraperBatchQuote) {
+	batch, err := h.s.CreateQuoteBatch(context.Request.Context(), batchQuotes, context.GetString(identityKey))
+	if err != nil {
+		writeError(context, err, errorDetails{
+			pensiondata.ErrInvalidBatch: fmt.Sprintf("A batch must contain between 1 and %d quotes", pensiondata.MaxQuoteBatchSize),
+		})
+		return
+	}
+
+	render(context, http.StatusOK, batch)
+}
@@ -2,11 +2,13 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"os"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -17,18 +19,18 @@ import (
 func TestGetQuotes(t *testing.T) {
 	t.Run("return list of quotes successfully", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
-		r := gin.Default()
+		r := testRouter()
 
 		quoteService := pensiondata.QuoteServiceMock{}
-		quoteService.GetQuotesFn = func(isin string) ([]pensiondata.PublicQuote, error) {
-			return testPublicQuotes(), nil
+		quoteService.GetQuotePageFn = func(ctx context.Context, isin string, criteria pensiondata.QuoteCriteria) (pensiondata.PublicQuotePage, error) {
+			return pensiondata.PublicQuotePage{Quotes: testPublicQuotes()}, nil
 		}
 
 		InitQuoteHandler(r, quoteService)
 
 		resp := httptest.NewRecorder()
 
-		req, _ := http.NewRequest(http.MethodGet, "/funds/BE123/quotes", nil)
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/quotes", nil)
 
 		r.ServeHTTP(resp, req)
 
@@ -42,51 +44,128 @@ func TestGetQuotes(t *testing.T) {
 
 	t.Run("return not found error for fund", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
-		r := gin.Default()
+		r := testRouter()
 
 		quoteService := pensiondata.QuoteServiceMock{}
-		quoteService.GetQuotesFn = func(isin string) ([]pensiondata.PublicQuote, error) {
-			return []pensiondata.PublicQuote{}, pensiondata.ErrFundNotFound
+		quoteService.GetQuotePageFn = func(ctx context.Context, isin string, criteria pensiondata.QuoteCriteria) (pensiondata.PublicQuotePage, error) {
+			return pensiondata.PublicQuotePage{}, pensiondata.ErrFundNotFound
 		}
 
 		InitQuoteHandler(r, quoteService)
 
 		resp := httptest.NewRecorder()
 
-		req, _ := http.NewRequest(http.MethodGet, "/funds/BE123/quotes", nil)
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/quotes", nil)
 
 		r.ServeHTTP(resp, req)
 
 		if http.StatusNotFound != resp.Code {
 			t.Errorf("want %d, got %d", http.StatusNotFound, resp.Code)
 		}
-		if contentTypeJson != resp.Header().Get("Content-Type") {
-			t.Errorf("want %s, got %s", contentTypeJson, resp.Header().Get("Content-Type"))
+		if contentTypeProblem != resp.Header().Get("Content-Type") {
+			t.Errorf("want %s, got %s", contentTypeProblem, resp.Header().Get("Content-Type"))
 		}
 	})
 
 	t.Run("return internal error", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
-		r := gin.Default()
+		r := testRouter()
 
 		quoteService := pensiondata.QuoteServiceMock{}
-		quoteService.GetQuotesFn = func(isin string) ([]pensiondata.PublicQuote, error) {
-			return []pensiondata.PublicQuote{}, errors.New("internal error")
+		quoteService.GetQuotePageFn = func(ctx context.Context, isin string, criteria pensiondata.QuoteCriteria) (pensiondata.PublicQuotePage, error) {
+			return pensiondata.PublicQuotePage{}, errors.New("internal error")
 		}
 
 		InitQuoteHandler(r, quoteService)
 
 		resp := httptest.NewRecorder()
 
-		req, _ := http.NewRequest(http.MethodGet, "/funds/BE123/quotes", nil)
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/quotes", nil)
 
 		r.ServeHTTP(resp, req)
 
 		if http.StatusInternalServerError != resp.Code {
 			t.Errorf("want %d, got %d", http.StatusInternalServerError, resp.Code)
 		}
-		if contentTypeJson != resp.Header().Get("Content-Type") {
-			t.Errorf("want %s, got %s", contentTypeJson, resp.Header().Get("Content-Type"))
+		if contentTypeProblem != resp.Header().Get("Content-Type") {
+			t.Errorf("want %s, got %s", contentTypeProblem, resp.Header().Get("Content-Type"))
+		}
+	})
+}
+
+func TestGetQuotesPagination(t *testing.T) {
+	t.Run("pass criteria and return next link", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		var got pensiondata.QuoteCriteria
+		quoteService := pensiondata.QuoteServiceMock{}
+		quoteService.GetQuotePageFn = func(ctx context.Context, isin string, criteria pensiondata.QuoteCriteria) (pensiondata.PublicQuotePage, error) {
+			got = criteria
+			return pensiondata.PublicQuotePage{Quotes: testPublicQuotes(), NextCursor: "next"}, nil
+		}
+
+		InitQuoteHandler(r, quoteService)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/quotes?from=2020-01-01&to=2020-12-31&limit=2&order=ASC", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusOK != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusOK, resp.Code)
+		}
+		if got.From.Format("2006-01-02") != "2020-01-01" || got.To.Format("2006-01-02") != "2020-12-31" {
+			t.Errorf("want 2020-01-01 to 2020-12-31, got %s to %s", got.From.Format("2006-01-02"), got.To.Format("2006-01-02"))
+		}
+		if got.Limit != 2 || got.Order != pensiondata.OrderAsc {
+			t.Errorf("want limit 2 and order asc, got limit %d and order %s", got.Limit, got.Order)
+		}
+		wantLink := `</funds/BE0948502365/quotes?cursor=next&from=2020-01-01&limit=2&order=ASC&to=2020-12-31>; rel="next"`
+		if wantLink != resp.Header().Get("Link") {
+			t.Errorf("want %s, got %s", wantLink, resp.Header().Get("Link"))
+		}
+	})
+
+	t.Run("return bad request error for invalid query parameters", func(t *testing.T) {
+		for _, query := range []string{"from=2020-13-01", "to=yesterday", "limit=-1", "cursor=%25%25"} {
+			gin.SetMode(gin.TestMode)
+			r := testRouter()
+
+			InitQuoteHandler(r, pensiondata.QuoteServiceMock{})
+
+			resp := httptest.NewRecorder()
+
+			req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/quotes?"+query, nil)
+
+			r.ServeHTTP(resp, req)
+
+			if http.StatusBadRequest != resp.Code {
+				t.Errorf("%s: want %d, got %d", query, http.StatusBadRequest, resp.Code)
+			}
+		}
+	})
+
+	t.Run("return bad request error for invalid criteria", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		quoteService := pensiondata.QuoteServiceMock{}
+		quoteService.GetQuotePageFn = func(ctx context.Context, isin string, criteria pensiondata.QuoteCriteria) (pensiondata.PublicQuotePage, error) {
+			return pensiondata.PublicQuotePage{}, pensiondata.ErrInvalidCriteria
+		}
+
+		InitQuoteHandler(r, quoteService)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/quotes?order=sideways", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusBadRequest != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusBadRequest, resp.Code)
 		}
 	})
 }
@@ -94,10 +173,10 @@ func TestGetQuotes(t *testing.T) {
 func TestGetQuoteByDate(t *testing.T) {
 	t.Run("return quote successfully", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
-		r := gin.Default()
+		r := testRouter()
 
 		quoteService := pensiondata.QuoteServiceMock{}
-		quoteService.GetQuoteFn = func(isin, date string) (pensiondata.PublicQuote, error) {
+		quoteService.GetQuoteFn = func(ctx context.Context, isin, date string) (pensiondata.PublicQuote, error) {
 			return testPublicQuote(), nil
 		}
 
@@ -105,7 +184,7 @@ func TestGetQuoteByDate(t *testing.T) {
 
 		resp := httptest.NewRecorder()
 
-		req, _ := http.NewRequest(http.MethodGet, "/funds/BE123/quotes/2020-06-28", nil)
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/quotes/2020-06-28", nil)
 
 		r.ServeHTTP(resp, req)
 
@@ -119,10 +198,10 @@ func TestGetQuoteByDate(t *testing.T) {
 
 	t.Run("return not found error for found", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
-		r := gin.Default()
+		r := testRouter()
 
 		quoteService := pensiondata.QuoteServiceMock{}
-		quoteService.GetQuoteFn = func(isin, date string) (pensiondata.PublicQuote, error) {
+		quoteService.GetQuoteFn = func(ctx context.Context, isin, date string) (pensiondata.PublicQuote, error) {
 			return pensiondata.PublicQuote{}, pensiondata.ErrFundNotFound
 		}
 
@@ -130,24 +209,24 @@ func TestGetQuoteByDate(t *testing.T) {
 
 		resp := httptest.NewRecorder()
 
-		req, _ := http.NewRequest(http.MethodGet, "/funds/BE123/quotes/2020-06-28", nil)
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/quotes/2020-06-28", nil)
 
 		r.ServeHTTP(resp, req)
 
 		if http.StatusNotFound != resp.Code {
 			t.Errorf("want %d, got %d", http.StatusNotFound, resp.Code)
 		}
-		if contentTypeJson != resp.Header().Get("Content-Type") {
-			t.Errorf("want %s, got %s", contentTypeJson, resp.Header().Get("Content-Type"))
+		if contentTypeProblem != resp.Header().Get("Content-Type") {
+			t.Errorf("want %s, got %s", contentTypeProblem, resp.Header().Get("Content-Type"))
 		}
 	})
 
 	t.Run("return not found error for quote", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
-		r := gin.Default()
+		r := testRouter()
 
 		quoteService := pensiondata.QuoteServiceMock{}
-		quoteService.GetQuoteFn = func(isin, date string) (pensiondata.PublicQuote, error) {
+		quoteService.GetQuoteFn = func(ctx context.Context, isin, date string) (pensiondata.PublicQuote, error) {
 			return pensiondata.PublicQuote{}, pensiondata.ErrQuoteNotFound
 		}
 
@@ -155,24 +234,24 @@ func TestGetQuoteByDate(t *testing.T) {
 
 		resp := httptest.NewRecorder()
 
-		req, _ := http.NewRequest(http.MethodGet, "/funds/BE123/quotes/2020-06-28", nil)
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/quotes/2020-06-28", nil)
 
 		r.ServeHTTP(resp, req)
 
 		if http.StatusNotFound != resp.Code {
 			t.Errorf("want %d, got %d", http.StatusNotFound, resp.Code)
 		}
-		if contentTypeJson != resp.Header().Get("Content-Type") {
-			t.Errorf("want %s, got %s", contentTypeJson, resp.Header().Get("Content-Type"))
+		if contentTypeProblem != resp.Header().Get("Content-Type") {
+			t.Errorf("want %s, got %s", contentTypeProblem, resp.Header().Get("Content-Type"))
 		}
 	})
 
 	t.Run("return internal error", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
-		r := gin.Default()
+		r := testRouter()
 
 		quoteService := pensiondata.QuoteServiceMock{}
-		quoteService.GetQuoteFn = func(isin, date string) (pensiondata.PublicQuote, error) {
+		quoteService.GetQuoteFn = func(ctx context.Context, isin, date string) (pensiondata.PublicQuote, error) {
 			return pensiondata.PublicQuote{}, errors.New("internal error")
 		}
 
@@ -180,24 +259,44 @@ func TestGetQuoteByDate(t *testing.T) {
 
 		resp := httptest.NewRecorder()
 
-		req, _ := http.NewRequest(http.MethodGet, "/funds/BE123/quotes/2020-06-28", nil)
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/quotes/2020-06-28", nil)
 
 		r.ServeHTTP(resp, req)
 
 		if http.StatusInternalServerError != resp.Code {
 			t.Errorf("want %d, got %d", http.StatusInternalServerError, resp.Code)
 		}
-		if contentTypeJson != resp.Header().Get("Content-Type") {
-			t.Errorf("want %s, got %s", contentTypeJson, resp.Header().Get("Content-Type"))
+		if contentTypeProblem != resp.Header().Get("Content-Type") {
+			t.Errorf("want %s, got %s", contentTypeProblem, resp.Header().Get("Content-Type"))
+		}
+	})
+
+	t.Run("return bad request error for invalid date", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		InitQuoteHandler(r, pensiondata.QuoteServiceMock{})
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/quotes/2020-02-30", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusBadRequest != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusBadRequest, resp.Code)
+		}
+		if !strings.Contains(resp.Body.String(), `"errors":[{"field":"date"`) {
+			t.Errorf("want error of date field, got %s", resp.Body.String())
 		}
 	})
 
 	t.Run("return latest quote successfully", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
-		r := gin.Default()
+		r := testRouter()
 
 		quoteService := pensiondata.QuoteServiceMock{}
-		quoteService.GetLatestQuoteFn = func(isin string) (pensiondata.PublicQuote, error) {
+		quoteService.GetLatestQuoteFn = func(ctx context.Context, isin string) (pensiondata.PublicQuote, error) {
 			return testPublicQuote(), nil
 		}
 
@@ -205,7 +304,7 @@ func TestGetQuoteByDate(t *testing.T) {
 
 		resp := httptest.NewRecorder()
 
-		req, _ := http.NewRequest(http.MethodGet, "/funds/BE123/quotes/latest", nil)
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/quotes/latest", nil)
 
 		r.ServeHTTP(resp, req)
 
@@ -218,14 +317,101 @@ func TestGetQuoteByDate(t *testing.T) {
 	})
 }
 
+func TestGetQuoteByDateWithProvenance(t *testing.T) {
+	t.Run("return quote with provenance successfully", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		quoteService := pensiondata.QuoteServiceMock{}
+		quoteService.GetQuoteWithProvenanceFn = func(ctx context.Context, isin, date string) (pensiondata.PublicQuoteWithProvenance, error) {
+			return pensiondata.PublicQuoteWithProvenance{
+				PublicQuote: testPublicQuote(),
+				Provenance: &pensiondata.PublicProvenance{
+					SourceSystem: "scraper",
+					RunID:        "run-42",
+					IngestedAt:   "2020-06-28T18:00:00Z",
+					IngestedBy:   "scraper",
+				},
+			}, nil
+		}
+
+		InitQuoteHandler(r, quoteService)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/quotes/2020-06-28?include=provenance", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusOK != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusOK, resp.Code)
+		}
+
+		var got map[string]interface{}
+		_ = json.Unmarshal(resp.Body.Bytes(), &got)
+		provenance, ok := got["provenance"].(map[string]interface{})
+		if !ok || provenance["run_id"] != "run-42" || got["price"] == nil {
+			t.Errorf("want quote with provenance of run-42, got %s", resp.Body.String())
+		}
+	})
+
+	t.Run("return latest quote with provenance successfully", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		var gotDate string
+		quoteService := pensiondata.QuoteServiceMock{}
+		quoteService.GetLatestQuoteFn = func(ctx context.Context, isin string) (pensiondata.PublicQuote, error) {
+			return pensiondata.PublicQuote{Date: "2020-06-30", Price: testPrice("7.99")}, nil
+		}
+		quoteService.GetQuoteWithProvenanceFn = func(ctx context.Context, isin, date string) (pensiondata.PublicQuoteWithProvenance, error) {
+			gotDate = date
+			return pensiondata.PublicQuoteWithProvenance{}, nil
+		}
+
+		InitQuoteHandler(r, quoteService)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/quotes/latest?include=provenance", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusOK != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusOK, resp.Code)
+		}
+		if gotDate != "2020-06-30" {
+			t.Errorf("want provenance of 2020-06-30, got %s", gotDate)
+		}
+	})
+
+	t.Run("return bad request error for unsupported include", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		InitQuoteHandler(r, pensiondata.QuoteServiceMock{})
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/quotes/2020-06-28?include=history", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusBadRequest != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusBadRequest, resp.Code)
+		}
+	})
+}
+
 func TestCreateQuote(t *testing.T) {
 	t.Run("create quote successfully", func(t *testing.T) {
-		_ = os.Setenv("SCRAPER_KEY", "s3cr3t")
 		gin.SetMode(gin.TestMode)
-		r := gin.Default()
+		r := testRouter()
 
+		var gotAuthor string
 		quoteService := pensiondata.QuoteServiceMock{}
-		quoteService.CreateQuoteFn = func(isin string, quote pensiondata.ScraperCreateQuote) (pensiondata.PublicQuote, error) {
+		quoteService.CreateQuoteFn = func(ctx context.Context, isin string, quote pensiondata.ScraperCreateQuote, author string) (pensiondata.PublicQuote, error) {
+			gotAuthor = author
 			return testPublicQuote(), nil
 		}
 
@@ -239,27 +425,28 @@ func TestCreateQuote(t *testing.T) {
 		}
 		jsonScraperCreateQuote, _ := json.Marshal(scraperCreateQuote)
 
-		req, _ := http.NewRequest("POST", "/funds/BE123/quotes", bytes.NewBuffer(jsonScraperCreateQuote))
-		req.Header.Add("SCRAPER-KEY", "s3cr3t")
+		req, _ := http.NewRequest("POST", "/funds/BE0948502365/quotes", bytes.NewBuffer(jsonScraperCreateQuote))
+		req.Header.Add("Authorization", "Bearer "+testScraperKey)
 
 		r.ServeHTTP(resp, req)
 
+		if gotAuthor != testScraperAPIKey.Identity() {
+			t.Errorf("want quote created by scraper, got %s", gotAuthor)
+		}
 		if http.StatusCreated != resp.Code {
 			t.Errorf("want %d, got %d", http.StatusCreated, resp.Code)
 		}
 		if contentTypeJson != resp.Header().Get("Content-Type") {
 			t.Errorf("want %s, got %s", contentTypeJson, resp.Header().Get("Content-Type"))
 		}
-		_ = os.Unsetenv("SCRAPER_KEY")
 	})
 
 	t.Run("return bad request error for invalid quote JSON binding", func(t *testing.T) {
-		_ = os.Setenv("SCRAPER_KEY", "s3cr3t")
 		gin.SetMode(gin.TestMode)
-		r := gin.Default()
+		r := testRouter()
 
 		quoteService := pensiondata.QuoteServiceMock{}
-		quoteService.CreateQuoteFn = func(isin string, quote pensiondata.ScraperCreateQuote) (pensiondata.PublicQuote, error) {
+		quoteService.CreateQuoteFn = func(ctx context.Context, isin string, quote pensiondata.ScraperCreateQuote, author string) (pensiondata.PublicQuote, error) {
 			return pensiondata.PublicQuote{}, nil
 		}
 
@@ -269,27 +456,59 @@ func TestCreateQuote(t *testing.T) {
 
 		jsonScraperCreateQuote, _ := json.Marshal(false)
 
-		req, _ := http.NewRequest(http.MethodPost, "/funds/BE123/quotes", bytes.NewBuffer(jsonScraperCreateQuote))
-		req.Header.Add("SCRAPER-KEY", "s3cr3t")
+		req, _ := http.NewRequest(http.MethodPost, "/funds/BE0948502365/quotes", bytes.NewBuffer(jsonScraperCreateQuote))
+		req.Header.Add("Authorization", "Bearer "+testScraperKey)
 
 		r.ServeHTTP(resp, req)
 
 		if http.StatusBadRequest != resp.Code {
 			t.Errorf("want %d, got %d", http.StatusBadRequest, resp.Code)
 		}
-		if contentTypeJson != resp.Header().Get("Content-Type") {
-			t.Errorf("want %s, got %s", contentTypeJson, resp.Header().Get("Content-Type"))
+		if contentTypeProblem != resp.Header().Get("Content-Type") {
+			t.Errorf("want %s, got %s", contentTypeProblem, resp.Header().Get("Content-Type"))
+		}
+	})
+
+	t.Run("return bad request error with invalid fields", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		quoteService := pensiondata.QuoteServiceMock{}
+		quoteService.CreateQuoteFn = func(ctx context.Context, isin string, quote pensiondata.ScraperCreateQuote, author string) (pensiondata.PublicQuote, error) {
+			return pensiondata.PublicQuote{}, pensiondata.ValidationError{Err: pensiondata.ErrInvalidQuote, Fields: []pensiondata.FieldError{
+				{Field: "date", Message: "the date 2020-06-30 must use the RFC 3339 format"},
+				{Field: "price", Message: "the price must be positive"},
+			}}
+		}
+
+		InitQuoteHandler(r, quoteService)
+
+		resp := httptest.NewRecorder()
+
+		jsonScraperCreateQuote, _ := json.Marshal(pensiondata.ScraperCreateQuote{Date: "2020-06-30", Price: decimal.Zero})
+
+		req, _ := http.NewRequest(http.MethodPost, "/funds/BE0948502365/quotes", bytes.NewBuffer(jsonScraperCreateQuote))
+		req.Header.Add("Authorization", "Bearer "+testScraperKey)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusBadRequest != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusBadRequest, resp.Code)
+		}
+
+		var problem Problem
+		_ = json.Unmarshal(resp.Body.Bytes(), &problem)
+		if problem.Code != "invalid_quote" || len(problem.Errors) != 2 || problem.Errors[1].Field != "price" {
+			t.Errorf("want invalid_quote with errors of date and price, got %s", resp.Body.String())
 		}
-		_ = os.Unsetenv("SCRAPER_KEY")
 	})
 
 	t.Run("return not found error for fund", func(t *testing.T) {
-		_ = os.Setenv("SCRAPER_KEY", "s3cr3t")
 		gin.SetMode(gin.TestMode)
-		r := gin.Default()
+		r := testRouter()
 
 		quoteService := pensiondata.QuoteServiceMock{}
-		quoteService.CreateQuoteFn = func(isin string, quote pensiondata.ScraperCreateQuote) (pensiondata.PublicQuote, error) {
+		quoteService.CreateQuoteFn = func(ctx context.Context, isin string, quote pensiondata.ScraperCreateQuote, author string) (pensiondata.PublicQuote, error) {
 			return pensiondata.PublicQuote{}, pensiondata.ErrFundNotFound
 		}
 
@@ -303,27 +522,25 @@ func TestCreateQuote(t *testing.T) {
 		}
 		jsonScraperCreateQuote, _ := json.Marshal(scraperCreateQuote)
 
-		req, _ := http.NewRequest("POST", "/funds/BE123/quotes", bytes.NewBuffer(jsonScraperCreateQuote))
-		req.Header.Add("SCRAPER-KEY", "s3cr3t")
+		req, _ := http.NewRequest("POST", "/funds/BE0948502365/quotes", bytes.NewBuffer(jsonScraperCreateQuote))
+		req.Header.Add("Authorization", "Bearer "+testScraperKey)
 
 		r.ServeHTTP(resp, req)
 
 		if http.StatusNotFound != resp.Code {
 			t.Errorf("want %d, got %d", http.StatusNotFound, resp.Code)
 		}
-		if contentTypeJson != resp.Header().Get("Content-Type") {
-			t.Errorf("want %s, got %s", contentTypeJson, resp.Header().Get("Content-Type"))
+		if contentTypeProblem != resp.Header().Get("Content-Type") {
+			t.Errorf("want %s, got %s", contentTypeProblem, resp.Header().Get("Content-Type"))
 		}
-		_ = os.Unsetenv("SCRAPER_KEY")
 	})
 
 	t.Run("return internal error", func(t *testing.T) {
-		_ = os.Setenv("SCRAPER_KEY", "s3cr3t")
 		gin.SetMode(gin.TestMode)
-		r := gin.Default()
+		r := testRouter()
 
 		quoteService := pensiondata.QuoteServiceMock{}
-		quoteService.CreateQuoteFn = func(isin string, quote pensiondata.ScraperCreateQuote) (pensiondata.PublicQuote, error) {
+		quoteService.CreateQuoteFn = func(ctx context.Context, isin string, quote pensiondata.ScraperCreateQuote, author string) (pensiondata.PublicQuote, error) {
 			return pensiondata.PublicQuote{}, errors.New("internal error")
 		}
 
@@ -337,28 +554,376 @@ func TestCreateQuote(t *testing.T) {
 		}
 		jsonScraperCreateQuote, _ := json.Marshal(scraperCreateQuote)
 
-		req, _ := http.NewRequest("POST", "/funds/BE123/quotes", bytes.NewBuffer(jsonScraperCreateQuote))
-		req.Header.Add("SCRAPER-KEY", "s3cr3t")
+		req, _ := http.NewRequest("POST", "/funds/BE0948502365/quotes", bytes.NewBuffer(jsonScraperCreateQuote))
+		req.Header.Add("Authorization", "Bearer "+testScraperKey)
 
 		r.ServeHTTP(resp, req)
 
 		if http.StatusInternalServerError != resp.Code {
 			t.Errorf("want %d, got %d", http.StatusInternalServerError, resp.Code)
 		}
-		if contentTypeJson != resp.Header().Get("Content-Type") {
-			t.Errorf("want %s, got %s", contentTypeJson, resp.Header().Get("Content-Type"))
+		if contentTypeProblem != resp.Header().Get("Content-Type") {
+			t.Errorf("want %s, got %s", contentTypeProblem, resp.Header().Get("Content-Type"))
 		}
-		_ = os.Unsetenv("SCRAPER_KEY")
 	})
 }
 
 func testPublicQuote() pensiondata.PublicQuote {
-	return pensiondata.PublicQuote{Price: 5.99, Date: "2020-06-28"}
+	return pensiondata.PublicQuote{Price: testPrice("5.99"), Date: "2020-06-28"}
 }
 
 func testPublicQuotes() []pensiondata.PublicQuote {
 	return []pensiondata.PublicQuote{
-		{Date: "2020-06-28", Price: 5.99},
-		{Date: "2020-06-27", Price: 5.99},
+		{Date: "2020-06-28", Price: testPrice("5.99")},
+		{Date: "2020-06-27", Price: testPrice("5.99")},
 	}
 }
+
+func TestCreateQuoteConflict(t *testing.T) {
+	t.Run("return conflict error for a different price on the same day", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		quoteService := pensiondata.QuoteServiceMock{}
+		quoteService.CreateQuoteFn = func(ctx context.Context, isin string, quote pensiondata.ScraperCreateQuote, author string) (pensiondata.PublicQuote, error) {
+			return pensiondata.PublicQuote{}, pensiondata.ErrQuoteConflict
+		}
+
+		InitQuoteHandler(r, quoteService)
+
+		resp := httptest.NewRecorder()
+
+		body := []byte(`{"date":"2020-06-30T00:00:00+02:00","price":7.99}`)
+
+		req, _ := http.NewRequest(http.MethodPost, "/funds/BE0948502365/quotes", bytes.NewBuffer(body))
+		req.Header.Add("Authorization", "Bearer "+testScraperKey)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusConflict != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusConflict, resp.Code)
+		}
+	})
+}
+
+func TestCreateQuoteQuarantined(t *testing.T) {
+	t.Run("return accepted for a quarantined quote", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		quoteService := pensiondata.QuoteServiceMock{}
+		quoteService.CreateQuoteFn = func(ctx context.Context, isin string, quote pensiondata.ScraperCreateQuote, author string) (pensiondata.PublicQuote, error) {
+			return pensiondata.PublicQuote{}, pensiondata.QuarantineError{Quote: pensiondata.QuarantinedQuote{
+				ID:      7,
+				Isin:    isin,
+				Quote:   pensiondata.Quote{Price: quote.Price},
+				Reasons: []string{pensiondata.QuarantineReasonPriceChange},
+				Status:  pensiondata.QuarantineStatusPending,
+			}}
+		}
+
+		InitQuoteHandler(r, quoteService)
+
+		resp := httptest.NewRecorder()
+
+		body := []byte(`{"date":"2020-06-30T00:00:00+02:00","price":799}`)
+
+		req, _ := http.NewRequest(http.MethodPost, "/funds/BE0948502365/quotes", bytes.NewBuffer(body))
+		req.Header.Add("Authorization", "Bearer "+testScraperKey)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusAccepted != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusAccepted, resp.Code)
+		}
+
+		var got pensiondata.PublicQuarantinedQuote
+		if err := json.Unmarshal(resp.Body.Bytes(), &got); err != nil {
+			t.Fatalf("want quarantined quote, got %s", resp.Body.String())
+		}
+		if got.ID != 7 || got.Status != pensiondata.QuarantineStatusPending || len(got.Reasons) != 1 ||
+			got.Reasons[0] != pensiondata.QuarantineReasonPriceChange {
+			t.Errorf("want pending quote 7 quarantined for %s, got %v", pensiondata.QuarantineReasonPriceChange, got)
+		}
+	})
+
+	t.Run("return bad request error for a rejected quote", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		quoteService := pensiondata.QuoteServiceMock{}
+		quoteService.CreateQuoteFn = func(ctx context.Context, isin string, quote pensiondata.ScraperCreateQuote, author string) (pensiondata.PublicQuote, error) {
+			return pensiondata.PublicQuote{}, fmt.Errorf("%w: the price must be positive", pensiondata.ErrInvalidQuote)
+		}
+
+		InitQuoteHandler(r, quoteService)
+
+		resp := httptest.NewRecorder()
+
+		body := []byte(`{"date":"2020-06-30T00:00:00+02:00","price":0}`)
+
+		req, _ := http.NewRequest(http.MethodPost, "/funds/BE0948502365/quotes", bytes.NewBuffer(body))
+		req.Header.Add("Authorization", "Bearer "+testScraperKey)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusBadRequest != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusBadRequest, resp.Code)
+		}
+	})
+}
+
+func TestCorrectQuote(t *testing.T) {
+	t.Run("correct quote successfully", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		var gotDate, gotAuthor string
+		quoteService := pensiondata.QuoteServiceMock{}
+		quoteService.CorrectQuoteFn = func(ctx context.Context, isin, date string, correction pensiondata.ScraperCorrectQuote, author string) (pensiondata.PublicQuote, error) {
+			gotDate, gotAuthor = date, author
+			return testPublicQuote(), nil
+		}
+
+		InitQuoteHandler(r, quoteService)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodPut, "/funds/BE0948502365/quotes/2020-06-30", bytes.NewBuffer([]byte(`{"price":"7.99"}`)))
+		req.Header.Add("Authorization", "Bearer "+testScraperKey)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusOK != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusOK, resp.Code)
+		}
+		if contentTypeJson != resp.Header().Get("Content-Type") {
+			t.Errorf("want %s, got %s", contentTypeJson, resp.Header().Get("Content-Type"))
+		}
+		if gotDate != "2020-06-30" || gotAuthor != testScraperAPIKey.Identity() {
+			t.Errorf("want 2020-06-30 corrected by scraper, got %s by %s", gotDate, gotAuthor)
+		}
+	})
+
+	t.Run("return unauthorized without scraper key", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		InitQuoteHandler(r, pensiondata.QuoteServiceMock{})
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodPut, "/funds/BE0948502365/quotes/2020-06-30", bytes.NewBuffer([]byte(`{"price":"7.99"}`)))
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusUnauthorized != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusUnauthorized, resp.Code)
+		}
+	})
+
+	t.Run("return bad request error for invalid quote", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		quoteService := pensiondata.QuoteServiceMock{}
+		quoteService.CorrectQuoteFn = func(ctx context.Context, isin, date string, correction pensiondata.ScraperCorrectQuote, author string) (pensiondata.PublicQuote, error) {
+			return pensiondata.PublicQuote{}, fmt.Errorf("%w: the price must be positive", pensiondata.ErrInvalidQuote)
+		}
+
+		InitQuoteHandler(r, quoteService)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodPut, "/funds/BE0948502365/quotes/2020-06-30", bytes.NewBuffer([]byte(`{"price":"-1"}`)))
+		req.Header.Add("Authorization", "Bearer "+testScraperKey)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusBadRequest != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusBadRequest, resp.Code)
+		}
+	})
+
+	t.Run("return not found error for fund", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		quoteService := pensiondata.QuoteServiceMock{}
+		quoteService.CorrectQuoteFn = func(ctx context.Context, isin, date string, correction pensiondata.ScraperCorrectQuote, author string) (pensiondata.PublicQuote, error) {
+			return pensiondata.PublicQuote{}, pensiondata.ErrFundNotFound
+		}
+
+		InitQuoteHandler(r, quoteService)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodPut, "/funds/BE0948502365/quotes/2020-06-30", bytes.NewBuffer([]byte(`{"price":"7.99"}`)))
+		req.Header.Add("Authorization", "Bearer "+testScraperKey)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusNotFound != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusNotFound, resp.Code)
+		}
+	})
+
+	t.Run("return internal error", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		quoteService := pensiondata.QuoteServiceMock{}
+		quoteService.CorrectQuoteFn = func(ctx context.Context, isin, date string, correction pensiondata.ScraperCorrectQuote, author string) (pensiondata.PublicQuote, error) {
+			return pensiondata.PublicQuote{}, errors.New("internal error")
+		}
+
+		InitQuoteHandler(r, quoteService)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodPut, "/funds/BE0948502365/quotes/2020-06-30", bytes.NewBuffer([]byte(`{"price":"7.99"}`)))
+		req.Header.Add("Authorization", "Bearer "+testScraperKey)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusInternalServerError != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusInternalServerError, resp.Code)
+		}
+	})
+}
+
+func TestCreateQuoteBatch(t *testing.T) {
+	t.Run("create fund quote batch successfully", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		var got []pensiondata.ScraperBatchQuote
+		quoteService := pensiondata.QuoteServiceMock{}
+		quoteService.CreateQuoteBatchFn = func(ctx context.Context, quotes []pensiondata.ScraperBatchQuote, author string) (pensiondata.PublicQuoteBatch, error) {
+			got = quotes
+			return pensiondata.PublicQuoteBatch{Created: len(quotes)}, nil
+		}
+
+		InitQuoteHandler(r, quoteService)
+
+		resp := httptest.NewRecorder()
+
+		scraperCreateQuotes := []pensiondata.ScraperCreateQuote{
+			{Date: "2020-06-30T00:00:00+02:00", Price: decimal.NewFromFloat(7.99)},
+			{Date: "2020-07-01T00:00:00+02:00", Price: decimal.NewFromFloat(8.01)},
+		}
+		jsonScraperCreateQuotes, _ := json.Marshal(scraperCreateQuotes)
+
+		req, _ := http.NewRequest(http.MethodPost, "/funds/be0948502365/quotes/batch", bytes.NewBuffer(jsonScraperCreateQuotes))
+		req.Header.Add("Authorization", "Bearer "+testScraperKey)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusOK != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusOK, resp.Code)
+		}
+		if contentTypeJson != resp.Header().Get("Content-Type") {
+			t.Errorf("want %s, got %s", contentTypeJson, resp.Header().Get("Content-Type"))
+		}
+		if len(got) != 2 || got[0].Isin != "BE0948502365" || got[1].Date != "2020-07-01T00:00:00+02:00" {
+			t.Errorf("want 2 quotes for BE0948502365, got %v", got)
+		}
+	})
+
+	t.Run("create cross-fund quote batch successfully", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		var got []pensiondata.ScraperBatchQuote
+		quoteService := pensiondata.QuoteServiceMock{}
+		quoteService.CreateQuoteBatchFn = func(ctx context.Context, quotes []pensiondata.ScraperBatchQuote, author string) (pensiondata.PublicQuoteBatch, error) {
+			got = quotes
+			return pensiondata.PublicQuoteBatch{Created: len(quotes)}, nil
+		}
+
+		InitQuoteHandler(r, quoteService)
+
+		resp := httptest.NewRecorder()
+
+		body := []byte(`[{"isin":"BE0948502365","date":"2020-06-30T00:00:00+02:00","price":7.99},{"isin":"LU0456789014","date":"2020-06-30T00:00:00+02:00","price":"12.5"}]`)
+
+		req, _ := http.NewRequest(http.MethodPost, "/quotes/batch", bytes.NewBuffer(body))
+		req.Header.Add("Authorization", "Bearer "+testScraperKey)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusOK != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusOK, resp.Code)
+		}
+		if len(got) != 2 || got[1].Isin != "LU0456789014" || !got[1].Price.Equal(decimal.NewFromFloat(12.5)) {
+			t.Errorf("want 2 quotes with their isin, got %v", got)
+		}
+	})
+
+	t.Run("return unauthorized without scraper key", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		InitQuoteHandler(r, pensiondata.QuoteServiceMock{})
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodPost, "/quotes/batch", bytes.NewBuffer([]byte("[]")))
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusUnauthorized != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusUnauthorized, resp.Code)
+		}
+	})
+
+	t.Run("return bad request error for invalid batch", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		quoteService := pensiondata.QuoteServiceMock{}
+		quoteService.CreateQuoteBatchFn = func(ctx context.Context, quotes []pensiondata.ScraperBatchQuote, author string) (pensiondata.PublicQuoteBatch, error) {
+			return pensiondata.PublicQuoteBatch{}, pensiondata.ErrInvalidBatch
+		}
+
+		InitQuoteHandler(r, quoteService)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodPost, "/quotes/batch", bytes.NewBuffer([]byte("[]")))
+		req.Header.Add("Authorization", "Bearer "+testScraperKey)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusBadRequest != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusBadRequest, resp.Code)
+		}
+	})
+
+	t.Run("return internal error", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		quoteService := pensiondata.QuoteServiceMock{}
+		quoteService.CreateQuoteBatchFn = func(ctx context.Context, quotes []pensiondata.ScraperBatchQuote, author string) (pensiondata.PublicQuoteBatch, error) {
+			return pensiondata.PublicQuoteBatch{}, errors.New("internal error")
+		}
+
+		InitQuoteHandler(r, quoteService)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodPost, "/quotes/batch", bytes.NewBuffer([]byte(`[{"isin":"BE0948502365"}]`)))
+		req.Header.Add("Authorization", "Bearer "+testScraperKey)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusInternalServerError != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusInternalServerError, resp.Code)
+		}
+	})
+}
+
+func testPrice(price string) pensiondata.Price {
+	return pensiondata.NewPrice(decimal.RequireFromString(price), 4)
+}
@@ -0,0 +1,46 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+)
+
+// RiskHandler handle all the HTTP requests for the risk metrics of a fund
+type RiskHandler struct {
+	s pensiondata.RiskService
+}
+
+// InitRiskHandler initialize a new RiskHandler and register routes
+func InitRiskHandler(router gin.IRouter, service pensiondata.RiskService) {
+	h := &RiskHandler{s: service}
+
+	router.GET("/funds/:isin/risk", h.GetRisk())
+}
+
+// GetRisk return the risk metrics of the given fund over the window given in query
+func (h RiskHandler) GetRisk() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		isin, ok := isinParam(context)
+		if !ok {
+			return
+		}
+		window := strings.ToLower(context.Query("window"))
+		publicRisk, err := h.s.GetRisk(context.Request.Context(), isin, window)
+
+		if err != nil {
+			writeError(context, err, errorDetails{
+				pensiondata.ErrInvalidWindow:   fmt.Sprintf("The window %s is invalid, use a duration such as 6m, 3y or max", window),
+				pensiondata.ErrFundNotFound:    fmt.Sprintf("The fund %s was not found", isin),
+				pensiondata.ErrQuoteNotFound:   fmt.Sprintf("No quotes are available for fund %s", isin),
+				pensiondata.ErrNotEnoughQuotes: fmt.Sprintf("The fund %s does not have enough quotes over this window", isin),
+			})
+			return
+		}
+
+		render(context, http.StatusOK, publicRisk)
+	}
+}
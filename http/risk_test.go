@@ -0,0 +1,110 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+)
+
+func TestGetRisk(t *testing.T) {
+	t.Run("return risk successfully", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := gin.Default()
+
+		var gotWindow string
+		s := pensiondata.RiskServiceMock{}
+		s.GetRiskFn = func(ctx context.Context, isin, window string) (pensiondata.PublicRisk, error) {
+			gotWindow = window
+			return pensiondata.PublicRisk{Isin: isin, Window: window}, nil
+		}
+
+		InitRiskHandler(r, s)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/risk?window=5Y", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusOK != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusOK, resp.Code)
+		}
+		if contentTypeJson != resp.Header().Get("Content-Type") {
+			t.Errorf("want %s, got %s", contentTypeJson, resp.Header().Get("Content-Type"))
+		}
+		if gotWindow != "5y" {
+			t.Errorf("want %s, got %s", "5y", gotWindow)
+		}
+	})
+
+	t.Run("return bad request error for invalid window", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := gin.Default()
+
+		s := pensiondata.RiskServiceMock{}
+		s.GetRiskFn = func(ctx context.Context, isin, window string) (pensiondata.PublicRisk, error) {
+			return pensiondata.PublicRisk{}, pensiondata.ErrInvalidWindow
+		}
+
+		InitRiskHandler(r, s)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/risk?window=3w", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusBadRequest != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusBadRequest, resp.Code)
+		}
+	})
+
+	t.Run("return unprocessable entity error when there are not enough quotes", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := gin.Default()
+
+		s := pensiondata.RiskServiceMock{}
+		s.GetRiskFn = func(ctx context.Context, isin, window string) (pensiondata.PublicRisk, error) {
+			return pensiondata.PublicRisk{}, pensiondata.ErrNotEnoughQuotes
+		}
+
+		InitRiskHandler(r, s)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/risk", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusUnprocessableEntity != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusUnprocessableEntity, resp.Code)
+		}
+	})
+
+	t.Run("return internal error", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := gin.Default()
+
+		s := pensiondata.RiskServiceMock{}
+		s.GetRiskFn = func(ctx context.Context, isin, window string) (pensiondata.PublicRisk, error) {
+			return pensiondata.PublicRisk{}, errors.New("internal error")
+		}
+
+		InitRiskHandler(r, s)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365/risk", nil)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusInternalServerError != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusInternalServerError, resp.Code)
+		}
+	})
+}
@@ -0,0 +1,37 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+)
+
+// Services are the services serving the routes of the API
+type Services struct {
+	APIKey      pensiondata.APIKeyService
+	Usage       pensiondata.UsageService
+	Fund        pensiondata.FundService
+	Quote       pensiondata.QuoteService
+	Quarantine  pensiondata.QuarantineService
+	DataQuality pensiondata.DataQualityService
+	Performance pensiondata.PerformanceService
+	Risk        pensiondata.RiskService
+	Simulation  pensiondata.SimulationService
+	Backtest    pensiondata.BacktestService
+	Compare     pensiondata.CompareService
+}
+
+// InitRoutes register the routes of every handler of the API, the ones described by the OpenAPI document
+func InitRoutes(router gin.IRouter, services Services) {
+	InitOpenAPIHandler(router)
+	InitAPIKeyHandler(router, services.APIKey)
+	InitUsageHandler(router, services.Usage)
+	InitFundHandler(router, services.Fund)
+	InitQuoteHandler(router, services.Quote)
+	InitQuarantineHandler(router, services.Quarantine)
+	InitDataQualityHandler(router, services.DataQuality)
+	InitPerformanceHandler(router, services.Performance)
+	InitRiskHandler(router, services.Risk)
+	InitSimulationHandler(router, services.Simulation)
+	InitBacktestHandler(router, services.Backtest)
+	InitCompareHandler(router, services.Compare)
+}
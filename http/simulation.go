@@ -0,0 +1,55 @@
+package http
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+)
+
+// SimulationHandler handle all the HTTP requests for simulations
+type SimulationHandler struct {
+	s pensiondata.SimulationService
+}
+
+// InitSimulationHandler initialize a new SimulationHandler and register routes
+func InitSimulationHandler(router gin.IRouter, service pensiondata.SimulationService) {
+	h := &SimulationHandler{s: service}
+
+	router.POST("/simulations/pension-savings", h.SimulatePensionSavings())
+}
+
+// SimulatePensionSavings return the projection of the requested pension savings plan
+func (h SimulationHandler) SimulatePensionSavings() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		var request pensiondata.PensionSavingsSimulationRequest
+		if err := context.ShouldBindJSON(&request); err != nil {
+			log.Printf("Error while binding request body to PensionSavingsSimulationRequest struct: %s", err)
+			writeProblem(context, problemInvalidBody, "The request body must be a valid pension savings simulation")
+			return
+		}
+		request.Isin = strings.ToUpper(request.Isin)
+		if err := pensiondata.ValidateISIN(request.Isin); err != nil {
+			writeError(context, err, nil)
+			return
+		}
+
+		publicSimulation, err := h.s.SimulatePensionSavings(context.Request.Context(), request)
+		if err == pensiondata.ErrQuoteNotFound {
+			// A fund without quotes cannot be simulated, like a fund with too few of them
+			err = pensiondata.ErrNotEnoughQuotes
+		}
+		if err != nil {
+			writeError(context, err, errorDetails{
+				pensiondata.ErrFundNotFound:    fmt.Sprintf("The fund %s was not found", request.Isin),
+				pensiondata.ErrNotEnoughQuotes: fmt.Sprintf("The fund %s needs at least one year of quotes to be simulated", request.Isin),
+			})
+			return
+		}
+
+		render(context, http.StatusOK, publicSimulation)
+	}
+}
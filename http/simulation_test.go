@@ -0,0 +1,143 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+	"github.com/shopspring/decimal"
+)
+
+func TestSimulatePensionSavings(t *testing.T) {
+	t.Run("return simulation successfully", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := gin.Default()
+
+		var got pensiondata.PensionSavingsSimulationRequest
+		s := pensiondata.SimulationServiceMock{}
+		s.SimulatePensionSavingsFn = func(ctx context.Context, request pensiondata.PensionSavingsSimulationRequest) (pensiondata.PublicPensionSavingsSimulation, error) {
+			got = request
+			return pensiondata.PublicPensionSavingsSimulation{Isin: request.Isin}, nil
+		}
+
+		InitSimulationHandler(r, s)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodPost, "/simulations/pension-savings", bytes.NewBuffer(testSimulationRequest()))
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusOK != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusOK, resp.Code)
+		}
+		if contentTypeJson != resp.Header().Get("Content-Type") {
+			t.Errorf("want %s, got %s", contentTypeJson, resp.Header().Get("Content-Type"))
+		}
+		if got.Isin != "BE0948502365" {
+			t.Errorf("want %s, got %s", "BE0948502365", got.Isin)
+		}
+	})
+
+	t.Run("return bad request error for invalid JSON binding", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := gin.Default()
+
+		InitSimulationHandler(r, pensiondata.SimulationServiceMock{})
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodPost, "/simulations/pension-savings", bytes.NewBufferString("false"))
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusBadRequest != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusBadRequest, resp.Code)
+		}
+	})
+
+	t.Run("return bad request error for invalid simulation", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := gin.Default()
+
+		s := pensiondata.SimulationServiceMock{}
+		s.SimulatePensionSavingsFn = func(ctx context.Context, request pensiondata.PensionSavingsSimulationRequest) (pensiondata.PublicPensionSavingsSimulation, error) {
+			return pensiondata.PublicPensionSavingsSimulation{}, fmt.Errorf("%w: the tax regime must be 30 or 25", pensiondata.ErrInvalidSimulation)
+		}
+
+		InitSimulationHandler(r, s)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodPost, "/simulations/pension-savings", bytes.NewBuffer(testSimulationRequest()))
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusBadRequest != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusBadRequest, resp.Code)
+		}
+	})
+
+	t.Run("return not found error for fund", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := gin.Default()
+
+		s := pensiondata.SimulationServiceMock{}
+		s.SimulatePensionSavingsFn = func(ctx context.Context, request pensiondata.PensionSavingsSimulationRequest) (pensiondata.PublicPensionSavingsSimulation, error) {
+			return pensiondata.PublicPensionSavingsSimulation{}, pensiondata.ErrFundNotFound
+		}
+
+		InitSimulationHandler(r, s)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodPost, "/simulations/pension-savings", bytes.NewBuffer(testSimulationRequest()))
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusNotFound != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusNotFound, resp.Code)
+		}
+	})
+
+	t.Run("return internal error", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := gin.Default()
+
+		s := pensiondata.SimulationServiceMock{}
+		s.SimulatePensionSavingsFn = func(ctx context.Context, request pensiondata.PensionSavingsSimulationRequest) (pensiondata.PublicPensionSavingsSimulation, error) {
+			return pensiondata.PublicPensionSavingsSimulation{}, errors.New("internal error")
+		}
+
+		InitSimulationHandler(r, s)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodPost, "/simulations/pension-savings", bytes.NewBuffer(testSimulationRequest()))
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusInternalServerError != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusInternalServerError, resp.Code)
+		}
+	})
+}
+
+func testSimulationRequest() []byte {
+	request, _ := json.Marshal(pensiondata.PensionSavingsSimulationRequest{
+		YearlyContribution: decimal.NewFromInt(990),
+		BirthDate:          "1990-05-12",
+		StartYear:          2020,
+		Isin:               "be0948502365",
+		TaxRegime:          pensiondata.TaxRegime30,
+	})
+
+	return request
+}
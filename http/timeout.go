@@ -0,0 +1,100 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultRequestTimeout is the deadline of the requests to the routes without a timeout of their own
+const DefaultRequestTimeout = 10 * time.Second
+
+// defaultRouteKey is the key of the default timeout in the list parsed by ParseRouteTimeouts
+const defaultRouteKey = "default"
+
+// RouteTimeouts are the deadlines of the requests, after which their queries are canceled.
+// Routes are keyed by method and path relative to the group, such as "GET /funds/:isin/risk",
+// Default applies to the other routes. A zero timeout serves the requests without deadline.
+// The exports are never given a deadline, they last as long as the client reads them.
+type RouteTimeouts struct {
+	Default time.Duration
+	Routes  map[string]time.Duration
+}
+
+// DefaultRouteTimeouts return the timeouts used when none is configured.
+// The stream of the quotes of every fund lasts as long as the client reads it and has no deadline.
+func DefaultRouteTimeouts() RouteTimeouts {
+	return RouteTimeouts{
+		Default: DefaultRequestTimeout,
+		Routes: map[string]time.Duration{
+			"GET /quotes": 0,
+		},
+	}
+}
+
+// ParseRouteTimeouts return the default timeouts overridden by the comma separated list of route=timeout,
+// as in "default=5s,GET /funds/:isin/risk=30s,GET /funds=0"
+func ParseRouteTimeouts(timeouts string) (RouteTimeouts, error) {
+	routeTimeouts := DefaultRouteTimeouts()
+	if strings.TrimSpace(timeouts) == "" {
+		return routeTimeouts, nil
+	}
+
+	for _, timeout := range strings.Split(timeouts, ",") {
+		parts := strings.SplitN(strings.TrimSpace(timeout), "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return RouteTimeouts{}, fmt.Errorf("the route timeout %s must be route=timeout", timeout)
+		}
+
+		duration, err := time.ParseDuration(parts[1])
+		if err != nil || duration < 0 {
+			return RouteTimeouts{}, fmt.Errorf("the timeout of the route %s must be a positive duration such as 5s", parts[0])
+		}
+
+		if parts[0] == defaultRouteKey {
+			routeTimeouts.Default = duration
+			continue
+		}
+
+		route := strings.Fields(parts[0])
+		if len(route) != 2 || !strings.HasPrefix(route[1], "/") {
+			return RouteTimeouts{}, fmt.Errorf("the route %s must be a method and a path such as GET /funds", parts[0])
+		}
+		routeTimeouts.Routes[strings.ToUpper(route[0])+" "+route[1]] = duration
+	}
+
+	return routeTimeouts, nil
+}
+
+// Timeout is a middleware to set the deadline of the requests to the routes of the group prefixed by prefix,
+// except for the requests exporting a collection as NDJSON, CSV or XLSX which are streamed without deadline.
+// The deadline is carried by the context of the request down to the queries, writeError translates it to a problem.
+func Timeout(prefix string, timeouts RouteTimeouts) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isExport(c) {
+			c.Next()
+			return
+		}
+
+		route := c.Request.Method + " " + "/" + strings.TrimPrefix(strings.TrimPrefix(c.FullPath(), strings.TrimSuffix(prefix, "/")), "/")
+
+		timeout, ok := timeouts.Routes[route]
+		if !ok {
+			timeout = timeouts.Default
+		}
+
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,123 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+)
+
+func TestParseRouteTimeouts(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeouts string
+		want     RouteTimeouts
+		wantErr  bool
+	}{
+		{name: "use default timeouts", timeouts: "", want: DefaultRouteTimeouts()},
+		{
+			name:     "override default and route timeouts",
+			timeouts: "default=5s, get /funds/:isin/risk=30s,GET /quotes=1m",
+			want: RouteTimeouts{Default: 5 * time.Second, Routes: map[string]time.Duration{
+				"GET /funds/:isin/risk": 30 * time.Second,
+				"GET /quotes":           time.Minute,
+			}},
+		},
+		{name: "return error for missing timeout", timeouts: "GET /funds", wantErr: true},
+		{name: "return error for invalid timeout", timeouts: "GET /funds=fast", wantErr: true},
+		{name: "return error for negative timeout", timeouts: "default=-1s", wantErr: true},
+		{name: "return error for route without method", timeouts: "/funds=5s", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRouteTimeouts(tt.timeouts)
+
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("want error %t, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(tt.want, got) {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := testRouter()
+
+	timeouts := RouteTimeouts{Default: time.Minute, Routes: map[string]time.Duration{
+		"GET /funds/:isin/quotes/:date": time.Millisecond,
+		"GET /funds":                    0,
+	}}
+
+	var deadline bool
+	quoteService := pensiondata.QuoteServiceMock{}
+	quoteService.StreamAllQuotesFn = func(ctx context.Context, fn func(pensiondata.PublicFundQuote) error) error {
+		_, deadline = ctx.Deadline()
+		return nil
+	}
+	quoteService.GetQuotePageFn = func(ctx context.Context, isin string, criteria pensiondata.QuoteCriteria) (pensiondata.PublicQuotePage, error) {
+		_, deadline = ctx.Deadline()
+		return pensiondata.PublicQuotePage{Quotes: testPublicQuotes()}, nil
+	}
+	quoteService.StreamQuotesFn = func(ctx context.Context, isin string, criteria pensiondata.QuoteCriteria, fn func(pensiondata.PublicQuote) error) error {
+		_, deadline = ctx.Deadline()
+		return nil
+	}
+	fundService := pensiondata.FundServiceMock{}
+	fundService.SearchFundsFn = func(ctx context.Context, criteria pensiondata.FundCriteria) ([]pensiondata.PublicFund, error) {
+		_, deadline = ctx.Deadline()
+		return []pensiondata.PublicFund{}, nil
+	}
+	quoteService.GetQuoteFn = func(ctx context.Context, isin, date string) (pensiondata.PublicQuote, error) {
+		<-ctx.Done()
+		return pensiondata.PublicQuote{}, ctx.Err()
+	}
+
+	v1 := r.Group("/v1", Version(V1), Timeout("/v1", timeouts))
+	InitQuoteHandler(v1, quoteService)
+	InitFundHandler(v1, fundService)
+
+	tests := []struct {
+		name         string
+		path         string
+		code         int
+		wantDeadline bool
+	}{
+		{name: "serve request with the default deadline", path: "/v1/quotes",
+			code: http.StatusOK, wantDeadline: true},
+		{name: "serve request of route with the default deadline", path: "/v1/funds/BE0948502365/quotes",
+			code: http.StatusOK, wantDeadline: true},
+		{name: "serve request of route without deadline", path: "/v1/funds",
+			code: http.StatusOK, wantDeadline: false},
+		{name: "serve export without deadline", path: "/v1/funds/BE0948502365/quotes?format=csv",
+			code: http.StatusOK, wantDeadline: false},
+		{name: "return timeout when the deadline of the route is exceeded", path: "/v1/funds/BE0948502365/quotes/2020-06-28",
+			code: http.StatusGatewayTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deadline = false
+			resp := httptest.NewRecorder()
+
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+
+			r.ServeHTTP(resp, req)
+
+			if tt.code != resp.Code {
+				t.Errorf("want %d, got %d", tt.code, resp.Code)
+			}
+			if tt.wantDeadline != deadline {
+				t.Errorf("want deadline %t, got %t", tt.wantDeadline, deadline)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+)
+
+// UsageHandler handle all the HTTP requests for the usage of the API
+type UsageHandler struct {
+	s pensiondata.UsageService
+}
+
+// InitUsageHandler initialize a new UsageHandler and register routes
+func InitUsageHandler(router gin.IRouter, service pensiondata.UsageService) {
+	h := &UsageHandler{s: service}
+
+	router.GET("/admin/usage", ScopeRequired(pensiondata.ScopeAdmin), h.GetUsage())
+}
+
+// GetUsage return the hourly usage of the API, filtered by the key, from and to query parameters
+func (h UsageHandler) GetUsage() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		criteria, err := parseUsageCriteria(context)
+		if err != nil {
+			writeProblem(context, problemInvalidParameter, fmt.Sprintf("Invalid query parameter: %s", err))
+			return
+		}
+
+		publicUsage, err := h.s.GetUsage(context.Request.Context(), criteria)
+		if err != nil {
+			writeError(context, err, errorDetails{
+				pensiondata.ErrInvalidCriteria: "The from date must be before the to date",
+			})
+			return
+		}
+
+		render(context, http.StatusOK, publicUsage)
+	}
+}
+
+// parseUsageCriteria return the UsageCriteria from the key, from and to query parameters.
+// The key is the public prefix of an API key, the to date is included.
+func parseUsageCriteria(context *gin.Context) (pensiondata.UsageCriteria, error) {
+	criteria := pensiondata.UsageCriteria{Key: context.Query("key")}
+	var err error
+
+	if from := context.Query("from"); from != "" {
+		if criteria.From, err = time.Parse("2006-01-02", from); err != nil {
+			return pensiondata.UsageCriteria{}, fmt.Errorf("the from date %s must use the YYYY-MM-DD format", from)
+		}
+	}
+
+	if to := context.Query("to"); to != "" {
+		if criteria.To, err = time.Parse("2006-01-02", to); err != nil {
+			return pensiondata.UsageCriteria{}, fmt.Errorf("the to date %s must use the YYYY-MM-DD format", to)
+		}
+		criteria.To = criteria.To.AddDate(0, 0, 1)
+	}
+
+	return criteria, nil
+}
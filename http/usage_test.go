@@ -0,0 +1,90 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+)
+
+func TestGetUsage(t *testing.T) {
+	t.Run("return usage successfully", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := testRouter()
+
+		var got pensiondata.UsageCriteria
+		usageService := pensiondata.UsageServiceMock{}
+		usageService.GetUsageFn = func(ctx context.Context, criteria pensiondata.UsageCriteria) ([]pensiondata.PublicUsage, error) {
+			got = criteria
+			return []pensiondata.PublicUsage{}, nil
+		}
+
+		InitUsageHandler(r, usageService)
+
+		resp := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/admin/usage?key=0a1b2c3d&from=2020-07-01&to=2020-07-14", nil)
+		req.Header.Add("Authorization", "Bearer "+testAdminKey)
+
+		r.ServeHTTP(resp, req)
+
+		if http.StatusOK != resp.Code {
+			t.Errorf("want %d, got %d", http.StatusOK, resp.Code)
+		}
+		if contentTypeJson != resp.Header().Get("Content-Type") {
+			t.Errorf("want %s, got %s", contentTypeJson, resp.Header().Get("Content-Type"))
+		}
+		if got.Key != "0a1b2c3d" || got.From.Format("2006-01-02") != "2020-07-01" || got.To.Format("2006-01-02") != "2020-07-15" {
+			t.Errorf("want criteria from query parameters with the to date included, got %v", got)
+		}
+	})
+
+	tests := []struct {
+		name       string
+		path       string
+		getUsageFn func(context.Context, pensiondata.UsageCriteria) ([]pensiondata.PublicUsage, error)
+		want       int
+	}{
+		{name: "return bad request error for invalid query parameter", path: "/admin/usage?from=07-2020", want: http.StatusBadRequest},
+		{
+			name: "return bad request error for invalid criteria",
+			path: "/admin/usage?from=2020-07-14&to=2020-07-01",
+			getUsageFn: func(ctx context.Context, criteria pensiondata.UsageCriteria) ([]pensiondata.PublicUsage, error) {
+				return []pensiondata.PublicUsage{}, pensiondata.ErrInvalidCriteria
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "return internal error",
+			path: "/admin/usage",
+			getUsageFn: func(ctx context.Context, criteria pensiondata.UsageCriteria) ([]pensiondata.PublicUsage, error) {
+				return []pensiondata.PublicUsage{}, errors.New("internal error")
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := testRouter()
+
+			InitUsageHandler(r, pensiondata.UsageServiceMock{GetUsageFn: tt.getUsageFn})
+
+			resp := httptest.NewRecorder()
+
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Add("Authorization", "Bearer "+testAdminKey)
+
+			r.ServeHTTP(resp, req)
+
+			if tt.want != resp.Code {
+				t.Errorf("want %d, got %d", tt.want, resp.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// versionKey is the key of the gin context holding the APIVersion serving the request
+const versionKey = "apiVersion"
+
+// APIVersion is a version of the API served under its own route group.
+// Every version is served by the same handlers and services, Represent converts the representations
+// returned by the services, such as pensiondata.PublicQuote, to the ones of the version.
+type APIVersion struct {
+	Name      string
+	Represent func(interface{}) interface{}
+}
+
+// V1 is the first version of the API, serving the representations of the services unchanged
+var V1 = APIVersion{Name: "v1", Represent: func(value interface{}) interface{} { return value }}
+
+// Version is a middleware to serve the requests of a route group with the given version,
+// in the price format requested by the client
+func Version(version APIVersion) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format, err := parsePriceFormat(c)
+		if err != nil {
+			writeProblem(c, problemInvalidParameter, fmt.Sprintf("Invalid price format: %s", err))
+			return
+		}
+
+		c.Set(versionKey, version)
+		c.Set(priceFormatKey, format)
+		c.Next()
+	}
+}
+
+// requestVersion return the version serving the request, V1 when the route is not in a versioned group
+func requestVersion(c *gin.Context) APIVersion {
+	if value, ok := c.Get(versionKey); ok {
+		if version, ok := value.(APIVersion); ok {
+			return version
+		}
+	}
+
+	return V1
+}
+
+// render write the value returned by the services as JSON, in the representation of the version serving the request.
+// In the string price format, prices and computed values are written as exact decimal strings.
+func render(c *gin.Context, code int, value interface{}) {
+	c.JSON(code, represent(c, value))
+}
+
+// marshal return the JSON encoding of the value returned by the services, in the representation of the version
+// serving the request and its price format
+func marshal(c *gin.Context, value interface{}) ([]byte, error) {
+	return json.Marshal(represent(c, value))
+}
+
+// represent return the value returned by the services in the representation of the version serving the request,
+// with exact decimal strings in the string price format
+func represent(c *gin.Context, value interface{}) interface{} {
+	value = requestVersion(c).Represent(value)
+	if requestPriceFormat(c) == PriceFormatString {
+		value = exactDecimals(value)
+	}
+
+	return value
+}
+
+// Deprecation announces the removal of a route group.
+// Sunset is the date of the removal, zero when not decided yet, and Successor the prefix of the group replacing it.
+type Deprecation struct {
+	At        time.Time
+	Sunset    time.Time
+	Successor string
+}
+
+// Deprecated is a middleware to announce the deprecation of the routes of the group prefixed by prefix, with the
+// Deprecation and Sunset headers and a link to the same route in the successor group
+func Deprecated(prefix string, deprecation Deprecation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", fmt.Sprintf("@%d", deprecation.At.Unix()))
+
+		if !deprecation.Sunset.IsZero() {
+			c.Header("Sunset", deprecation.Sunset.UTC().Format(http.TimeFormat))
+		}
+
+		if deprecation.Successor != "" {
+			successor := deprecation.Successor + strings.TrimPrefix(c.Request.URL.Path, strings.TrimSuffix(prefix, "/"))
+			c.Writer.Header().Add("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/obawi/pensiondata-api"
+)
+
+func TestVersion(t *testing.T) {
+	v2 := APIVersion{Name: "v2", Represent: func(value interface{}) interface{} {
+		if quote, ok := value.(pensiondata.PublicQuote); ok {
+			return gin.H{"day": quote.Date}
+		}
+		return value
+	}}
+
+	gin.SetMode(gin.TestMode)
+	r := testRouter()
+
+	quoteService := pensiondata.QuoteServiceMock{}
+	quoteService.GetQuoteFn = func(ctx context.Context, isin, date string) (pensiondata.PublicQuote, error) {
+		return pensiondata.PublicQuote{Date: date, Price: testPrice("7.99")}, nil
+	}
+
+	for _, group := range []*gin.RouterGroup{r.Group("/v1", Version(V1)), r.Group("/v2", Version(v2)), r.Group("/")} {
+		InitQuoteHandler(group, quoteService)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "serve v1 representation", path: "/v1/funds/BE0948502365/quotes/2020-06-30", want: `{"date":"2020-06-30","price":7.99}`},
+		{name: "serve v2 representation from the same service", path: "/v2/funds/BE0948502365/quotes/2020-06-30", want: `{"day":"2020-06-30"}`},
+		{name: "serve v1 representation at the root", path: "/funds/BE0948502365/quotes/2020-06-30", want: `{"date":"2020-06-30","price":7.99}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := httptest.NewRecorder()
+
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+
+			r.ServeHTTP(resp, req)
+
+			if http.StatusOK != resp.Code {
+				t.Errorf("want %d, got %d", http.StatusOK, resp.Code)
+			}
+			if tt.want != resp.Body.String() {
+				t.Errorf("want %s, got %s", tt.want, resp.Body.String())
+			}
+		})
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	deprecatedAt := time.Date(2021, time.September, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		deprecation Deprecation
+		want        map[string]string
+	}{
+		{
+			name:        "announce deprecation with successor",
+			deprecation: Deprecation{At: deprecatedAt, Successor: "/v1"},
+			want:        map[string]string{"Deprecation": "@1630454400", "Sunset": "", "Link": `</v1/funds/BE0948502365>; rel="successor-version"`},
+		},
+		{
+			name:        "announce sunset",
+			deprecation: Deprecation{At: deprecatedAt, Sunset: deprecatedAt.AddDate(0, 6, 0)},
+			want:        map[string]string{"Deprecation": "@1630454400", "Sunset": "Tue, 01 Mar 2022 00:00:00 GMT", "Link": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := testRouter()
+
+			fundService := pensiondata.FundServiceMock{}
+			fundService.GetFundByISINFn = func(ctx context.Context, isin string) (pensiondata.PublicFund, error) {
+				return pensiondata.PublicFund{Isin: isin}, nil
+			}
+
+			InitFundHandler(r.Group("/", Deprecated("/", tt.deprecation)), fundService)
+
+			resp := httptest.NewRecorder()
+
+			req, _ := http.NewRequest(http.MethodGet, "/funds/BE0948502365", nil)
+
+			r.ServeHTTP(resp, req)
+
+			if http.StatusOK != resp.Code {
+				t.Errorf("want %d, got %d", http.StatusOK, resp.Code)
+			}
+			for header, value := range tt.want {
+				if got := resp.Header().Get(header); got != value {
+					t.Errorf("want %s %s, got %s", header, value, got)
+				}
+			}
+		})
+	}
+}
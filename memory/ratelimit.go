@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/obawi/pensiondata-api"
+)
+
+// sweepInterval is the interval between two removals of the full buckets, which hold no state
+const sweepInterval = time.Minute
+
+// bucket is the token bucket of a client, refilled continuously since updated and full after period
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	period  time.Duration
+}
+
+// RateLimitStore is the struct used to implement the pensiondata.RateLimitStore interface in memory.
+// The buckets are local to the process, so the limits are per instance of the API.
+type RateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewRateLimitStore return a new RateLimitStore in memory
+func NewRateLimitStore() *RateLimitStore {
+	return &RateLimitStore{buckets: map[string]*bucket{}}
+}
+
+// Take take a token from the bucket of the client, a new client starts with a full bucket
+func (s *RateLimitStore) Take(client string, plan pensiondata.RateLimitPlan, now time.Time) (pensiondata.RateLimitResult, error) {
+	return s.refill(client, plan, now, true), nil
+}
+
+// Peek return the state of the bucket of the client without taking a token, a new client has a full bucket
+func (s *RateLimitStore) Peek(client string, plan pensiondata.RateLimitPlan, now time.Time) (pensiondata.RateLimitResult, error) {
+	return s.refill(client, plan, now, false), nil
+}
+
+// refill refill the bucket of the client up to now and take a token from it when take is true.
+// A peeked client without bucket is not given one, it would be full.
+func (s *RateLimitStore) refill(client string, plan pensiondata.RateLimitPlan, now time.Time, take bool) pensiondata.RateLimitResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	capacity := float64(plan.Limit)
+	perToken := plan.Period / time.Duration(plan.Limit)
+
+	b, ok := s.buckets[client]
+	if !ok {
+		b = &bucket{tokens: capacity, updated: now}
+		if take {
+			s.buckets[client] = b
+		}
+	}
+
+	b.tokens = math.Min(capacity, b.tokens+float64(now.Sub(b.updated))/float64(perToken))
+	b.updated = now
+	b.period = plan.Period
+
+	result := pensiondata.RateLimitResult{Limit: plan.Limit}
+	if b.tokens >= 1 {
+		if take {
+			b.tokens--
+		}
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.tokens) * float64(perToken))
+	}
+
+	result.Remaining = int(b.tokens)
+	result.Reset = time.Duration((capacity - b.tokens) * float64(perToken))
+
+	return result
+}
+
+// sweep remove the buckets refilled since, limiting the memory to the clients of the last period.
+// A removed bucket was full and is recreated full, so removing it does not change the limits.
+func (s *RateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for client, b := range s.buckets {
+		if now.Sub(b.updated) >= b.period {
+			delete(s.buckets, client)
+		}
+	}
+}
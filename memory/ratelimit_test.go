@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/obawi/pensiondata-api"
+)
+
+func TestTake(t *testing.T) {
+	plan := pensiondata.RateLimitPlan{Name: "test", Limit: 2, Period: 2 * time.Second}
+	now := time.Date(2020, time.July, 15, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		at   time.Duration
+		want pensiondata.RateLimitResult
+	}{
+		{name: "allow first request with full bucket", want: pensiondata.RateLimitResult{Allowed: true, Limit: 2, Remaining: 1, Reset: time.Second}},
+		{name: "allow burst", want: pensiondata.RateLimitResult{Allowed: true, Limit: 2, Remaining: 0, Reset: 2 * time.Second}},
+		{name: "deny empty bucket", at: 500 * time.Millisecond, want: pensiondata.RateLimitResult{Limit: 2, Remaining: 0, Reset: 1500 * time.Millisecond, RetryAfter: 500 * time.Millisecond}},
+		{name: "allow refilled token", at: time.Second, want: pensiondata.RateLimitResult{Allowed: true, Limit: 2, Remaining: 0, Reset: 2 * time.Second}},
+	}
+
+	store := NewRateLimitStore()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.Take("ip:127.0.0.1", plan, now.Add(tt.at))
+
+			if err != nil || got != tt.want {
+				t.Errorf("want %v, got %v (%v)", tt.want, got, err)
+			}
+		})
+	}
+
+	t.Run("limit each client separately", func(t *testing.T) {
+		got, _ := store.Take("ip:127.0.0.2", plan, now.Add(time.Second))
+
+		if !got.Allowed || got.Remaining != 1 {
+			t.Errorf("want full bucket for new client, got %v", got)
+		}
+	})
+
+	t.Run("remove full buckets", func(t *testing.T) {
+		store.Take("ip:127.0.0.1", plan, now.Add(time.Hour))
+
+		if len(store.buckets) != 1 {
+			t.Errorf("want only the bucket in use, got %d buckets", len(store.buckets))
+		}
+	})
+}
+
+func TestPeek(t *testing.T) {
+	plan := pensiondata.RateLimitPlan{Name: "test", Limit: 1, Period: time.Second}
+	now := time.Date(2020, time.July, 15, 9, 0, 0, 0, time.UTC)
+
+	store := NewRateLimitStore()
+
+	if got, _ := store.Peek("auth:127.0.0.1", plan, now); !got.Allowed || got.Remaining != 1 || len(store.buckets) != 0 {
+		t.Errorf("want full bucket for new client without creating it, got %v", got)
+	}
+
+	store.Take("auth:127.0.0.1", plan, now)
+	for i := 0; i < 2; i++ {
+		if got, _ := store.Peek("auth:127.0.0.1", plan, now); got.Allowed || got.RetryAfter != time.Second {
+			t.Errorf("want empty bucket left untouched, got %v", got)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package pensiondata
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Periods for which the performance of a fund is computed
+const (
+	PeriodYTD         = "ytd"
+	Period1M          = "1m"
+	Period3M          = "3m"
+	Period6M          = "6m"
+	Period1Y          = "1y"
+	Period3Y          = "3y"
+	Period5Y          = "5y"
+	Period10Y         = "10y"
+	PeriodSinceLaunch = "since_launch"
+)
+
+// performancePeriods is the ordered list of periods returned by the performance endpoint
+var performancePeriods = []string{
+	PeriodYTD, Period1M, Period3M, Period6M, Period1Y, Period3Y, Period5Y, Period10Y, PeriodSinceLaunch,
+}
+
+// daysPerYear is used to annualize returns over a number of calendar days
+const daysPerYear = 365.25
+
+// returnPrecision is the number of decimal places kept for returns
+const returnPrecision = 6
+
+// Performance is the performance of a fund over several periods, computed from its quotes
+type Performance struct {
+	Isin    string
+	AsOf    time.Time
+	Returns []PeriodReturn
+}
+
+// PeriodReturn is the return of a fund over a single period.
+// Available is false when the fund has no quote old enough to cover the period.
+type PeriodReturn struct {
+	Period     string
+	Available  bool
+	StartDate  time.Time
+	EndDate    time.Time
+	StartPrice decimal.Decimal
+	EndPrice   decimal.Decimal
+	Cumulative decimal.Decimal
+	Annualized decimal.NullDecimal
+}
+
+// PerformanceService handle the use cases for the performance of a fund
+type PerformanceService interface {
+	GetPerformance(context.Context, string) (PublicPerformance, error)
+}
+
+// PerformanceServiceImpl is the implementation of PerformanceService
+type PerformanceServiceImpl struct {
+	fundRepo  FundRepository
+	quoteRepo QuoteRepository
+}
+
+// NewPerformanceService return a new, fully functional, implementation of PerformanceService
+func NewPerformanceService(fundRepo FundRepository, quoteRepo QuoteRepository) *PerformanceServiceImpl {
+	return &PerformanceServiceImpl{fundRepo: fundRepo, quoteRepo: quoteRepo}
+}
+
+// GetPerformance return the cumulative and annualized returns of the fund for the given isin
+func (s PerformanceServiceImpl) GetPerformance(ctx context.Context, isin string) (PublicPerformance, error) {
+	fund, err := s.fundRepo.FindByISIN(ctx, isin)
+	if err != nil {
+		return PublicPerformance{}, err
+	}
+
+	quotes, err := s.quoteRepo.FindAll(ctx, isin)
+	if err != nil {
+		return PublicPerformance{}, err
+	}
+
+	if len(quotes) == 0 {
+		return PublicPerformance{}, ErrQuoteNotFound
+	}
+
+	return newPublicPerformance(computePerformance(fund, quotes)), nil
+}
+
+// computePerformance return the performance of the fund over every period, as of its latest quote.
+// Each period is anchored on the nearest quote at or before the start of the period.
+func computePerformance(fund Fund, quotes []Quote) Performance {
+	sorted := sortQuotesAsc(quotes)
+	end := sorted[len(sorted)-1]
+	asOf := truncateToDay(end.Date)
+
+	performance := Performance{Isin: fund.Isin, AsOf: asOf}
+	for _, period := range performancePeriods {
+		var start Quote
+		var found bool
+
+		if period == PeriodSinceLaunch {
+			start, found = launchQuote(fund, sorted)
+		} else {
+			start, found = quoteAtOrBefore(sorted, periodStart(period, asOf))
+		}
+
+		if !found {
+			performance.Returns = append(performance.Returns, PeriodReturn{Period: period})
+			continue
+		}
+
+		performance.Returns = append(performance.Returns, newPeriodReturn(period, start, end))
+	}
+
+	return performance
+}
+
+// newPeriodReturn return the return between the start and end quotes.
+// Returns are only annualized for periods of at least one year, as annualizing shorter
+// periods gives misleading figures.
+func newPeriodReturn(period string, start, end Quote) PeriodReturn {
+	periodReturn := PeriodReturn{
+		Period:     period,
+		Available:  true,
+		StartDate:  truncateToDay(start.Date),
+		EndDate:    truncateToDay(end.Date),
+		StartPrice: start.Price,
+		EndPrice:   end.Price,
+	}
+
+	if start.Price.IsZero() {
+		periodReturn.Available = false
+		return periodReturn
+	}
+
+	cumulative := end.Price.DivRound(start.Price, 16).Sub(decimal.NewFromInt(1))
+	periodReturn.Cumulative = cumulative.Round(returnPrecision)
+
+	days := periodReturn.EndDate.Sub(periodReturn.StartDate).Hours() / 24
+	if days >= 365 {
+		growth, _ := cumulative.Add(decimal.NewFromInt(1)).Float64()
+		annualized := math.Pow(growth, daysPerYear/days) - 1
+		periodReturn.Annualized = decimal.NullDecimal{
+			Decimal: decimal.NewFromFloat(annualized).Round(returnPrecision),
+			Valid:   true,
+		}
+	}
+
+	return periodReturn
+}
+
+// periodStart return the start date of the given period for a performance computed as of asOf
+func periodStart(period string, asOf time.Time) time.Time {
+	switch period {
+	case PeriodYTD:
+		return time.Date(asOf.Year()-1, time.December, 31, 0, 0, 0, 0, time.UTC)
+	case Period1M:
+		return subtractMonths(asOf, 1)
+	case Period3M:
+		return subtractMonths(asOf, 3)
+	case Period6M:
+		return subtractMonths(asOf, 6)
+	case Period1Y:
+		return subtractMonths(asOf, 12)
+	case Period3Y:
+		return subtractMonths(asOf, 36)
+	case Period5Y:
+		return subtractMonths(asOf, 60)
+	case Period10Y:
+		return subtractMonths(asOf, 120)
+	}
+
+	return asOf
+}
+
+// launchQuote return the quote to use as the start of the since-launch period.
+// It is the nearest quote at or before the launch date or, when the history starts after
+// the launch, the first available quote.
+func launchQuote(fund Fund, sorted []Quote) (Quote, bool) {
+	if !fund.LaunchDate.IsZero() {
+		if quote, found := quoteAtOrBefore(sorted, truncateToDay(fund.LaunchDate)); found {
+			return quote, true
+		}
+	}
+
+	return sorted[0], true
+}
+
+// quoteAtOrBefore return the latest quote dated at or before the given day from quotes sorted by date asc
+func quoteAtOrBefore(sorted []Quote, day time.Time) (Quote, bool) {
+	i := sort.Search(len(sorted), func(i int) bool {
+		return truncateToDay(sorted[i].Date).After(day)
+	})
+	if i == 0 {
+		return Quote{}, false
+	}
+
+	return sorted[i-1], true
+}
+
+// sortQuotesAsc return a copy of the quotes sorted by date asc
+func sortQuotesAsc(quotes []Quote) []Quote {
+	sorted := make([]Quote, len(quotes))
+	copy(sorted, quotes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	return sorted
+}
+
+// truncateToDay return the calendar day of t at midnight UTC
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// subtractMonths return t minus the given number of months, clamped to the last day of the target month
+func subtractMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	firstOfMonth := time.Date(year, month-time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+
+	return time.Date(firstOfMonth.Year(), firstOfMonth.Month(), day, 0, 0, 0, 0, time.UTC)
+}
+
+// PublicPerformance is Performance's representation to be returned by the API
+type PublicPerformance struct {
+	Isin    string               `json:"isin"`
+	AsOf    string               `json:"as_of"`
+	Returns []PublicPeriodReturn `json:"returns"`
+}
+
+// PublicPeriodReturn is PeriodReturn's representation to be returned by the API.
+// Returns are expressed as fractions (0.05 is 5%) and are null when not available.
+type PublicPeriodReturn struct {
+	Period           string  `json:"period"`
+	StartDate        string  `json:"start_date,omitempty"`
+	EndDate          string  `json:"end_date,omitempty"`
+	CumulativeReturn *Number `json:"cumulative_return"`
+	AnnualizedReturn *Number `json:"annualized_return"`
+}
+
+// ExactDecimals return the performance with its returns written as exact decimal strings
+func (p PublicPerformance) ExactDecimals() PublicPerformance {
+	returns := make([]PublicPeriodReturn, len(p.Returns))
+	for i, periodReturn := range p.Returns {
+		periodReturn.CumulativeReturn = exactNumber(periodReturn.CumulativeReturn)
+		periodReturn.AnnualizedReturn = exactNumber(periodReturn.AnnualizedReturn)
+		returns[i] = periodReturn
+	}
+	p.Returns = returns
+
+	return p
+}
+
+// newPublicPerformance return a PublicPerformance based on a Performance
+func newPublicPerformance(performance Performance) PublicPerformance {
+	publicPerformance := PublicPerformance{
+		Isin:    performance.Isin,
+		AsOf:    performance.AsOf.Format("2006-01-02"),
+		Returns: []PublicPeriodReturn{},
+	}
+
+	for _, periodReturn := range performance.Returns {
+		publicPerformance.Returns = append(publicPerformance.Returns, newPublicPeriodReturn(periodReturn))
+	}
+
+	return publicPerformance
+}
+
+// newPublicPeriodReturn return a PublicPeriodReturn based on a PeriodReturn
+func newPublicPeriodReturn(periodReturn PeriodReturn) PublicPeriodReturn {
+	publicPeriodReturn := PublicPeriodReturn{Period: periodReturn.Period}
+	if !periodReturn.Available {
+		return publicPeriodReturn
+	}
+
+	cumulative := NewNumber(periodReturn.Cumulative)
+	publicPeriodReturn.StartDate = periodReturn.StartDate.Format("2006-01-02")
+	publicPeriodReturn.EndDate = periodReturn.EndDate.Format("2006-01-02")
+	publicPeriodReturn.CumulativeReturn = &cumulative
+
+	if periodReturn.Annualized.Valid {
+		annualized := NewNumber(periodReturn.Annualized.Decimal)
+		publicPeriodReturn.AnnualizedReturn = &annualized
+	}
+
+	return publicPeriodReturn
+}
@@ -0,0 +1,13 @@
+package pensiondata
+
+import "context"
+
+// PerformanceServiceMock used for tests
+type PerformanceServiceMock struct {
+	GetPerformanceFn func(context.Context, string) (PublicPerformance, error)
+}
+
+// GetPerformance mock
+func (s PerformanceServiceMock) GetPerformance(ctx context.Context, isin string) (PublicPerformance, error) {
+	return s.GetPerformanceFn(ctx, isin)
+}
@@ -0,0 +1,144 @@
+package pensiondata
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestGetPerformance(t *testing.T) {
+	t.Run("return performance successfully", func(t *testing.T) {
+		fundRepo := FundRepositoryMock{}
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return testPerformanceFund(), nil
+		}
+
+		quoteRepo := QuoteRepositoryMock{}
+		quoteRepo.FindAllFn = func(ctx context.Context, isin string) ([]Quote, error) {
+			return testPerformanceQuotes(), nil
+		}
+
+		s := NewPerformanceService(fundRepo, quoteRepo)
+		got, err := s.GetPerformance(context.Background(), "BE123")
+		if err != nil {
+			t.Fatalf("want no error, got %s", err)
+		}
+
+		if got.AsOf != "2020-06-30" {
+			t.Errorf("want %s, got %s", "2020-06-30", got.AsOf)
+		}
+		if len(got.Returns) != len(performancePeriods) {
+			t.Fatalf("want %d, got %d", len(performancePeriods), len(got.Returns))
+		}
+
+		wants := map[string]float64{
+			PeriodYTD:         0.1,
+			Period1M:          0.03125,
+			Period1Y:          0.178571,
+			Period3Y:          0.65,
+			PeriodSinceLaunch: 0.65,
+		}
+		for _, periodReturn := range got.Returns {
+			want, ok := wants[periodReturn.Period]
+			if !ok {
+				continue
+			}
+			if periodReturn.CumulativeReturn == nil || periodReturn.CumulativeReturn.Float64() != want {
+				t.Errorf("%s: want %f, got %v", periodReturn.Period, want, periodReturn.CumulativeReturn)
+			}
+		}
+	})
+
+	t.Run("return error for fund", func(t *testing.T) {
+		fundRepo := FundRepositoryMock{}
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return Fund{}, errors.New("error")
+		}
+
+		s := NewPerformanceService(fundRepo, QuoteRepositoryMock{})
+		_, err := s.GetPerformance(context.Background(), "BE123")
+
+		if err == nil {
+			t.Errorf("want error")
+		}
+	})
+
+	t.Run("return error when fund has no quotes", func(t *testing.T) {
+		fundRepo := FundRepositoryMock{}
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return testPerformanceFund(), nil
+		}
+
+		quoteRepo := QuoteRepositoryMock{}
+		quoteRepo.FindAllFn = func(ctx context.Context, isin string) ([]Quote, error) {
+			return []Quote{}, nil
+		}
+
+		s := NewPerformanceService(fundRepo, quoteRepo)
+		_, err := s.GetPerformance(context.Background(), "BE123")
+
+		if err != ErrQuoteNotFound {
+			t.Errorf("want %s, got %v", ErrQuoteNotFound, err)
+		}
+	})
+}
+
+func TestComputePerformance(t *testing.T) {
+	t.Run("anchor periods on the nearest quote at or before the start", func(t *testing.T) {
+		got := computePerformance(testPerformanceFund(), testPerformanceQuotes())
+
+		for _, periodReturn := range got.Returns {
+			switch periodReturn.Period {
+			case Period1M:
+				if periodReturn.StartDate.Format("2006-01-02") != "2020-05-29" {
+					t.Errorf("want %s, got %s", "2020-05-29", periodReturn.StartDate.Format("2006-01-02"))
+				}
+				if periodReturn.Annualized.Valid {
+					t.Errorf("want no annualized return for %s", periodReturn.Period)
+				}
+			case Period3Y:
+				if !periodReturn.Annualized.Valid {
+					t.Errorf("want annualized return for %s", periodReturn.Period)
+				}
+			case Period10Y:
+				if periodReturn.Available {
+					t.Errorf("want %s to be unavailable", periodReturn.Period)
+				}
+			}
+		}
+	})
+}
+
+func TestSubtractMonths(t *testing.T) {
+	t.Run("clamp to the last day of the month", func(t *testing.T) {
+		date, _ := time.Parse("2006-01-02", "2020-03-31")
+		got := subtractMonths(date, 1)
+
+		if got.Format("2006-01-02") != "2020-02-29" {
+			t.Errorf("want %s, got %s", "2020-02-29", got.Format("2006-01-02"))
+		}
+	})
+}
+
+func testPerformanceFund() Fund {
+	launchDate, _ := time.Parse("2006-01-02", "2015-01-02")
+	return Fund{Isin: "BE123", Name: "First Fund", LaunchDate: launchDate, Currency: "EUR"}
+}
+
+func testPerformanceQuotes() []Quote {
+	quote := func(date string, price float64) Quote {
+		d, _ := time.Parse("2006-01-02", date)
+		return Quote{Date: d, Price: decimal.NewFromFloat(price)}
+	}
+
+	return []Quote{
+		quote("2020-06-30", 165),
+		quote("2020-05-29", 160),
+		quote("2019-12-31", 150),
+		quote("2019-06-28", 140),
+		quote("2015-01-02", 100),
+	}
+}
@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/obawi/pensiondata-api"
+)
+
+// apiKeySelect select the API keys in the order scanned by scanAPIKey
+const apiKeySelect = "SELECT id, name, prefix, hash, scopes, plan, created_at, expires_at, last_used_at, revoked_at FROM api_keys"
+
+// APIKeyRepository is the struct used to implement the pensiondata.APIKeyRepository interface for Postgres
+type APIKeyRepository struct {
+	DB *sql.DB
+}
+
+// NewAPIKeyRepository return a new APIKeyRepository for Postgres
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{DB: db}
+}
+
+// FindByPrefix return the API key for the given prefix
+func (r APIKeyRepository) FindByPrefix(ctx context.Context, prefix string) (pensiondata.APIKey, error) {
+	row := r.DB.QueryRowContext(ctx, apiKeySelect+" WHERE prefix = $1;", prefix)
+
+	apiKey, err := scanAPIKey(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return pensiondata.APIKey{}, pensiondata.ErrAPIKeyNotFound
+		}
+		return pensiondata.APIKey{}, err
+	}
+
+	return apiKey, nil
+}
+
+// FindAll return all API keys, oldest first
+func (r APIKeyRepository) FindAll(ctx context.Context) ([]pensiondata.APIKey, error) {
+	rows, err := r.DB.QueryContext(ctx, apiKeySelect+" ORDER BY created_at ASC, id ASC;")
+	if err != nil {
+		return []pensiondata.APIKey{}, err
+	}
+	defer rows.Close()
+
+	var apiKeys []pensiondata.APIKey
+	for rows.Next() {
+		apiKey, err := scanAPIKey(rows)
+		if err != nil {
+			return []pensiondata.APIKey{}, err
+		}
+		apiKeys = append(apiKeys, apiKey)
+	}
+
+	if err = rows.Err(); err != nil {
+		return []pensiondata.APIKey{}, err
+	}
+
+	return apiKeys, nil
+}
+
+// Create return the newly created API key
+func (r APIKeyRepository) Create(ctx context.Context, apiKey pensiondata.APIKey) (pensiondata.APIKey, error) {
+	row := r.DB.QueryRowContext(ctx, "INSERT INTO api_keys (name, prefix, hash, scopes, plan, expires_at) VALUES ($1, $2, $3, $4, $5, $6) "+
+		"RETURNING id, name, prefix, hash, scopes, plan, created_at, expires_at, last_used_at, revoked_at;",
+		apiKey.Name, apiKey.Prefix, apiKey.Hash, pq.Array(apiKey.Scopes), apiKey.Plan, nullTime(apiKey.ExpiresAt))
+
+	return scanAPIKey(row)
+}
+
+// Revoke revoke the API key for the given id, revoking it twice keeps the first revocation date
+func (r APIKeyRepository) Revoke(ctx context.Context, id int64) error {
+	row := r.DB.QueryRowContext(ctx, "UPDATE api_keys SET revoked_at = COALESCE(revoked_at, now()) WHERE id = $1 RETURNING id;", id)
+
+	if err := row.Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return pensiondata.ErrAPIKeyNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// UpdateLastUsed record the last time the API key for the given id was used
+func (r APIKeyRepository) UpdateLastUsed(ctx context.Context, id int64, lastUsedAt time.Time) error {
+	_, err := r.DB.ExecContext(ctx, "UPDATE api_keys SET last_used_at = $1 WHERE id = $2;", lastUsedAt, id)
+
+	return err
+}
+
+// scanAPIKey return the API key scanned from a row selected with apiKeySelect
+func scanAPIKey(s scanner) (pensiondata.APIKey, error) {
+	var apiKey pensiondata.APIKey
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	err := s.Scan(&apiKey.ID, &apiKey.Name, &apiKey.Prefix, &apiKey.Hash, pq.Array(&apiKey.Scopes), &apiKey.Plan, &apiKey.CreatedAt,
+		&expiresAt, &lastUsedAt, &revokedAt)
+	if err != nil {
+		return pensiondata.APIKey{}, err
+	}
+
+	apiKey.ExpiresAt = expiresAt.Time
+	apiKey.LastUsedAt = lastUsedAt.Time
+	apiKey.RevokedAt = revokedAt.Time
+
+	return apiKey, nil
+}
+
+// nullTime return a null value for a zero time
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -1,10 +1,31 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/lib/pq"
 	"github.com/obawi/pensiondata-api"
+	"github.com/shopspring/decimal"
 )
 
+// uniqueViolation is the Postgres error code raised when a unique constraint is violated
+const uniqueViolation = "23505"
+
+// fundSelect select the funds with the version of their details applicable today
+const fundSelect = "SELECT f.isin, f.name, f.bank, f.launch_date, f.currency, f.pricing_frequency, f.price_precision, " +
+	"d.valid_from, d.entry_fee, d.ongoing_charges, d.risk_class, d.equity_allocation, d.bond_allocation, " +
+	"d.management_company, d.benchmark " +
+	"FROM funds f LEFT JOIN LATERAL (SELECT * FROM fund_details WHERE fund_isin = f.isin AND valid_from <= CURRENT_DATE " +
+	"ORDER BY valid_from DESC LIMIT 1) d ON TRUE"
+
+// fundDetailsColumns are the columns of the fund_details table in the order scanned by scanFundDetails
+const fundDetailsColumns = "valid_from, entry_fee, ongoing_charges, risk_class, equity_allocation, bond_allocation, " +
+	"management_company, benchmark"
+
 // FundRepository is the struct used to implement the pensiondata.FundRepository interface for Postgres
 type FundRepository struct {
 	DB *sql.DB
@@ -16,11 +37,11 @@ func NewFundRepository(db *sql.DB) *FundRepository {
 }
 
 // FindByISIN return the fund for the given isin
-func (r FundRepository) FindByISIN(isin string) (pensiondata.Fund, error) {
-	row := r.DB.QueryRow("SELECT isin, name, bank, launch_date, currency FROM funds WHERE isin = $1;", isin)
+func (r FundRepository) FindByISIN(ctx context.Context, isin string) (pensiondata.Fund, error) {
+	row := r.DB.QueryRowContext(ctx, fundSelect+" WHERE f.isin = $1;", isin)
 
-	var fund pensiondata.Fund
-	if err := row.Scan(&fund.Isin, &fund.Name, &fund.Bank, &fund.LaunchDate, &fund.Currency); err != nil {
+	fund, err := scanFund(row)
+	if err != nil {
 		if err == sql.ErrNoRows {
 			return pensiondata.Fund{}, pensiondata.ErrFundNotFound
 		}
@@ -31,9 +52,9 @@ func (r FundRepository) FindByISIN(isin string) (pensiondata.Fund, error) {
 }
 
 // FindAll return all funds
-func (r FundRepository) FindAll() ([]pensiondata.Fund, error) {
+func (r FundRepository) FindAll(ctx context.Context) ([]pensiondata.Fund, error) {
 	var funds []pensiondata.Fund
-	rows, err := r.DB.Query("SELECT isin, name, bank, launch_date, currency FROM funds ORDER BY name ASC;")
+	rows, err := r.DB.QueryContext(ctx, fundSelect+" ORDER BY f.name ASC;")
 	if err != nil {
 		return []pensiondata.Fund{}, err
 	}
@@ -41,8 +62,8 @@ func (r FundRepository) FindAll() ([]pensiondata.Fund, error) {
 	defer rows.Close()
 
 	for rows.Next() {
-		fund := pensiondata.Fund{}
-		if err := rows.Scan(&fund.Isin, &fund.Name, &fund.Bank, &fund.LaunchDate, &fund.Currency); err != nil {
+		fund, err := scanFund(rows)
+		if err != nil {
 			return []pensiondata.Fund{}, err
 		}
 		funds = append(funds, fund)
@@ -54,3 +75,213 @@ func (r FundRepository) FindAll() ([]pensiondata.Fund, error) {
 
 	return funds, nil
 }
+
+// fundSortColumns map the sort fields of pensiondata.FundCriteria to their column
+var fundSortColumns = map[string]string{
+	pensiondata.FundSortName:       "f.name",
+	pensiondata.FundSortBank:       "f.bank",
+	pensiondata.FundSortLaunchDate: "f.launch_date",
+	pensiondata.FundSortCurrency:   "f.currency",
+	pensiondata.FundSortRiskClass:  "d.risk_class",
+}
+
+// FindByCriteria return the funds matching the given criteria
+func (r FundRepository) FindByCriteria(ctx context.Context, criteria pensiondata.FundCriteria) ([]pensiondata.Fund, error) {
+	var funds []pensiondata.Fund
+	err := r.StreamByCriteria(ctx, criteria, func(fund pensiondata.Fund) error {
+		funds = append(funds, fund)
+		return nil
+	})
+	if err != nil {
+		return []pensiondata.Fund{}, err
+	}
+
+	return funds, nil
+}
+
+// StreamByCriteria call fn with each fund matching the given criteria as it is read, without holding the funds
+// in memory. It stops at the first error returned by fn and return it, the query being canceled when ctx is done.
+func (r FundRepository) StreamByCriteria(ctx context.Context, criteria pensiondata.FundCriteria, fn func(pensiondata.Fund) error) error {
+	var conditions []string
+	var args []interface{}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if criteria.Bank != "" {
+		where("LOWER(f.bank) = LOWER($%d)", criteria.Bank)
+	}
+	if criteria.Currency != "" {
+		where("f.currency = UPPER($%d)", criteria.Currency)
+	}
+	if !criteria.LaunchedBefore.IsZero() {
+		where("f.launch_date < $%d", criteria.LaunchedBefore.Format("2006-01-02"))
+	}
+	if !criteria.LaunchedAfter.IsZero() {
+		where("f.launch_date > $%d", criteria.LaunchedAfter.Format("2006-01-02"))
+	}
+	if criteria.RiskClass != 0 {
+		where("d.risk_class = $%d", criteria.RiskClass)
+	}
+	if tsQuery := prefixTsQuery(criteria.Query); tsQuery != "" {
+		where("to_tsvector('simple', immutable_unaccent(f.name)) @@ to_tsquery('simple', immutable_unaccent($%d))", tsQuery)
+	}
+
+	query := fundSelect
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	column, ok := fundSortColumns[criteria.Sort]
+	if !ok {
+		column = fundSortColumns[pensiondata.FundSortName]
+	}
+	order := "ASC"
+	if criteria.Order == pensiondata.OrderDesc {
+		order = "DESC"
+	}
+	query += fmt.Sprintf(" ORDER BY %s %s NULLS LAST, f.name ASC;", column, order)
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		fund, err := scanFund(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(fund); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// prefixTsQuery return a tsquery matching every word of the search as a prefix, such as "pension:* & epargne:*".
+// Characters other than letters and digits are dropped so the search cannot break the tsquery syntax.
+func prefixTsQuery(search string) string {
+	words := strings.FieldsFunc(search, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, word := range words {
+		words[i] = strings.ToLower(word) + ":*"
+	}
+
+	return strings.Join(words, " & ")
+}
+
+// FindDetailsHistory return every version of the details of the fund for the given isin ordered by date desc
+func (r FundRepository) FindDetailsHistory(ctx context.Context, isin string) ([]pensiondata.FundDetails, error) {
+	rows, err := r.DB.QueryContext(ctx, "SELECT "+fundDetailsColumns+" FROM fund_details WHERE fund_isin = $1 ORDER BY valid_from DESC;", isin)
+	if err != nil {
+		return []pensiondata.FundDetails{}, err
+	}
+	defer rows.Close()
+
+	var history []pensiondata.FundDetails
+	for rows.Next() {
+		details, err := scanFundDetails(rows)
+		if err != nil {
+			return []pensiondata.FundDetails{}, err
+		}
+		history = append(history, details)
+	}
+
+	if err = rows.Err(); err != nil {
+		return []pensiondata.FundDetails{}, err
+	}
+
+	return history, nil
+}
+
+// CreateDetails return the newly created version of the details of the fund for the given isin
+func (r FundRepository) CreateDetails(ctx context.Context, isin string, details pensiondata.FundDetails) (pensiondata.FundDetails, error) {
+	row := r.DB.QueryRowContext(ctx, "INSERT INTO fund_details (fund_isin, "+fundDetailsColumns+") "+
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING "+fundDetailsColumns+";",
+		isin, details.ValidFrom, details.EntryFee, details.OngoingCharges, nullInt(details.RiskClass),
+		details.EquityAllocation, details.BondAllocation, nullString(details.ManagementCompany), nullString(details.Benchmark))
+
+	createdDetails, err := scanFundDetails(row)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+			return pensiondata.FundDetails{}, pensiondata.ErrFundDetailsAlreadyExist
+		}
+		return pensiondata.FundDetails{}, err
+	}
+
+	return createdDetails, nil
+}
+
+// scanner is implemented by *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanFund return the fund scanned from a row selected with fundSelect
+func scanFund(s scanner) (pensiondata.Fund, error) {
+	var fund pensiondata.Fund
+	var details nullFundDetails
+	err := s.Scan(&fund.Isin, &fund.Name, &fund.Bank, &fund.LaunchDate, &fund.Currency, &fund.PricingFrequency,
+		&fund.PricePrecision, &details.validFrom, &details.entryFee, &details.ongoingCharges, &details.riskClass,
+		&details.equityAllocation, &details.bondAllocation, &details.managementCompany, &details.benchmark)
+	if err != nil {
+		return pensiondata.Fund{}, err
+	}
+	fund.Details = details.toFundDetails()
+
+	return fund, nil
+}
+
+// scanFundDetails return the fund details scanned from a row selected with fundDetailsColumns
+func scanFundDetails(s scanner) (pensiondata.FundDetails, error) {
+	var details nullFundDetails
+	err := s.Scan(&details.validFrom, &details.entryFee, &details.ongoingCharges, &details.riskClass,
+		&details.equityAllocation, &details.bondAllocation, &details.managementCompany, &details.benchmark)
+	if err != nil {
+		return pensiondata.FundDetails{}, err
+	}
+
+	return details.toFundDetails(), nil
+}
+
+// nullFundDetails hold the nullable columns of a fund_details row, which are all null when a fund has no details
+type nullFundDetails struct {
+	validFrom         sql.NullTime
+	entryFee          decimal.NullDecimal
+	ongoingCharges    decimal.NullDecimal
+	riskClass         sql.NullInt32
+	equityAllocation  decimal.NullDecimal
+	bondAllocation    decimal.NullDecimal
+	managementCompany sql.NullString
+	benchmark         sql.NullString
+}
+
+// toFundDetails return the pensiondata.FundDetails with zero values for null columns
+func (d nullFundDetails) toFundDetails() pensiondata.FundDetails {
+	return pensiondata.FundDetails{
+		ValidFrom:         d.validFrom.Time,
+		EntryFee:          d.entryFee,
+		OngoingCharges:    d.ongoingCharges,
+		RiskClass:         int(d.riskClass.Int32),
+		EquityAllocation:  d.equityAllocation,
+		BondAllocation:    d.bondAllocation,
+		ManagementCompany: d.managementCompany.String,
+		Benchmark:         d.benchmark.String,
+	}
+}
+
+// nullInt return a null value for 0
+func nullInt(i int) sql.NullInt32 {
+	return sql.NullInt32{Int32: int32(i), Valid: i != 0}
+}
+
+// nullString return a null value for an empty string
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,293 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// migrationLockID is the key of the advisory lock held by the transaction applying the migrations,
+// so that the instances sharing the database apply them one after the other
+const migrationLockID = 726173616
+
+// migrationFileName is the name of the migration files: version, name and direction, as in 0001_create_funds.up.sql
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration is a versioned change of the schema, Up applying it and Down reverting it
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is a migration and the time it was applied, zero when it is pending
+type MigrationStatus struct {
+	Migration
+	AppliedAt time.Time
+}
+
+// Migrations return the migrations embedded in the binary, ordered by version
+func Migrations() ([]Migration, error) {
+	return readMigrations(migrationFiles, "migrations")
+}
+
+// readMigrations return the migrations of the directory, every version must have both an up and a down file
+func readMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := map[int]*Migration{}
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("the migration file %s must be named version_name.up.sql or version_name.down.sql", entry.Name())
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("the version of the migration file %s must be a positive number", entry.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, dir+"/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := migrations[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			migrations[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("the migration %d is named both %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	sorted := make([]Migration, 0, len(migrations))
+	for _, migration := range migrations {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("the migration %d_%s must have an up and a down file", migration.Version, migration.Name)
+		}
+		sorted = append(sorted, *migration)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	return sorted, nil
+}
+
+// Migrator applies the embedded migrations to the database, recording the applied versions in schema_migrations
+type Migrator struct {
+	DB *sql.DB
+}
+
+// NewMigrator return a new Migrator
+func NewMigrator(db *sql.DB) *Migrator {
+	return &Migrator{DB: db}
+}
+
+// Up apply the pending migrations in a single transaction and return them, none is applied when one fails
+func (m Migrator) Up(ctx context.Context) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := m.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	applied, err := appliedMigrations(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := pendingMigrations(migrations, applied)
+	for _, migration := range pending {
+		if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+			return nil, fmt.Errorf("error while applying migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2);",
+			migration.Version, migration.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return pending, nil
+}
+
+// Down revert the given number of applied migrations, latest first, in a single transaction and return them
+func (m Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := m.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	applied, err := appliedMigrations(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	reverted, err := revertedMigrations(migrations, applied, steps)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, migration := range reverted {
+		if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+			return nil, fmt.Errorf("error while reverting migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1;", migration.Version); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return reverted, nil
+}
+
+// Status return every embedded migration with the time it was applied
+func (m Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := m.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	applied, err := appliedMigrations(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		statuses = append(statuses, MigrationStatus{Migration: migration, AppliedAt: applied[migration.Version]})
+	}
+
+	return statuses, tx.Commit()
+}
+
+// begin start a transaction holding the migration lock, schema_migrations being created if needed.
+// The lock is released when the transaction ends.
+func (m Migrator) begin(ctx context.Context) (*sql.Tx, error) {
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1);", migrationLockID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS schema_migrations ("+
+		"version INTEGER PRIMARY KEY, name TEXT NOT NULL, applied_at TIMESTAMPTZ NOT NULL DEFAULT now());"); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// appliedMigrations return the time each applied version was applied at
+func appliedMigrations(ctx context.Context, tx *sql.Tx) (map[int]time.Time, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// pendingMigrations return the migrations that have not been applied, ordered by version
+func pendingMigrations(migrations []Migration, applied map[int]time.Time) []Migration {
+	var pending []Migration
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+
+	return pending
+}
+
+// revertedMigrations return the last steps applied migrations, latest first.
+// An applied version unknown to the binary cannot be reverted and is returned as an error.
+func revertedMigrations(migrations []Migration, applied map[int]time.Time, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("the number of migrations to revert must be positive")
+	}
+
+	byVersion := map[int]Migration{}
+	for _, migration := range migrations {
+		byVersion[migration.Version] = migration
+	}
+
+	versions := make([]int, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+	var reverted []Migration
+	for _, version := range versions {
+		if len(reverted) == steps {
+			break
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			return nil, fmt.Errorf("the applied migration %d is unknown to this version of the API", version)
+		}
+		reverted = append(reverted, migration)
+	}
+
+	return reverted, nil
+}
@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestMigrations(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("want migrations, got %s", err)
+	}
+
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			t.Errorf("want version %d, got %d_%s", i+1, migration.Version, migration.Name)
+		}
+	}
+}
+
+func TestReadMigrations(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		want    []Migration
+		wantErr bool
+	}{
+		{
+			name: "read migrations ordered by version",
+			files: fstest.MapFS{
+				"migrations/0002_add_column.up.sql":     {Data: []byte("ALTER TABLE t ADD COLUMN c TEXT;")},
+				"migrations/0002_add_column.down.sql":   {Data: []byte("ALTER TABLE t DROP COLUMN c;")},
+				"migrations/0001_create_table.up.sql":   {Data: []byte("CREATE TABLE t (id INTEGER);")},
+				"migrations/0001_create_table.down.sql": {Data: []byte("DROP TABLE t;")},
+			},
+			want: []Migration{
+				{Version: 1, Name: "create_table", Up: "CREATE TABLE t (id INTEGER);", Down: "DROP TABLE t;"},
+				{Version: 2, Name: "add_column", Up: "ALTER TABLE t ADD COLUMN c TEXT;", Down: "ALTER TABLE t DROP COLUMN c;"},
+			},
+		},
+		{
+			name:    "return error for missing down file",
+			files:   fstest.MapFS{"migrations/0001_create_table.up.sql": {Data: []byte("CREATE TABLE t (id INTEGER);")}},
+			wantErr: true,
+		},
+		{
+			name:    "return error for invalid file name",
+			files:   fstest.MapFS{"migrations/create_table.sql": {Data: []byte("CREATE TABLE t (id INTEGER);")}},
+			wantErr: true,
+		},
+		{
+			name: "return error for version with two names",
+			files: fstest.MapFS{
+				"migrations/0001_create_table.up.sql":   {Data: []byte("CREATE TABLE t (id INTEGER);")},
+				"migrations/0001_create_other.down.sql": {Data: []byte("DROP TABLE t;")},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readMigrations(tt.files, "migrations")
+
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("want error %t, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(tt.want, got) {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPendingAndRevertedMigrations(t *testing.T) {
+	migrations := []Migration{{Version: 1, Name: "one"}, {Version: 2, Name: "two"}, {Version: 3, Name: "three"}}
+	applied := map[int]time.Time{1: time.Now(), 2: time.Now()}
+
+	if got := pendingMigrations(migrations, applied); !reflect.DeepEqual(migrations[2:], got) {
+		t.Errorf("want %v, got %v", migrations[2:], got)
+	}
+
+	t.Run("revert latest migrations first", func(t *testing.T) {
+		got, err := revertedMigrations(migrations, applied, 5)
+
+		want := []Migration{migrations[1], migrations[0]}
+		if err != nil || !reflect.DeepEqual(want, got) {
+			t.Errorf("want %v, got %v (%v)", want, got, err)
+		}
+	})
+
+	t.Run("return error for unknown applied migration", func(t *testing.T) {
+		if _, err := revertedMigrations(migrations, map[int]time.Time{4: time.Now()}, 1); err == nil {
+			t.Errorf("want error")
+		}
+	})
+
+	t.Run("return error for no step", func(t *testing.T) {
+		if _, err := revertedMigrations(migrations, applied, 0); err == nil {
+			t.Errorf("want error")
+		}
+	})
+}
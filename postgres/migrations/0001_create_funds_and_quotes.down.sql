@@ -0,0 +1,7 @@
+-- The funds and quotes tables may predate the migrations and hold the whole price history,
+-- they are never dropped by a migration and must be dropped by hand when really intended
+DO $$
+BEGIN
+    RAISE EXCEPTION 'the migration 1 cannot be reverted, drop the quotes and funds tables by hand if intended';
+END
+$$;
@@ -0,0 +1,15 @@
+-- Pension funds and their net asset value, one quote per fund and day.
+-- Databases created before the migrations already have these tables and keep them as they are.
+CREATE TABLE IF NOT EXISTS funds (
+    isin        VARCHAR(12) PRIMARY KEY,
+    name        TEXT        NOT NULL,
+    bank        TEXT        NOT NULL,
+    launch_date DATE        NOT NULL,
+    currency    CHAR(3)     NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS quotes (
+    fund_isin VARCHAR(12) NOT NULL REFERENCES funds (isin),
+    date      TIMESTAMP   NOT NULL,
+    price     NUMERIC     NOT NULL
+);
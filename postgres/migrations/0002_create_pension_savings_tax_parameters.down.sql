@@ -0,0 +1 @@
+DROP TABLE IF EXISTS pension_savings_tax_parameters;
@@ -0,0 +1,19 @@
+-- Pension savings tax parameters, one row per income year
+CREATE TABLE IF NOT EXISTS pension_savings_tax_parameters (
+    year                  INTEGER PRIMARY KEY,
+    basic_ceiling         NUMERIC(10, 2) NOT NULL,
+    basic_rate            NUMERIC(5, 4)  NOT NULL,
+    extended_ceiling      NUMERIC(10, 2) NOT NULL,
+    extended_rate         NUMERIC(5, 4)  NOT NULL,
+    anticipatory_tax_rate NUMERIC(5, 4)  NOT NULL,
+    fictitious_rate       NUMERIC(5, 4)  NOT NULL
+);
+
+INSERT INTO pension_savings_tax_parameters
+    (year, basic_ceiling, basic_rate, extended_ceiling, extended_rate, anticipatory_tax_rate, fictitious_rate)
+VALUES (2020, 990.00, 0.30, 1270.00, 0.25, 0.08, 0.0475),
+       (2021, 990.00, 0.30, 1270.00, 0.25, 0.08, 0.0475),
+       (2022, 1020.00, 0.30, 1310.00, 0.25, 0.08, 0.0475),
+       (2023, 1050.00, 0.30, 1350.00, 0.25, 0.08, 0.0475),
+       (2024, 1020.00, 0.30, 1310.00, 0.25, 0.08, 0.0475)
+ON CONFLICT (year) DO NOTHING;
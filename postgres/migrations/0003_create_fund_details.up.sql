@@ -0,0 +1,13 @@
+-- Versioned characteristics of the funds, the applicable version is the latest one valid from today or before
+CREATE TABLE IF NOT EXISTS fund_details (
+    fund_isin          VARCHAR(12)   NOT NULL REFERENCES funds (isin),
+    valid_from         DATE          NOT NULL,
+    entry_fee          NUMERIC(7, 6) CHECK (entry_fee BETWEEN 0 AND 1),
+    ongoing_charges    NUMERIC(7, 6) CHECK (ongoing_charges BETWEEN 0 AND 1),
+    risk_class         SMALLINT      CHECK (risk_class BETWEEN 1 AND 7),
+    equity_allocation  NUMERIC(5, 4) CHECK (equity_allocation BETWEEN 0 AND 1),
+    bond_allocation    NUMERIC(5, 4) CHECK (bond_allocation BETWEEN 0 AND 1),
+    management_company TEXT,
+    benchmark          TEXT,
+    PRIMARY KEY (fund_isin, valid_from)
+);
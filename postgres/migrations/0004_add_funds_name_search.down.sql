@@ -0,0 +1,3 @@
+-- The unaccent extension is kept, other objects of the database may use it
+DROP INDEX IF EXISTS funds_name_search_idx;
+DROP FUNCTION IF EXISTS immutable_unaccent(text);
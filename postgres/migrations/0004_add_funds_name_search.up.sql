@@ -0,0 +1,9 @@
+-- Accent-insensitive full-text search on fund names, which mix French and Dutch
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+CREATE OR REPLACE FUNCTION immutable_unaccent(text) RETURNS text AS
+$$
+SELECT public.unaccent('public.unaccent', $1)
+$$ LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT;
+
+CREATE INDEX IF NOT EXISTS funds_name_search_idx ON funds USING GIN (to_tsvector('simple', immutable_unaccent(name)));
@@ -0,0 +1,12 @@
+-- Every correction of a quote, previous_price is null when the correction created the quote
+CREATE TABLE IF NOT EXISTS quote_history (
+    id             BIGSERIAL PRIMARY KEY,
+    fund_isin      VARCHAR(12)   NOT NULL REFERENCES funds (isin),
+    day            DATE          NOT NULL,
+    previous_price NUMERIC,
+    price          NUMERIC       NOT NULL,
+    changed_by     TEXT          NOT NULL,
+    changed_at     TIMESTAMPTZ   NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS quote_history_fund_isin_day_idx ON quote_history (fund_isin, day);
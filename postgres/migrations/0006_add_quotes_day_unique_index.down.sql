@@ -0,0 +1,2 @@
+-- The duplicate quotes removed by the migration stay recorded in quote_history, changed by migration:quotes_day_unique
+DROP INDEX IF EXISTS quotes_fund_isin_day_idx;
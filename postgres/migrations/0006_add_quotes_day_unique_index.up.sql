@@ -0,0 +1,19 @@
+-- A single quote per fund and day. DATE(date) can only be indexed because quotes.date is a timestamp without time zone.
+-- Of the duplicates already stored, the quote with the latest time of the day is kept as the last price published
+-- that day, the others are recorded in quote_history as corrected to it before being removed.
+WITH ranked AS (
+    SELECT ctid, fund_isin, DATE(date) AS day, price,
+           first_value(price) OVER day_quotes AS kept_price,
+           row_number() OVER day_quotes AS rank
+    FROM quotes
+    WINDOW day_quotes AS (PARTITION BY fund_isin, DATE(date) ORDER BY date DESC, ctid DESC)
+), removed AS (
+    DELETE FROM quotes q USING ranked r
+    WHERE q.ctid = r.ctid AND r.rank > 1
+    RETURNING r.fund_isin, r.day, r.price, r.kept_price
+)
+INSERT INTO quote_history (fund_isin, day, previous_price, price, changed_by)
+SELECT fund_isin, day, price, kept_price, 'migration:quotes_day_unique'
+FROM removed;
+
+CREATE UNIQUE INDEX IF NOT EXISTS quotes_fund_isin_day_idx ON quotes (fund_isin, DATE(date));
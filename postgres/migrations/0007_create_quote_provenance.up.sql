@@ -0,0 +1,13 @@
+-- Provenance of the quotes written through the API, one row per fund and day like quotes
+CREATE TABLE IF NOT EXISTS quote_provenance (
+    fund_isin     VARCHAR(12) NOT NULL REFERENCES funds (isin),
+    day           DATE        NOT NULL,
+    source_system TEXT,
+    run_id        TEXT,
+    source_url    TEXT,
+    ingested_at   TIMESTAMPTZ NOT NULL,
+    ingested_by   TEXT        NOT NULL,
+    PRIMARY KEY (fund_isin, day)
+);
+
+CREATE INDEX IF NOT EXISTS quote_provenance_run_id_idx ON quote_provenance (run_id);
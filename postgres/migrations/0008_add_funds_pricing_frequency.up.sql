@@ -0,0 +1,3 @@
+-- Expected publication frequency of the net asset value of the funds: daily, weekly or monthly
+ALTER TABLE funds ADD COLUMN IF NOT EXISTS pricing_frequency VARCHAR(10) NOT NULL DEFAULT 'daily'
+    CHECK (pricing_frequency IN ('daily', 'weekly', 'monthly'));
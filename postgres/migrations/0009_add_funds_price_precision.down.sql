@@ -0,0 +1 @@
+ALTER TABLE funds DROP COLUMN IF EXISTS price_precision;
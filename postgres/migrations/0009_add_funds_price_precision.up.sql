@@ -0,0 +1,3 @@
+-- Number of decimals declared by the funds for their net asset value, used to write prices as exact decimal strings
+ALTER TABLE funds ADD COLUMN IF NOT EXISTS price_precision SMALLINT NOT NULL DEFAULT 4
+    CHECK (price_precision BETWEEN 0 AND 6);
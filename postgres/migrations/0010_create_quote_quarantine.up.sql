@@ -0,0 +1,21 @@
+-- Suspicious quotes waiting for the review of an admin, with the provenance they were sent with
+CREATE TABLE IF NOT EXISTS quote_quarantine (
+    id            BIGSERIAL PRIMARY KEY,
+    fund_isin     VARCHAR(12) NOT NULL REFERENCES funds (isin),
+    date          TIMESTAMP   NOT NULL,
+    price         NUMERIC     NOT NULL,
+    reasons       TEXT[]      NOT NULL,
+    status        VARCHAR(10) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'rejected')),
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    reviewed_by   TEXT,
+    reviewed_at   TIMESTAMPTZ,
+    source_system TEXT,
+    run_id        TEXT,
+    source_url    TEXT,
+    ingested_at   TIMESTAMPTZ,
+    ingested_by   TEXT
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS quote_quarantine_pending_idx ON quote_quarantine (fund_isin, DATE(date), price)
+    WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS quote_quarantine_status_idx ON quote_quarantine (status, created_at);
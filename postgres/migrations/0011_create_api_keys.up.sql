@@ -0,0 +1,13 @@
+-- API keys of the clients, only the SHA-256 hash of the key is stored and the public prefix identifies it.
+-- Several keys can be valid for the same client so a key can be rotated without downtime.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id           BIGSERIAL PRIMARY KEY,
+    name         TEXT        NOT NULL,
+    prefix       VARCHAR(16) NOT NULL UNIQUE,
+    hash         CHAR(64)    NOT NULL,
+    scopes       TEXT[]      NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at   TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ
+);
@@ -0,0 +1,2 @@
+-- Rate limit plan of the API keys, the limits of the plans are configured in the API
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS plan TEXT NOT NULL DEFAULT 'standard';
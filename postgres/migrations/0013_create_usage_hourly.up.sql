@@ -0,0 +1,15 @@
+-- Hourly usage of the API per key, route template, ISIN and status, key and isin are empty when not applicable.
+-- Latencies are stored in microseconds.
+CREATE TABLE IF NOT EXISTS usage_hourly (
+    hour          TIMESTAMPTZ NOT NULL,
+    key_prefix    VARCHAR(16) NOT NULL,
+    route         TEXT        NOT NULL,
+    isin          VARCHAR(12) NOT NULL,
+    status        SMALLINT    NOT NULL,
+    requests      BIGINT      NOT NULL,
+    total_latency BIGINT      NOT NULL,
+    max_latency   BIGINT      NOT NULL,
+    PRIMARY KEY (hour, key_prefix, route, isin, status)
+);
+
+CREATE INDEX IF NOT EXISTS usage_hourly_key_prefix_idx ON usage_hourly (key_prefix, hour);
@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/obawi/pensiondata-api"
+)
+
+// quarantineSelect select the quarantined quotes in the order scanned by scanQuarantinedQuote
+const quarantineSelect = "SELECT id, fund_isin, date, price, reasons, status, created_at, reviewed_by, reviewed_at, " +
+	"source_system, run_id, source_url, ingested_at, ingested_by FROM quote_quarantine"
+
+// QuarantineRepository is the struct used to implement the pensiondata.QuarantineRepository interface for Postgres
+type QuarantineRepository struct {
+	DB *sql.DB
+}
+
+// NewQuarantineRepository return a new QuarantineRepository for Postgres
+func NewQuarantineRepository(db *sql.DB) *QuarantineRepository {
+	return &QuarantineRepository{DB: db}
+}
+
+// FindByID return the quarantined quote for the given id
+func (r QuarantineRepository) FindByID(ctx context.Context, id int64) (pensiondata.QuarantinedQuote, error) {
+	row := r.DB.QueryRowContext(ctx, quarantineSelect+" WHERE id = $1;", id)
+
+	quarantinedQuote, err := scanQuarantinedQuote(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return pensiondata.QuarantinedQuote{}, pensiondata.ErrQuarantinedQuoteNotFound
+		}
+		return pensiondata.QuarantinedQuote{}, err
+	}
+
+	return quarantinedQuote, nil
+}
+
+// FindByStatus return the quarantined quotes with the given status, oldest first
+func (r QuarantineRepository) FindByStatus(ctx context.Context, status string) ([]pensiondata.QuarantinedQuote, error) {
+	rows, err := r.DB.QueryContext(ctx, quarantineSelect+" WHERE status = $1 ORDER BY created_at ASC, id ASC;", status)
+	if err != nil {
+		return []pensiondata.QuarantinedQuote{}, err
+	}
+	defer rows.Close()
+
+	var quarantinedQuotes []pensiondata.QuarantinedQuote
+	for rows.Next() {
+		quarantinedQuote, err := scanQuarantinedQuote(rows)
+		if err != nil {
+			return []pensiondata.QuarantinedQuote{}, err
+		}
+		quarantinedQuotes = append(quarantinedQuotes, quarantinedQuote)
+	}
+
+	if err = rows.Err(); err != nil {
+		return []pensiondata.QuarantinedQuote{}, err
+	}
+
+	return quarantinedQuotes, nil
+}
+
+// Create return the newly quarantined quote.
+// The same quote quarantined twice while pending is only stored once and the existing one is returned.
+func (r QuarantineRepository) Create(ctx context.Context, quarantinedQuote pensiondata.QuarantinedQuote) (pensiondata.QuarantinedQuote, error) {
+	quote := quarantinedQuote.Quote
+	provenance := quote.Provenance
+	if provenance == nil {
+		provenance = &pensiondata.Provenance{}
+	}
+
+	var ingestedAt sql.NullTime
+	if !provenance.IngestedAt.IsZero() {
+		ingestedAt = sql.NullTime{Time: provenance.IngestedAt, Valid: true}
+	}
+
+	if _, err := r.DB.ExecContext(ctx, "INSERT INTO quote_quarantine "+
+		"(fund_isin, date, price, reasons, source_system, run_id, source_url, ingested_at, ingested_by) "+
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) ON CONFLICT DO NOTHING;",
+		quarantinedQuote.Isin, quote.Date, quote.Price, pq.Array(quarantinedQuote.Reasons), nullString(provenance.SourceSystem),
+		nullString(provenance.RunID), nullString(provenance.SourceURL), ingestedAt, nullString(provenance.IngestedBy),
+	); err != nil {
+		return pensiondata.QuarantinedQuote{}, err
+	}
+
+	row := r.DB.QueryRowContext(ctx, quarantineSelect+" WHERE fund_isin = $1 AND DATE(date) = $2 AND price = $3 AND status = $4;",
+		quarantinedQuote.Isin, quote.Date.Format("2006-01-02"), quote.Price, pensiondata.QuarantineStatusPending)
+
+	return scanQuarantinedQuote(row)
+}
+
+// Review set the status of the pending quarantined quote for the given id and record its reviewer
+func (r QuarantineRepository) Review(ctx context.Context, id int64, status, reviewer string) (pensiondata.QuarantinedQuote, error) {
+	row := r.DB.QueryRowContext(ctx, "UPDATE quote_quarantine SET status = $1, reviewed_by = $2, reviewed_at = now() "+
+		"WHERE id = $3 AND status = $4 RETURNING id;", status, reviewer, id, pensiondata.QuarantineStatusPending)
+
+	if err := row.Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return pensiondata.QuarantinedQuote{}, pensiondata.ErrQuarantinedQuoteReviewed
+		}
+		return pensiondata.QuarantinedQuote{}, err
+	}
+
+	return r.FindByID(ctx, id)
+}
+
+// scanQuarantinedQuote return the quarantined quote scanned from a row selected with quarantineSelect
+func scanQuarantinedQuote(s scanner) (pensiondata.QuarantinedQuote, error) {
+	var quarantinedQuote pensiondata.QuarantinedQuote
+	var reviewedBy, sourceSystem, runID, sourceURL, ingestedBy sql.NullString
+	var reviewedAt, ingestedAt sql.NullTime
+	err := s.Scan(&quarantinedQuote.ID, &quarantinedQuote.Isin, &quarantinedQuote.Quote.Date, &quarantinedQuote.Quote.Price,
+		pq.Array(&quarantinedQuote.Reasons), &quarantinedQuote.Status, &quarantinedQuote.CreatedAt, &reviewedBy, &reviewedAt,
+		&sourceSystem, &runID, &sourceURL, &ingestedAt, &ingestedBy)
+	if err != nil {
+		return pensiondata.QuarantinedQuote{}, err
+	}
+
+	quarantinedQuote.ReviewedBy = reviewedBy.String
+	quarantinedQuote.ReviewedAt = reviewedAt.Time
+
+	if ingestedAt.Valid {
+		quarantinedQuote.Quote.Provenance = &pensiondata.Provenance{
+			SourceSystem: sourceSystem.String,
+			RunID:        runID.String,
+			SourceURL:    sourceURL.String,
+			IngestedAt:   ingestedAt.Time,
+			IngestedBy:   ingestedBy.String,
+		}
+	}
+
+	return quarantinedQuote, nil
+}
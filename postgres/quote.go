@@ -1,8 +1,14 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
 	"github.com/obawi/pensiondata-api"
+	"github.com/shopspring/decimal"
 )
 
 // QuoteRepository is the struct used to implement the pensiondata.QuoteRepository interface for Postgres
@@ -15,24 +21,52 @@ func NewQuoteRepository(db *sql.DB) *QuoteRepository {
 	return &QuoteRepository{DB: db}
 }
 
-// FindByISINAndDate return the quote for the given fund isin and date
-func (r QuoteRepository) FindByISINAndDate(isin, date string) (pensiondata.Quote, error) {
-	row := r.DB.QueryRow("SELECT date, price FROM quotes WHERE fund_isin = $1 AND DATE(date) = $2;", isin, date)
+// quoteWithProvenanceSelect select a quote along with its provenance, which is null for older quotes
+const quoteWithProvenanceSelect = "SELECT q.date, q.price, p.source_system, p.run_id, p.source_url, p.ingested_at, p.ingested_by " +
+	"FROM quotes q LEFT JOIN quote_provenance p ON p.fund_isin = q.fund_isin AND p.day = DATE(q.date) " +
+	"WHERE q.fund_isin = $1 AND DATE(q.date) = $2;"
+
+// rowQuerier is implemented by both *sql.DB and *sql.Tx
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// FindByISINAndDate return the quote for the given fund isin and date along with its provenance
+func (r QuoteRepository) FindByISINAndDate(ctx context.Context, isin, date string) (pensiondata.Quote, error) {
+	return findByISINAndDate(ctx, r.DB, isin, date)
+}
+
+// findByISINAndDate return the quote for the given fund isin and date along with its provenance,
+// read with the given database or transaction
+func findByISINAndDate(ctx context.Context, q rowQuerier, isin, date string) (pensiondata.Quote, error) {
+	row := q.QueryRowContext(ctx, quoteWithProvenanceSelect, isin, date)
 
 	var quote pensiondata.Quote
-	if err := row.Scan(&quote.Date, &quote.Price); err != nil {
+	var sourceSystem, runID, sourceURL, ingestedBy sql.NullString
+	var ingestedAt sql.NullTime
+	if err := row.Scan(&quote.Date, &quote.Price, &sourceSystem, &runID, &sourceURL, &ingestedAt, &ingestedBy); err != nil {
 		if err == sql.ErrNoRows {
 			return pensiondata.Quote{}, pensiondata.ErrQuoteNotFound
 		}
 		return pensiondata.Quote{}, err
 	}
 
+	if ingestedAt.Valid {
+		quote.Provenance = &pensiondata.Provenance{
+			SourceSystem: sourceSystem.String,
+			RunID:        runID.String,
+			SourceURL:    sourceURL.String,
+			IngestedAt:   ingestedAt.Time,
+			IngestedBy:   ingestedBy.String,
+		}
+	}
+
 	return quote, nil
 }
 
 // FindByDateDesc return the quote for the given isin order by date desc
-func (r QuoteRepository) FindByDateDesc(isin string) (pensiondata.Quote, error) {
-	row := r.DB.QueryRow("SELECT date, price FROM quotes WHERE fund_isin = $1 ORDER BY date DESC LIMIT 1;", isin)
+func (r QuoteRepository) FindByDateDesc(ctx context.Context, isin string) (pensiondata.Quote, error) {
+	row := r.DB.QueryRowContext(ctx, "SELECT date, price FROM quotes WHERE fund_isin = $1 ORDER BY date DESC LIMIT 1;", isin)
 
 	var quote pensiondata.Quote
 	if err := row.Scan(&quote.Date, &quote.Price); err != nil {
@@ -46,8 +80,8 @@ func (r QuoteRepository) FindByDateDesc(isin string) (pensiondata.Quote, error)
 }
 
 // FindAll return all quotes for the given isin
-func (r QuoteRepository) FindAll(isin string) ([]pensiondata.Quote, error) {
-	rows, err := r.DB.Query("SELECT date, price FROM quotes WHERE fund_isin = $1 ORDER BY date DESC;", isin)
+func (r QuoteRepository) FindAll(ctx context.Context, isin string) ([]pensiondata.Quote, error) {
+	rows, err := r.DB.QueryContext(ctx, "SELECT date, price FROM quotes WHERE fund_isin = $1 ORDER BY date DESC;", isin)
 	if err != nil {
 		return []pensiondata.Quote{}, err
 	}
@@ -69,21 +103,318 @@ func (r QuoteRepository) FindAll(isin string) ([]pensiondata.Quote, error) {
 	return quotes, nil
 }
 
-// Create return the newly created quote
-func (r QuoteRepository) Create(isin string, quote pensiondata.Quote) (pensiondata.Quote, error) {
-	statement, err := r.DB.Prepare("INSERT INTO quotes (price, date, fund_isin) VALUES ($1, $2, $3);")
+// FindByCriteria return the quotes for the given isin matching the given criteria
+func (r QuoteRepository) FindByCriteria(ctx context.Context, isin string, criteria pensiondata.QuoteCriteria) ([]pensiondata.Quote, error) {
+	var quotes []pensiondata.Quote
+	err := r.StreamByCriteria(ctx, isin, criteria, func(quote pensiondata.Quote) error {
+		quotes = append(quotes, quote)
+		return nil
+	})
+	if err != nil {
+		return []pensiondata.Quote{}, err
+	}
+
+	return quotes, nil
+}
+
+// StreamByCriteria call fn with each quote for the given isin matching the given criteria as it is read,
+// without holding the quotes in memory. It stops at the first error returned by fn and return it,
+// the query being canceled when ctx is done.
+func (r QuoteRepository) StreamByCriteria(ctx context.Context, isin string, criteria pensiondata.QuoteCriteria, fn func(pensiondata.Quote) error) error {
+	query := "SELECT date, price FROM quotes WHERE fund_isin = $1"
+	args := []interface{}{isin}
+
+	if !criteria.From.IsZero() {
+		args = append(args, criteria.From.Format("2006-01-02"))
+		query += fmt.Sprintf(" AND DATE(date) >= $%d", len(args))
+	}
+
+	if !criteria.To.IsZero() {
+		args = append(args, criteria.To.Format("2006-01-02"))
+		query += fmt.Sprintf(" AND DATE(date) <= $%d", len(args))
+	}
+
+	order := "DESC"
+	if criteria.Order == pensiondata.OrderAsc {
+		order = "ASC"
+	}
+
+	if !criteria.After.IsZero() {
+		args = append(args, criteria.After.Format("2006-01-02"))
+		if order == "ASC" {
+			query += fmt.Sprintf(" AND DATE(date) > $%d", len(args))
+		} else {
+			query += fmt.Sprintf(" AND DATE(date) < $%d", len(args))
+		}
+	}
+
+	query += " ORDER BY date " + order
+
+	if criteria.Limit > 0 {
+		args = append(args, criteria.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := r.DB.QueryContext(ctx, query+";", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var quote pensiondata.Quote
+		if err := rows.Scan(&quote.Date, &quote.Price); err != nil {
+			return err
+		}
+		if err := fn(quote); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// Create return the newly created quote along with its provenance, both written in a single transaction.
+// When a quote already exists for the fund on the same day, it is returned if it has the same price
+// and pensiondata.ErrQuoteConflict is returned otherwise.
+func (r QuoteRepository) Create(ctx context.Context, isin string, quote pensiondata.Quote) (pensiondata.Quote, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return pensiondata.Quote{}, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "INSERT INTO quotes (price, date, fund_isin) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING;", quote.Price, quote.Date, isin)
 	if err != nil {
 		return pensiondata.Quote{}, err
 	}
 
-	if _, err := statement.Exec(quote.Price, quote.Date, isin); err != nil {
+	inserted, err := result.RowsAffected()
+	if err != nil {
 		return pensiondata.Quote{}, err
 	}
 
-	createdQuote, err := r.FindByISINAndDate(isin, quote.Date.Format("2006-01-02"))
+	if inserted == 1 && quote.Provenance != nil {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO quote_provenance (fund_isin, day, source_system, run_id, source_url, ingested_at, ingested_by) "+
+				"VALUES ($1, $2, $3, $4, $5, $6, $7);",
+			provenanceValues(isin, quote)...,
+		); err != nil {
+			return pensiondata.Quote{}, err
+		}
+	}
+
+	createdQuote, err := findByISINAndDate(ctx, tx, isin, quote.Date.Format("2006-01-02"))
 	if err != nil {
 		return pensiondata.Quote{}, err
 	}
 
+	if !createdQuote.Price.Equal(quote.Price) {
+		return pensiondata.Quote{}, pensiondata.ErrQuoteConflict
+	}
+
+	if err := tx.Commit(); err != nil {
+		return pensiondata.Quote{}, err
+	}
+
 	return createdQuote, nil
 }
+
+// provenanceValues return the values of the quote_provenance columns for the given quote
+func provenanceValues(isin string, quote pensiondata.Quote) []interface{} {
+	return []interface{}{
+		isin,
+		quote.Date.Format("2006-01-02"),
+		nullString(quote.Provenance.SourceSystem),
+		nullString(quote.Provenance.RunID),
+		nullString(quote.Provenance.SourceURL),
+		quote.Provenance.IngestedAt,
+		quote.Provenance.IngestedBy,
+	}
+}
+
+// Correct create or update the quote for the given isin on the day of the quote in a single transaction.
+// Every change is recorded in quote_history with the previous price, the author and the time of the change,
+// and replaces the provenance of the quote with the one of the correction.
+func (r QuoteRepository) Correct(ctx context.Context, isin string, quote pensiondata.Quote, author string) (pensiondata.Quote, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return pensiondata.Quote{}, err
+	}
+	defer tx.Rollback()
+
+	day := quote.Date.Format("2006-01-02")
+
+	result, err := tx.ExecContext(ctx, "INSERT INTO quotes (price, date, fund_isin) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING;", quote.Price, quote.Date, isin)
+	if err != nil {
+		return pensiondata.Quote{}, err
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return pensiondata.Quote{}, err
+	}
+
+	var previousPrice decimal.NullDecimal
+	if inserted == 0 {
+		row := tx.QueryRowContext(ctx, "SELECT price FROM quotes WHERE fund_isin = $1 AND DATE(date) = $2 FOR UPDATE;", isin, day)
+		if err := row.Scan(&previousPrice); err != nil {
+			return pensiondata.Quote{}, err
+		}
+
+		if previousPrice.Decimal.Equal(quote.Price) {
+			return findByISINAndDate(ctx, tx, isin, day)
+		}
+
+		if _, err := tx.ExecContext(ctx, "UPDATE quotes SET price = $1 WHERE fund_isin = $2 AND DATE(date) = $3;", quote.Price, isin, day); err != nil {
+			return pensiondata.Quote{}, err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO quote_history (fund_isin, day, previous_price, price, changed_by) VALUES ($1, $2, $3, $4, $5);",
+		isin, day, previousPrice, quote.Price, author,
+	); err != nil {
+		return pensiondata.Quote{}, err
+	}
+
+	if quote.Provenance != nil {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO quote_provenance (fund_isin, day, source_system, run_id, source_url, ingested_at, ingested_by) "+
+				"VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (fund_isin, day) DO UPDATE SET "+
+				"source_system = EXCLUDED.source_system, run_id = EXCLUDED.run_id, source_url = EXCLUDED.source_url, "+
+				"ingested_at = EXCLUDED.ingested_at, ingested_by = EXCLUDED.ingested_by;",
+			provenanceValues(isin, quote)...,
+		); err != nil {
+			return pensiondata.Quote{}, err
+		}
+	}
+
+	correctedQuote, err := findByISINAndDate(ctx, tx, isin, day)
+	if err != nil {
+		return pensiondata.Quote{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return pensiondata.Quote{}, err
+	}
+
+	return correctedQuote, nil
+}
+
+// CreateBatch create the given quotes in a single transaction and return the status of each of them, in order.
+// The quotes are copied in a temporary table then inserted skipping the conflicts on the unique index on the fund
+// and day, so the quotes already existing for a fund on the same day, created concurrently by another request
+// or appearing twice in the batch are duplicates. The quotes returned by the insert are the created ones,
+// the provenance is recorded for them only.
+func (r QuoteRepository) CreateBatch(ctx context.Context, quotes []pensiondata.FundQuote) ([]string, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "CREATE TEMPORARY TABLE quote_batch (position INTEGER NOT NULL, "+
+		"fund_isin VARCHAR(12) NOT NULL, date TIMESTAMP NOT NULL, price NUMERIC NOT NULL, source_system TEXT, run_id TEXT, "+
+		"source_url TEXT, ingested_at TIMESTAMPTZ, ingested_by TEXT) ON COMMIT DROP;"); err != nil {
+		return nil, err
+	}
+
+	batchRows := make([][]interface{}, len(quotes))
+	for i, fundQuote := range quotes {
+		row := []interface{}{i, fundQuote.Isin, fundQuote.Quote.Date, fundQuote.Quote.Price}
+		if fundQuote.Quote.Provenance != nil {
+			row = append(row, provenanceValues(fundQuote.Isin, fundQuote.Quote)[2:]...)
+		} else {
+			row = append(row, nil, nil, nil, nil, nil)
+		}
+		batchRows[i] = row
+	}
+
+	if err := copyIn(ctx, tx, batchRows, "quote_batch",
+		"position", "fund_isin", "date", "price", "source_system", "run_id", "source_url", "ingested_at", "ingested_by"); err != nil {
+		return nil, err
+	}
+
+	// The first quote of the batch for a fund and day is the one inserted, the next ones are duplicates
+	rows, err := tx.QueryContext(ctx, `
+		WITH firsts AS (
+			SELECT DISTINCT ON (fund_isin, DATE(date)) *
+			FROM quote_batch
+			ORDER BY fund_isin, DATE(date), position
+		), created AS (
+			INSERT INTO quotes (price, date, fund_isin)
+			SELECT price, date, fund_isin FROM firsts
+			ON CONFLICT DO NOTHING
+			RETURNING fund_isin, DATE(date) AS day
+		), provenance AS (
+			INSERT INTO quote_provenance (fund_isin, day, source_system, run_id, source_url, ingested_at, ingested_by)
+			SELECT f.fund_isin, c.day, f.source_system, f.run_id, f.source_url, f.ingested_at, f.ingested_by
+			FROM firsts f
+			JOIN created c ON c.fund_isin = f.fund_isin AND c.day = DATE(f.date)
+			WHERE f.ingested_at IS NOT NULL
+		)
+		SELECT fund_isin, day FROM created;`)
+	if err != nil {
+		return nil, err
+	}
+
+	created := make(map[string]bool)
+	for rows.Next() {
+		var isin string
+		var day time.Time
+		if err := rows.Scan(&isin, &day); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		created[isin+day.Format("2006-01-02")] = true
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]string, len(quotes))
+	for i, fundQuote := range quotes {
+		key := fundQuote.Isin + fundQuote.Quote.Date.Format("2006-01-02")
+		if !created[key] {
+			statuses[i] = pensiondata.QuoteStatusDuplicate
+			continue
+		}
+		delete(created, key)
+		statuses[i] = pensiondata.QuoteStatusCreated
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return statuses, nil
+}
+
+// copyIn insert the given rows in the table with a COPY statement within the given transaction
+func copyIn(ctx context.Context, tx *sql.Tx, rows [][]interface{}, table string, columns ...string) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	statement, err := tx.PrepareContext(ctx, pq.CopyIn(table, columns...))
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		if _, err := statement.ExecContext(ctx, row...); err != nil {
+			statement.Close()
+			return err
+		}
+	}
+
+	if _, err := statement.ExecContext(ctx); err != nil {
+		statement.Close()
+		return err
+	}
+
+	return statement.Close()
+}
@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/obawi/pensiondata-api"
+)
+
+// TaxParametersRepository is the struct used to implement the pensiondata.TaxParametersRepository interface for Postgres
+type TaxParametersRepository struct {
+	DB *sql.DB
+}
+
+// NewTaxParametersRepository return a new TaxParametersRepository for Postgres
+func NewTaxParametersRepository(db *sql.DB) *TaxParametersRepository {
+	return &TaxParametersRepository{DB: db}
+}
+
+// FindAll return the tax parameters of every income year ordered by year asc
+func (r TaxParametersRepository) FindAll(ctx context.Context) ([]pensiondata.TaxParameters, error) {
+	rows, err := r.DB.QueryContext(ctx, "SELECT year, basic_ceiling, basic_rate, extended_ceiling, extended_rate, anticipatory_tax_rate, "+
+		"fictitious_rate FROM pension_savings_tax_parameters ORDER BY year ASC;")
+	if err != nil {
+		return []pensiondata.TaxParameters{}, err
+	}
+	defer rows.Close()
+
+	var parameters []pensiondata.TaxParameters
+	for rows.Next() {
+		var p pensiondata.TaxParameters
+		if err := rows.Scan(&p.Year, &p.BasicCeiling, &p.BasicRate, &p.ExtendedCeiling, &p.ExtendedRate,
+			&p.AnticipatoryTaxRate, &p.FictitiousRate); err != nil {
+			return []pensiondata.TaxParameters{}, err
+		}
+		parameters = append(parameters, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return []pensiondata.TaxParameters{}, err
+	}
+
+	return parameters, nil
+}
@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/obawi/pensiondata-api"
+)
+
+// UsageRepository is the struct used to implement the pensiondata.UsageRepository interface for Postgres
+type UsageRepository struct {
+	DB *sql.DB
+}
+
+// NewUsageRepository return a new UsageRepository for Postgres
+func NewUsageRepository(db *sql.DB) *UsageRepository {
+	return &UsageRepository{DB: db}
+}
+
+// dataExceptionClass and integrityViolationClass are the classes of the Postgres error codes raised for a row
+// the table rejects, such as a value too long for its column
+const (
+	dataExceptionClass      = "22"
+	integrityViolationClass = "23"
+)
+
+// AddBuckets add the buckets to the stored ones in a single statement, the buckets must be unique.
+// A bucket rejected by the table fails the statement, the buckets are then added one by one so that only
+// the rejected ones are lost. Other errors, such as an unavailable database, are returned for the batch.
+func (r UsageRepository) AddBuckets(ctx context.Context, buckets []pensiondata.UsageBucket) error {
+	err := r.addBuckets(ctx, buckets)
+	if !isRejectedRow(err) || len(buckets) == 1 {
+		return err
+	}
+
+	for _, bucket := range buckets {
+		err := r.addBuckets(ctx, []pensiondata.UsageBucket{bucket})
+		if isRejectedRow(err) {
+			log.Printf("Usage bucket of %s on %s rejected: %s", bucket.Route, bucket.Hour.Format(time.RFC3339), err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isRejectedRow return true when the error is raised by Postgres for a row the table rejects
+func isRejectedRow(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && (pqErr.Code.Class() == dataExceptionClass || pqErr.Code.Class() == integrityViolationClass)
+}
+
+// addBuckets add the buckets to the stored ones in a single statement, the buckets must be unique
+func (r UsageRepository) addBuckets(ctx context.Context, buckets []pensiondata.UsageBucket) error {
+	hours := make([]time.Time, len(buckets))
+	keys := make([]string, len(buckets))
+	routes := make([]string, len(buckets))
+	isins := make([]string, len(buckets))
+	statuses := make([]int64, len(buckets))
+	requests := make([]int64, len(buckets))
+	totalLatencies := make([]int64, len(buckets))
+	maxLatencies := make([]int64, len(buckets))
+	for i, bucket := range buckets {
+		hours[i] = bucket.Hour
+		keys[i] = bucket.Key
+		routes[i] = bucket.Route
+		isins[i] = bucket.Isin
+		statuses[i] = int64(bucket.Status)
+		requests[i] = bucket.Requests
+		totalLatencies[i] = bucket.TotalLatency.Microseconds()
+		maxLatencies[i] = bucket.MaxLatency.Microseconds()
+	}
+
+	_, err := r.DB.ExecContext(ctx, "INSERT INTO usage_hourly "+
+		"(hour, key_prefix, route, isin, status, requests, total_latency, max_latency) "+
+		"SELECT * FROM unnest($1::timestamptz[], $2::text[], $3::text[], $4::text[], $5::smallint[], $6::bigint[], $7::bigint[], $8::bigint[]) "+
+		"ON CONFLICT (hour, key_prefix, route, isin, status) DO UPDATE SET "+
+		"requests = usage_hourly.requests + EXCLUDED.requests, "+
+		"total_latency = usage_hourly.total_latency + EXCLUDED.total_latency, "+
+		"max_latency = GREATEST(usage_hourly.max_latency, EXCLUDED.max_latency);",
+		pq.Array(timeStrings(hours)), pq.Array(keys), pq.Array(routes), pq.Array(isins), pq.Array(statuses),
+		pq.Array(requests), pq.Array(totalLatencies), pq.Array(maxLatencies))
+
+	return err
+}
+
+// FindByCriteria return the hourly usage matching the criteria
+func (r UsageRepository) FindByCriteria(ctx context.Context, criteria pensiondata.UsageCriteria) ([]pensiondata.UsageBucket, error) {
+	query := "SELECT hour, key_prefix, route, isin, status, requests, total_latency, max_latency FROM usage_hourly " +
+		"WHERE hour >= $1 AND ($2 = '' OR key_prefix = $2) AND ($3::timestamptz IS NULL OR hour < $3) " +
+		"ORDER BY hour ASC, key_prefix ASC, route ASC, isin ASC, status ASC;"
+
+	rows, err := r.DB.QueryContext(ctx, query, criteria.From, criteria.Key, nullTime(criteria.To))
+	if err != nil {
+		return []pensiondata.UsageBucket{}, err
+	}
+	defer rows.Close()
+
+	var buckets []pensiondata.UsageBucket
+	for rows.Next() {
+		var bucket pensiondata.UsageBucket
+		var totalLatency, maxLatency int64
+		err := rows.Scan(&bucket.Hour, &bucket.Key, &bucket.Route, &bucket.Isin, &bucket.Status, &bucket.Requests,
+			&totalLatency, &maxLatency)
+		if err != nil {
+			return []pensiondata.UsageBucket{}, err
+		}
+		bucket.TotalLatency = time.Duration(totalLatency) * time.Microsecond
+		bucket.MaxLatency = time.Duration(maxLatency) * time.Microsecond
+		buckets = append(buckets, bucket)
+	}
+
+	if err = rows.Err(); err != nil {
+		return []pensiondata.UsageBucket{}, err
+	}
+
+	return buckets, nil
+}
+
+// timeStrings return the times formatted in RFC 3339 with nanoseconds, as pq.Array does not support time.Time
+func timeStrings(times []time.Time) []string {
+	formatted := make([]string, len(times))
+	for i, t := range times {
+		formatted[i] = t.Format(time.RFC3339Nano)
+	}
+
+	return formatted
+}
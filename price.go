@@ -0,0 +1,164 @@
+package pensiondata
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/shopspring/decimal"
+)
+
+// Price is an exact price along with the number of decimals declared by its fund.
+// It is written as a JSON number by default and, when Exact is set for the string price format,
+// as the exact decimal string of String, which never drops decimals of the value beyond the declared precision.
+type Price struct {
+	Value     decimal.Decimal
+	Precision int32
+	Exact     bool
+}
+
+// NewPrice return the Price of the value with the given precision, the number of decimals declared by the fund
+func NewPrice(value decimal.Decimal, precision int32) Price {
+	return Price{Value: value, Precision: precision}
+}
+
+// String return the exact decimal representation of the price, with at least Precision decimals
+func (p Price) String() string {
+	return stringFixed(p.Value, p.Precision)
+}
+
+// Float64 return the nearest float64 of the price, which is exact within the bounds of MaxPriceDigits
+func (p Price) Float64() float64 {
+	value, _ := p.Value.Float64()
+	return value
+}
+
+// Equal return true when both prices have the same value, whatever their precision
+func (p Price) Equal(other Price) bool {
+	return p.Value.Equal(other.Value)
+}
+
+// MarshalJSON write the price as a JSON number, or as an exact decimal string when Exact is set
+func (p Price) MarshalJSON() ([]byte, error) {
+	if p.Exact {
+		return json.Marshal(p.String())
+	}
+
+	return json.Marshal(p.Float64())
+}
+
+// UnmarshalJSON read a price written as a JSON number or a decimal string, its precision is the number of decimals read
+func (p *Price) UnmarshalJSON(data []byte) error {
+	value, err := decimal.NewFromString(string(bytes.Trim(data, `"`)))
+	if err != nil {
+		return err
+	}
+
+	precision := -value.Exponent()
+	if precision < 0 {
+		precision = 0
+	}
+
+	*p = Price{Value: value, Precision: precision}
+	return nil
+}
+
+// Amount is an exact amount in euros or number of fund units computed by the API, along with its number of decimals.
+// Like Price, it is written as a JSON number by default and as an exact decimal string when Exact is set.
+type Amount struct {
+	Value     decimal.Decimal
+	Precision int32
+	Exact     bool
+}
+
+// NewAmount return the Amount of the value with the given number of decimals
+func NewAmount(value decimal.Decimal, precision int32) Amount {
+	return Amount{Value: value, Precision: precision}
+}
+
+// String return the exact decimal representation of the amount, with at least Precision decimals
+func (a Amount) String() string {
+	return stringFixed(a.Value, a.Precision)
+}
+
+// Float64 return the nearest float64 of the amount
+func (a Amount) Float64() float64 {
+	value, _ := a.Value.Float64()
+	return value
+}
+
+// MarshalJSON write the amount as a JSON number, or as an exact decimal string when Exact is set
+func (a Amount) MarshalJSON() ([]byte, error) {
+	if a.Exact {
+		return json.Marshal(a.String())
+	}
+
+	return json.Marshal(a.Float64())
+}
+
+// Number is a value computed by the API without declared precision, such as a return or a ratio.
+// It is written as a JSON number by default and as an exact decimal string when Exact is set.
+type Number struct {
+	Value decimal.Decimal
+	Exact bool
+}
+
+// NewNumber return the Number of the value
+func NewNumber(value decimal.Decimal) Number {
+	return Number{Value: value}
+}
+
+// NewNumberFromFloat return the Number of the shortest decimal representation of the value
+func NewNumberFromFloat(value float64) Number {
+	return Number{Value: decimal.NewFromFloat(value)}
+}
+
+// String return the exact decimal representation of the number
+func (n Number) String() string {
+	return n.Value.String()
+}
+
+// Float64 return the nearest float64 of the number
+func (n Number) Float64() float64 {
+	value, _ := n.Value.Float64()
+	return value
+}
+
+// MarshalJSON write the number as a JSON number, or as an exact decimal string when Exact is set
+func (n Number) MarshalJSON() ([]byte, error) {
+	if n.Exact {
+		return json.Marshal(n.String())
+	}
+
+	return json.Marshal(n.Float64())
+}
+
+// exactNumber return a copy of the optional number written as an exact decimal string, nil when nil
+func exactNumber(number *Number) *Number {
+	if number == nil {
+		return nil
+	}
+
+	exact := *number
+	exact.Exact = true
+	return &exact
+}
+
+// stringFixed return the exact decimal representation of the value with at least the given number of decimals
+func stringFixed(value decimal.Decimal, precision int32) string {
+	decimals := precision
+	if exponent := value.Exponent(); -exponent > decimals {
+		decimals = -exponent
+	}
+
+	return value.StringFixed(decimals)
+}
+
+// newOptionalNumber return the Number of the optional value, nil when nil
+func newOptionalNumber(value *float64) *Number {
+	if value == nil {
+		return nil
+	}
+
+	number := NewNumberFromFloat(*value)
+	return &number
+}
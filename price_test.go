@@ -0,0 +1,99 @@
+package pensiondata
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestPriceString(t *testing.T) {
+	tests := []struct {
+		name  string
+		price Price
+		want  string
+	}{
+		{name: "pad to the precision of the fund", price: NewPrice(decimal.RequireFromString("7.99"), 4), want: "7.9900"},
+		{name: "keep decimals beyond the precision", price: NewPrice(decimal.RequireFromString("7.123456"), 2), want: "7.123456"},
+		{name: "keep decimals without precision", price: NewPrice(decimal.RequireFromString("7.10"), 0), want: "7.10"},
+		{name: "write integer without precision", price: NewPrice(decimal.RequireFromString("12"), 0), want: "12"},
+		{name: "stay exact beyond float64", price: NewPrice(decimal.RequireFromString("123456789.123456"), 6), want: "123456789.123456"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.price.String(); tt.want != got {
+				t.Errorf("want %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPriceJSON(t *testing.T) {
+	t.Run("write price as number", func(t *testing.T) {
+		got, err := json.Marshal(NewPrice(decimal.RequireFromString("7.99"), 4))
+
+		if err != nil || string(got) != "7.99" {
+			t.Errorf("want 7.99, got %s (%v)", got, err)
+		}
+	})
+
+	t.Run("write exact price as string", func(t *testing.T) {
+		price := NewPrice(decimal.RequireFromString("7.99"), 4)
+		price.Exact = true
+
+		got, err := json.Marshal(price)
+
+		if err != nil || string(got) != `"7.9900"` {
+			t.Errorf("want \"7.9900\", got %s (%v)", got, err)
+		}
+	})
+
+	for _, data := range []string{`7.9900`, `"7.9900"`} {
+		t.Run("read price from "+data, func(t *testing.T) {
+			var got Price
+			err := json.Unmarshal([]byte(data), &got)
+
+			if err != nil || got.String() != "7.9900" || !got.Equal(NewPrice(decimal.RequireFromString("7.99"), 2)) {
+				t.Errorf("want 7.9900, got %s (%v)", got, err)
+			}
+		})
+	}
+
+	t.Run("return error for invalid price", func(t *testing.T) {
+		var got Price
+		if err := json.Unmarshal([]byte(`"seven"`), &got); err == nil {
+			t.Errorf("want error")
+		}
+	})
+}
+
+func TestAmountJSON(t *testing.T) {
+	amount := NewAmount(decimal.RequireFromString("4.25"), 8)
+
+	got, err := json.Marshal(amount)
+	if err != nil || string(got) != "4.25" {
+		t.Errorf("want 4.25, got %s (%v)", got, err)
+	}
+
+	amount.Exact = true
+	got, err = json.Marshal(amount)
+	if err != nil || string(got) != `"4.25000000"` {
+		t.Errorf("want \"4.25000000\", got %s (%v)", got, err)
+	}
+}
+
+func TestNumberJSON(t *testing.T) {
+	number := NewNumberFromFloat(0.1)
+
+	got, err := json.Marshal(number)
+	if err != nil || string(got) != "0.1" {
+		t.Errorf("want 0.1, got %s (%v)", got, err)
+	}
+
+	number.Exact = true
+	got, err = json.Marshal(number)
+	if err != nil || string(got) != `"0.1"` {
+		t.Errorf("want \"0.1\", got %s (%v)", got, err)
+	}
+}
@@ -0,0 +1,446 @@
+package pensiondata
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Reasons for which a quote is put in quarantine
+const (
+	QuarantineReasonPriceChange  = "price_change"
+	QuarantineReasonBeforeLaunch = "before_launch"
+	QuarantineReasonFutureDate   = "future_date"
+	QuarantineReasonWeekend      = "weekend"
+)
+
+// Statuses of a quarantined quote
+const (
+	QuarantineStatusPending  = "pending"
+	QuarantineStatusAccepted = "accepted"
+	QuarantineStatusRejected = "rejected"
+)
+
+// QuoteStatusQuarantined is the status of a quote of a batch put in quarantine
+const QuoteStatusQuarantined = "quarantined"
+
+// QuarantinedQuote is a suspicious quote waiting for, or having received, the review of an admin
+type QuarantinedQuote struct {
+	ID         int64
+	Isin       string
+	Quote      Quote
+	Reasons    []string
+	Status     string
+	CreatedAt  time.Time
+	ReviewedBy string
+	ReviewedAt time.Time
+}
+
+// QuarantineRepository handle the data access operations on QuarantinedQuote
+type QuarantineRepository interface {
+	FindByID(context.Context, int64) (QuarantinedQuote, error)
+	FindByStatus(context.Context, string) ([]QuarantinedQuote, error)
+	Create(context.Context, QuarantinedQuote) (QuarantinedQuote, error)
+	Review(context.Context, int64, string, string) (QuarantinedQuote, error)
+}
+
+// QuarantineService handle the use cases for the review of quarantined quotes
+type QuarantineService interface {
+	GetQuarantinedQuotes(context.Context, string) ([]PublicQuarantinedQuote, error)
+	AcceptQuote(context.Context, int64, string) (PublicQuarantinedQuote, error)
+	RejectQuote(context.Context, int64, string) (PublicQuarantinedQuote, error)
+}
+
+// QuoteValidator check the quotes before they are created.
+// Quotes with a price that is not positive are rejected, quotes moving more than maxPriceChange
+// from the previous quote, dated before the launch of the fund, in the future or on a weekend
+// for daily priced funds are suspicious. A zero maxPriceChange disables the price change check.
+type QuoteValidator struct {
+	maxPriceChange decimal.Decimal
+	now            func() time.Time
+}
+
+// NewQuoteValidator return a QuoteValidator flagging price changes above the given fraction (0.2 is 20%)
+func NewQuoteValidator(maxPriceChange decimal.Decimal) QuoteValidator {
+	return QuoteValidator{maxPriceChange: maxPriceChange, now: time.Now}
+}
+
+// Validate return the reasons the quote of the fund is suspicious, previous is the latest quote before it if any.
+// ErrInvalidQuote is returned when the quote must be rejected.
+func (v QuoteValidator) Validate(fund Fund, quote Quote, previous *Quote) ([]string, error) {
+	if !quote.Price.IsPositive() {
+		return nil, fmt.Errorf("%w: the price must be positive", ErrInvalidQuote)
+	}
+
+	var reasons []string
+	day := truncateToDay(quote.Date)
+
+	if previous != nil && v.maxPriceChange.IsPositive() && previous.Price.IsPositive() {
+		change := quote.Price.Div(previous.Price).Sub(decimal.NewFromInt(1)).Abs()
+		if change.GreaterThan(v.maxPriceChange) {
+			reasons = append(reasons, QuarantineReasonPriceChange)
+		}
+	}
+
+	if !fund.LaunchDate.IsZero() && day.Before(truncateToDay(fund.LaunchDate)) {
+		reasons = append(reasons, QuarantineReasonBeforeLaunch)
+	}
+
+	if day.After(truncateToDay(v.now())) {
+		reasons = append(reasons, QuarantineReasonFutureDate)
+	}
+
+	weekday := quote.Date.Weekday()
+	if fund.PricingFrequency == PricingFrequencyDaily && (weekday == time.Saturday || weekday == time.Sunday) {
+		reasons = append(reasons, QuarantineReasonWeekend)
+	}
+
+	return reasons, nil
+}
+
+// QuarantineError is returned, wrapping ErrQuoteQuarantined, when a suspicious quote is put in quarantine.
+// Quote is the quarantined quote as stored, waiting for the review of an admin.
+type QuarantineError struct {
+	Quote QuarantinedQuote
+}
+
+// Error return ErrQuoteQuarantined followed by the reasons of the quarantine
+func (e QuarantineError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrQuoteQuarantined, strings.Join(e.Quote.Reasons, ", "))
+}
+
+// Unwrap return ErrQuoteQuarantined
+func (e QuarantineError) Unwrap() error {
+	return ErrQuoteQuarantined
+}
+
+// PublicQuote return the representation of the quarantined quote to be returned by the API
+func (e QuarantineError) PublicQuote() PublicQuarantinedQuote {
+	return newPublicQuarantinedQuote(e.Quote)
+}
+
+// ValidatedQuoteRepository is a QuoteRepository validating the quotes before they are created or corrected.
+// Suspicious quotes are put in quarantine instead of being created, suspicious corrections are rejected.
+type ValidatedQuoteRepository struct {
+	QuoteRepository
+	fundRepo       FundRepository
+	quarantineRepo QuarantineRepository
+	validator      QuoteValidator
+}
+
+// NewValidatedQuoteRepository return a new ValidatedQuoteRepository creating the valid quotes with quoteRepo
+func NewValidatedQuoteRepository(quoteRepo QuoteRepository, fundRepo FundRepository, quarantineRepo QuarantineRepository, validator QuoteValidator) *ValidatedQuoteRepository {
+	return &ValidatedQuoteRepository{
+		QuoteRepository: quoteRepo,
+		fundRepo:        fundRepo,
+		quarantineRepo:  quarantineRepo,
+		validator:       validator,
+	}
+}
+
+// Create validate the quote before creating it.
+// A QuarantineError holding the quarantined quote is returned when the quote is put in quarantine.
+// A quote already existing on the same day is not validated again so that creating it stays idempotent.
+func (r ValidatedQuoteRepository) Create(ctx context.Context, isin string, quote Quote) (Quote, error) {
+	if _, err := r.QuoteRepository.FindByISINAndDate(ctx, isin, quote.Date.Format("2006-01-02")); err == nil {
+		return r.QuoteRepository.Create(ctx, isin, quote)
+	} else if err != ErrQuoteNotFound {
+		return Quote{}, err
+	}
+
+	fund, err := r.fundRepo.FindByISIN(ctx, isin)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	previous, err := r.previousQuote(ctx, isin, quote.Date)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	reasons, err := r.validator.Validate(fund, quote, previous)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	if len(reasons) > 0 {
+		quarantinedQuote, err := r.quarantineRepo.Create(ctx, QuarantinedQuote{Isin: isin, Quote: quote, Reasons: reasons})
+		if err != nil {
+			return Quote{}, err
+		}
+		return Quote{}, QuarantineError{Quote: quarantinedQuote}
+	}
+
+	return r.QuoteRepository.Create(ctx, isin, quote)
+}
+
+// Correct validate the corrected quote before creating or updating it.
+// A suspicious correction is rejected with ErrInvalidQuote, wrapped with the reasons, instead of being put in quarantine
+// since accepting a quarantined quote creates it and cannot update a stored one.
+// A correction keeping the stored price is not validated again so that correcting stays idempotent.
+func (r ValidatedQuoteRepository) Correct(ctx context.Context, isin string, quote Quote, author string) (Quote, error) {
+	stored, err := r.QuoteRepository.FindByISINAndDate(ctx, isin, quote.Date.Format("2006-01-02"))
+	if err == nil && stored.Price.Equal(quote.Price) {
+		return r.QuoteRepository.Correct(ctx, isin, quote, author)
+	} else if err != nil && err != ErrQuoteNotFound {
+		return Quote{}, err
+	}
+
+	fund, err := r.fundRepo.FindByISIN(ctx, isin)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	previous, err := r.previousQuote(ctx, isin, quote.Date)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	reasons, err := r.validator.Validate(fund, quote, previous)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	if len(reasons) > 0 {
+		return Quote{}, fmt.Errorf("%w: the correction is suspicious (%s)", ErrInvalidQuote, strings.Join(reasons, ", "))
+	}
+
+	return r.QuoteRepository.Correct(ctx, isin, quote, author)
+}
+
+// CreateBatch validate the quotes before creating them, suspicious quotes get the quarantined status.
+// Quotes of a fund are validated in date order, each one against the latest quote before it
+// that is either already stored or valid in the batch.
+func (r ValidatedQuoteRepository) CreateBatch(ctx context.Context, quotes []FundQuote) ([]string, error) {
+	statuses := make([]string, len(quotes))
+
+	byIsin := make(map[string][]int)
+	var isins []string
+	for i, fundQuote := range quotes {
+		if _, ok := byIsin[fundQuote.Isin]; !ok {
+			isins = append(isins, fundQuote.Isin)
+		}
+		byIsin[fundQuote.Isin] = append(byIsin[fundQuote.Isin], i)
+	}
+
+	var validIndexes []int
+	for _, isin := range isins {
+		indexes := byIsin[isin]
+		sort.SliceStable(indexes, func(i, j int) bool {
+			return quotes[indexes[i]].Quote.Date.Before(quotes[indexes[j]].Quote.Date)
+		})
+
+		fund, err := r.fundRepo.FindByISIN(ctx, isin)
+		if err != nil {
+			return nil, err
+		}
+
+		first := truncateToDay(quotes[indexes[0]].Quote.Date)
+		last := truncateToDay(quotes[indexes[len(indexes)-1]].Quote.Date)
+		stored, err := r.QuoteRepository.FindByCriteria(ctx, isin, QuoteCriteria{From: first, To: last, Order: OrderAsc})
+		if err != nil {
+			return nil, err
+		}
+
+		storedByDay := make(map[string]Quote)
+		for _, quote := range stored {
+			storedByDay[quote.Date.Format("2006-01-02")] = quote
+		}
+
+		previous, err := r.previousQuote(ctx, isin, quotes[indexes[0]].Quote.Date)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, i := range indexes {
+			quote := quotes[i].Quote
+
+			// Stored quotes become the reference for the next ones and are reported as duplicates by the repository
+			if storedQuote, ok := storedByDay[quote.Date.Format("2006-01-02")]; ok {
+				previous = &storedQuote
+				validIndexes = append(validIndexes, i)
+				continue
+			}
+
+			reasons, err := r.validator.Validate(fund, quote, previous)
+			if err != nil {
+				statuses[i] = QuoteStatusInvalid
+				continue
+			}
+
+			if len(reasons) > 0 {
+				if _, err := r.quarantineRepo.Create(ctx, QuarantinedQuote{Isin: isin, Quote: quote, Reasons: reasons}); err != nil {
+					return nil, err
+				}
+				statuses[i] = QuoteStatusQuarantined
+				continue
+			}
+
+			previous = &quotes[i].Quote
+			validIndexes = append(validIndexes, i)
+		}
+	}
+
+	if len(validIndexes) > 0 {
+		sort.Ints(validIndexes)
+		orderedQuotes := make([]FundQuote, len(validIndexes))
+		for j, i := range validIndexes {
+			orderedQuotes[j] = quotes[i]
+		}
+
+		createdStatuses, err := r.QuoteRepository.CreateBatch(ctx, orderedQuotes)
+		if err != nil {
+			return nil, err
+		}
+
+		for j, i := range validIndexes {
+			statuses[i] = createdStatuses[j]
+		}
+	}
+
+	return statuses, nil
+}
+
+// previousQuote return the latest stored quote of the fund before the day of the given date, or nil
+func (r ValidatedQuoteRepository) previousQuote(ctx context.Context, isin string, date time.Time) (*Quote, error) {
+	criteria := QuoteCriteria{To: truncateToDay(date).AddDate(0, 0, -1), Limit: 1, Order: OrderDesc}
+	quotes, err := r.QuoteRepository.FindByCriteria(ctx, isin, criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(quotes) == 0 {
+		return nil, nil
+	}
+
+	return &quotes[0], nil
+}
+
+// QuarantineServiceImpl is the implementation of QuarantineService
+type QuarantineServiceImpl struct {
+	quarantineRepo QuarantineRepository
+	quoteRepo      QuoteRepository
+}
+
+// NewQuarantineService return a new, fully functional, implementation of QuarantineService.
+// quoteRepo must create the accepted quotes without validating them again.
+func NewQuarantineService(quarantineRepo QuarantineRepository, quoteRepo QuoteRepository) *QuarantineServiceImpl {
+	return &QuarantineServiceImpl{quarantineRepo: quarantineRepo, quoteRepo: quoteRepo}
+}
+
+// GetQuarantinedQuotes return the quarantined quotes with the given status, pending when empty
+func (s QuarantineServiceImpl) GetQuarantinedQuotes(ctx context.Context, status string) ([]PublicQuarantinedQuote, error) {
+	if status == "" {
+		status = QuarantineStatusPending
+	}
+
+	if status != QuarantineStatusPending && status != QuarantineStatusAccepted && status != QuarantineStatusRejected {
+		return []PublicQuarantinedQuote{}, ErrInvalidQuarantineStatus
+	}
+
+	quarantinedQuotes, err := s.quarantineRepo.FindByStatus(ctx, status)
+	if err != nil {
+		return []PublicQuarantinedQuote{}, err
+	}
+
+	publicQuarantinedQuotes := []PublicQuarantinedQuote{}
+	for _, quarantinedQuote := range quarantinedQuotes {
+		publicQuarantinedQuotes = append(publicQuarantinedQuotes, newPublicQuarantinedQuote(quarantinedQuote))
+	}
+
+	return publicQuarantinedQuotes, nil
+}
+
+// AcceptQuote create the quarantined quote and mark it as accepted by the given reviewer
+func (s QuarantineServiceImpl) AcceptQuote(ctx context.Context, id int64, reviewer string) (PublicQuarantinedQuote, error) {
+	quarantinedQuote, err := s.pendingQuote(ctx, id)
+	if err != nil {
+		return PublicQuarantinedQuote{}, err
+	}
+
+	if _, err := s.quoteRepo.Create(ctx, quarantinedQuote.Isin, quarantinedQuote.Quote); err != nil {
+		return PublicQuarantinedQuote{}, err
+	}
+
+	reviewedQuote, err := s.quarantineRepo.Review(ctx, id, QuarantineStatusAccepted, reviewer)
+	if err != nil {
+		return PublicQuarantinedQuote{}, err
+	}
+
+	return newPublicQuarantinedQuote(reviewedQuote), nil
+}
+
+// RejectQuote mark the quarantined quote as rejected by the given reviewer, it is never created
+func (s QuarantineServiceImpl) RejectQuote(ctx context.Context, id int64, reviewer string) (PublicQuarantinedQuote, error) {
+	if _, err := s.pendingQuote(ctx, id); err != nil {
+		return PublicQuarantinedQuote{}, err
+	}
+
+	reviewedQuote, err := s.quarantineRepo.Review(ctx, id, QuarantineStatusRejected, reviewer)
+	if err != nil {
+		return PublicQuarantinedQuote{}, err
+	}
+
+	return newPublicQuarantinedQuote(reviewedQuote), nil
+}
+
+// pendingQuote return the quarantined quote for the given id, or ErrQuarantinedQuoteReviewed when already reviewed
+func (s QuarantineServiceImpl) pendingQuote(ctx context.Context, id int64) (QuarantinedQuote, error) {
+	quarantinedQuote, err := s.quarantineRepo.FindByID(ctx, id)
+	if err != nil {
+		return QuarantinedQuote{}, err
+	}
+
+	if quarantinedQuote.Status != QuarantineStatusPending {
+		return QuarantinedQuote{}, ErrQuarantinedQuoteReviewed
+	}
+
+	return quarantinedQuote, nil
+}
+
+// PublicQuarantinedQuote is QuarantinedQuote's representation to be returned by the API
+type PublicQuarantinedQuote struct {
+	ID         int64             `json:"id"`
+	Isin       string            `json:"isin"`
+	Date       string            `json:"date"`
+	Price      Price             `json:"price"`
+	Reasons    []string          `json:"reasons"`
+	Status     string            `json:"status"`
+	CreatedAt  string            `json:"created_at"`
+	ReviewedBy string            `json:"reviewed_by,omitempty"`
+	ReviewedAt string            `json:"reviewed_at,omitempty"`
+	Provenance *PublicProvenance `json:"provenance"`
+}
+
+// ExactDecimals return the quarantined quote with its price written as an exact decimal string
+func (q PublicQuarantinedQuote) ExactDecimals() PublicQuarantinedQuote {
+	q.Price.Exact = true
+	return q
+}
+
+// newPublicQuarantinedQuote return a PublicQuarantinedQuote based on a QuarantinedQuote.
+// The price is written with the decimals it was sent with, as it is still to be reviewed.
+func newPublicQuarantinedQuote(quarantinedQuote QuarantinedQuote) PublicQuarantinedQuote {
+	publicQuote := newPublicQuoteWithProvenance(quarantinedQuote.Quote, 0)
+	publicQuarantinedQuote := PublicQuarantinedQuote{
+		ID:         quarantinedQuote.ID,
+		Isin:       quarantinedQuote.Isin,
+		Date:       publicQuote.Date,
+		Price:      publicQuote.Price,
+		Reasons:    quarantinedQuote.Reasons,
+		Status:     quarantinedQuote.Status,
+		CreatedAt:  quarantinedQuote.CreatedAt.Format(time.RFC3339),
+		ReviewedBy: quarantinedQuote.ReviewedBy,
+		Provenance: publicQuote.Provenance,
+	}
+
+	if !quarantinedQuote.ReviewedAt.IsZero() {
+		publicQuarantinedQuote.ReviewedAt = quarantinedQuote.ReviewedAt.Format(time.RFC3339)
+	}
+
+	return publicQuarantinedQuote
+}
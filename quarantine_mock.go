@@ -0,0 +1,53 @@
+package pensiondata
+
+import "context"
+
+// QuarantineRepositoryMock used for tests
+type QuarantineRepositoryMock struct {
+	FindByIDFn     func(context.Context, int64) (QuarantinedQuote, error)
+	FindByStatusFn func(context.Context, string) ([]QuarantinedQuote, error)
+	CreateFn       func(context.Context, QuarantinedQuote) (QuarantinedQuote, error)
+	ReviewFn       func(context.Context, int64, string, string) (QuarantinedQuote, error)
+}
+
+// QuarantineServiceMock used for tests
+type QuarantineServiceMock struct {
+	GetQuarantinedQuotesFn func(context.Context, string) ([]PublicQuarantinedQuote, error)
+	AcceptQuoteFn          func(context.Context, int64, string) (PublicQuarantinedQuote, error)
+	RejectQuoteFn          func(context.Context, int64, string) (PublicQuarantinedQuote, error)
+}
+
+// FindByID mock
+func (r QuarantineRepositoryMock) FindByID(ctx context.Context, id int64) (QuarantinedQuote, error) {
+	return r.FindByIDFn(ctx, id)
+}
+
+// FindByStatus mock
+func (r QuarantineRepositoryMock) FindByStatus(ctx context.Context, status string) ([]QuarantinedQuote, error) {
+	return r.FindByStatusFn(ctx, status)
+}
+
+// Create mock
+func (r QuarantineRepositoryMock) Create(ctx context.Context, quarantinedQuote QuarantinedQuote) (QuarantinedQuote, error) {
+	return r.CreateFn(ctx, quarantinedQuote)
+}
+
+// Review mock
+func (r QuarantineRepositoryMock) Review(ctx context.Context, id int64, status, reviewer string) (QuarantinedQuote, error) {
+	return r.ReviewFn(ctx, id, status, reviewer)
+}
+
+// GetQuarantinedQuotes mock
+func (s QuarantineServiceMock) GetQuarantinedQuotes(ctx context.Context, status string) ([]PublicQuarantinedQuote, error) {
+	return s.GetQuarantinedQuotesFn(ctx, status)
+}
+
+// AcceptQuote mock
+func (s QuarantineServiceMock) AcceptQuote(ctx context.Context, id int64, reviewer string) (PublicQuarantinedQuote, error) {
+	return s.AcceptQuoteFn(ctx, id, reviewer)
+}
+
+// RejectQuote mock
+func (s QuarantineServiceMock) RejectQuote(ctx context.Context, id int64, reviewer string) (PublicQuarantinedQuote, error) {
+	return s.RejectQuoteFn(ctx, id, reviewer)
+}
@@ -0,0 +1,362 @@
+package pensiondata
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func testQuoteValidator() QuoteValidator {
+	validator := NewQuoteValidator(decimal.NewFromFloat(0.2))
+	validator.now = func() time.Time {
+		return time.Date(2020, time.July, 10, 12, 0, 0, 0, time.UTC)
+	}
+
+	return validator
+}
+
+func testQuote(date string, price float64) Quote {
+	day, _ := time.Parse("2006-01-02", date)
+	return Quote{Date: day, Price: decimal.NewFromFloat(price)}
+}
+
+func TestQuoteValidator(t *testing.T) {
+	fund := Fund{
+		Isin:             "BE123",
+		LaunchDate:       time.Date(2020, time.January, 6, 0, 0, 0, 0, time.UTC),
+		PricingFrequency: PricingFrequencyDaily,
+	}
+	previous := testQuote("2020-07-08", 100)
+
+	tests := []struct {
+		name     string
+		fund     Fund
+		quote    Quote
+		previous *Quote
+		want     []string
+	}{
+		{name: "accept a valid quote", fund: fund, quote: testQuote("2020-07-09", 110), previous: &previous},
+		{name: "accept the first quote", fund: fund, quote: testQuote("2020-07-09", 5000)},
+		{name: "flag a price change above the threshold", fund: fund, quote: testQuote("2020-07-09", 10000), previous: &previous, want: []string{QuarantineReasonPriceChange}},
+		{name: "flag a price drop above the threshold", fund: fund, quote: testQuote("2020-07-09", 1), previous: &previous, want: []string{QuarantineReasonPriceChange}},
+		{name: "flag a date before launch", fund: fund, quote: testQuote("2020-01-03", 100), want: []string{QuarantineReasonBeforeLaunch}},
+		{name: "flag a date in the future", fund: fund, quote: testQuote("2020-07-13", 100), previous: &previous, want: []string{QuarantineReasonFutureDate}},
+		{name: "flag a weekend date for daily priced funds", fund: fund, quote: testQuote("2020-07-04", 100), want: []string{QuarantineReasonWeekend}},
+		{name: "accept a weekend date for weekly priced funds", fund: Fund{PricingFrequency: PricingFrequencyWeekly}, quote: testQuote("2020-07-04", 100)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testQuoteValidator().Validate(tt.fund, tt.quote, tt.previous)
+
+			if err != nil {
+				t.Fatalf("want no error, got %s", err)
+			}
+			if !reflect.DeepEqual(tt.want, got) {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("reject a price that is not positive", func(t *testing.T) {
+		_, err := testQuoteValidator().Validate(fund, testQuote("2020-07-09", 0), &previous)
+
+		if !errors.Is(err, ErrInvalidQuote) {
+			t.Errorf("want %s, got %v", ErrInvalidQuote, err)
+		}
+	})
+}
+
+func TestValidatedQuoteRepositoryCreate(t *testing.T) {
+	fundRepo := FundRepositoryMock{}
+	fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+		return Fund{Isin: isin, PricingFrequency: PricingFrequencyDaily}, nil
+	}
+
+	newQuoteRepo := func(created *bool) QuoteRepositoryMock {
+		quoteRepo := QuoteRepositoryMock{}
+		quoteRepo.FindByISINAndDateFn = func(ctx context.Context, isin, date string) (Quote, error) {
+			return Quote{}, ErrQuoteNotFound
+		}
+		quoteRepo.FindByCriteriaFn = func(ctx context.Context, isin string, criteria QuoteCriteria) ([]Quote, error) {
+			return []Quote{testQuote("2020-07-08", 100)}, nil
+		}
+		quoteRepo.CreateFn = func(ctx context.Context, isin string, quote Quote) (Quote, error) {
+			*created = true
+			return quote, nil
+		}
+		return quoteRepo
+	}
+
+	t.Run("create a valid quote", func(t *testing.T) {
+		var created bool
+		r := NewValidatedQuoteRepository(newQuoteRepo(&created), fundRepo, QuarantineRepositoryMock{}, testQuoteValidator())
+		_, err := r.Create(context.Background(), "BE123", testQuote("2020-07-09", 101))
+
+		if err != nil || !created {
+			t.Errorf("want quote created, got %v", err)
+		}
+	})
+
+	t.Run("quarantine a suspicious quote", func(t *testing.T) {
+		var created bool
+		var quarantined QuarantinedQuote
+		quarantineRepo := QuarantineRepositoryMock{}
+		quarantineRepo.CreateFn = func(ctx context.Context, quarantinedQuote QuarantinedQuote) (QuarantinedQuote, error) {
+			quarantined = quarantinedQuote
+			return quarantinedQuote, nil
+		}
+
+		r := NewValidatedQuoteRepository(newQuoteRepo(&created), fundRepo, quarantineRepo, testQuoteValidator())
+		_, err := r.Create(context.Background(), "BE123", testQuote("2020-07-09", 10100))
+
+		var quarantineErr QuarantineError
+		if !errors.Is(err, ErrQuoteQuarantined) || !errors.As(err, &quarantineErr) {
+			t.Fatalf("want %s, got %v", ErrQuoteQuarantined, err)
+		}
+		if quarantineErr.Quote.Isin != "BE123" {
+			t.Errorf("want quarantined quote of BE123, got %v", quarantineErr.Quote)
+		}
+		if created {
+			t.Errorf("want quote not created")
+		}
+		if quarantined.Isin != "BE123" || !reflect.DeepEqual([]string{QuarantineReasonPriceChange}, quarantined.Reasons) {
+			t.Errorf("want quote of BE123 quarantined for price change, got %v", quarantined)
+		}
+	})
+
+	t.Run("create an existing quote without validating it", func(t *testing.T) {
+		var created bool
+		quoteRepo := newQuoteRepo(&created)
+		quoteRepo.FindByISINAndDateFn = func(ctx context.Context, isin, date string) (Quote, error) {
+			return testQuote("2020-07-09", 10100), nil
+		}
+
+		r := NewValidatedQuoteRepository(quoteRepo, fundRepo, QuarantineRepositoryMock{}, testQuoteValidator())
+		_, err := r.Create(context.Background(), "BE123", testQuote("2020-07-09", 10100))
+
+		if err != nil || !created {
+			t.Errorf("want quote created, got %v", err)
+		}
+	})
+}
+
+func TestValidatedQuoteRepositoryCorrect(t *testing.T) {
+	fundRepo := FundRepositoryMock{}
+	fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+		return Fund{Isin: isin, PricingFrequency: PricingFrequencyDaily}, nil
+	}
+
+	newQuoteRepo := func(stored Quote, corrected *bool) QuoteRepositoryMock {
+		quoteRepo := QuoteRepositoryMock{}
+		quoteRepo.FindByISINAndDateFn = func(ctx context.Context, isin, date string) (Quote, error) {
+			if stored.Date.IsZero() {
+				return Quote{}, ErrQuoteNotFound
+			}
+			return stored, nil
+		}
+		quoteRepo.FindByCriteriaFn = func(ctx context.Context, isin string, criteria QuoteCriteria) ([]Quote, error) {
+			return []Quote{testQuote("2020-07-08", 100)}, nil
+		}
+		quoteRepo.CorrectFn = func(ctx context.Context, isin string, quote Quote, author string) (Quote, error) {
+			*corrected = true
+			return quote, nil
+		}
+		return quoteRepo
+	}
+
+	tests := []struct {
+		name          string
+		stored        Quote
+		quote         Quote
+		wantErr       error
+		wantCorrected bool
+	}{
+		{name: "correct a stored quote", stored: testQuote("2020-07-09", 10100), quote: testQuote("2020-07-09", 101), wantCorrected: true},
+		{name: "create a missing quote", quote: testQuote("2020-07-09", 101), wantCorrected: true},
+		{name: "reject a suspicious correction", stored: testQuote("2020-07-09", 101), quote: testQuote("2020-07-09", 10100), wantErr: ErrInvalidQuote},
+		{name: "reject an invalid correction", quote: testQuote("2020-07-09", 0), wantErr: ErrInvalidQuote},
+		{name: "keep the stored price without validating it", stored: testQuote("2020-07-09", 10100), quote: testQuote("2020-07-09", 10100), wantCorrected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var corrected bool
+			r := NewValidatedQuoteRepository(newQuoteRepo(tt.stored, &corrected), fundRepo, QuarantineRepositoryMock{}, testQuoteValidator())
+			_, err := r.Correct(context.Background(), "BE123", tt.quote, "scraper")
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("want %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantCorrected != corrected {
+				t.Errorf("want quote corrected %t, got %t", tt.wantCorrected, corrected)
+			}
+		})
+	}
+}
+
+func TestValidatedQuoteRepositoryCreateBatch(t *testing.T) {
+	t.Run("return the status of each quote", func(t *testing.T) {
+		fundRepo := FundRepositoryMock{}
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return Fund{Isin: isin, PricingFrequency: PricingFrequencyDaily}, nil
+		}
+
+		quoteRepo := QuoteRepositoryMock{}
+		quoteRepo.FindByCriteriaFn = func(ctx context.Context, isin string, criteria QuoteCriteria) ([]Quote, error) {
+			if criteria.Limit == 1 {
+				return []Quote{testQuote("2020-07-03", 100)}, nil
+			}
+			return []Quote{testQuote("2020-07-08", 102)}, nil
+		}
+
+		var created []FundQuote
+		quoteRepo.CreateBatchFn = func(ctx context.Context, quotes []FundQuote) ([]string, error) {
+			created = quotes
+			statuses := make([]string, len(quotes))
+			for i, quote := range quotes {
+				statuses[i] = QuoteStatusCreated
+				if quote.Quote.Date.Day() == 8 {
+					statuses[i] = QuoteStatusDuplicate
+				}
+			}
+			return statuses, nil
+		}
+
+		var quarantined []QuarantinedQuote
+		quarantineRepo := QuarantineRepositoryMock{}
+		quarantineRepo.CreateFn = func(ctx context.Context, quarantinedQuote QuarantinedQuote) (QuarantinedQuote, error) {
+			quarantined = append(quarantined, quarantinedQuote)
+			return quarantinedQuote, nil
+		}
+
+		quotes := []FundQuote{
+			{Isin: "BE123", Quote: testQuote("2020-07-09", 1020)},
+			{Isin: "BE123", Quote: testQuote("2020-07-06", 101)},
+			{Isin: "BE123", Quote: testQuote("2020-07-08", 102)},
+			{Isin: "BE123", Quote: testQuote("2020-07-10", 103)},
+			{Isin: "BE123", Quote: testQuote("2020-07-11", 103)},
+			{Isin: "BE123", Quote: testQuote("2020-07-07", 0)},
+		}
+
+		r := NewValidatedQuoteRepository(quoteRepo, fundRepo, quarantineRepo, testQuoteValidator())
+		got, err := r.CreateBatch(context.Background(), quotes)
+
+		if err != nil {
+			t.Fatalf("want no error, got %s", err)
+		}
+
+		want := []string{QuoteStatusQuarantined, QuoteStatusCreated, QuoteStatusDuplicate, QuoteStatusCreated, QuoteStatusQuarantined, QuoteStatusInvalid}
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+		if len(created) != 3 || created[0].Quote.Date.Day() != 6 {
+			t.Errorf("want 3 quotes sent to the repository in the batch order, got %v", created)
+		}
+		if len(quarantined) != 2 {
+			t.Errorf("want 2 quotes quarantined, got %v", quarantined)
+		}
+	})
+}
+
+func TestGetQuarantinedQuotes(t *testing.T) {
+	t.Run("return pending quotes by default", func(t *testing.T) {
+		var gotStatus string
+		quarantineRepo := QuarantineRepositoryMock{}
+		quarantineRepo.FindByStatusFn = func(ctx context.Context, status string) ([]QuarantinedQuote, error) {
+			gotStatus = status
+			return []QuarantinedQuote{{ID: 1, Isin: "BE123", Quote: testQuote("2020-07-09", 1020), Reasons: []string{QuarantineReasonPriceChange}, Status: status}}, nil
+		}
+
+		s := NewQuarantineService(quarantineRepo, QuoteRepositoryMock{})
+		got, err := s.GetQuarantinedQuotes(context.Background(), "")
+
+		if err != nil || len(got) != 1 || got[0].Date != "2020-07-09" || got[0].Price.Float64() != 1020 {
+			t.Errorf("want 1 quarantined quote, got %v (%v)", got, err)
+		}
+		if gotStatus != QuarantineStatusPending {
+			t.Errorf("want %s, got %s", QuarantineStatusPending, gotStatus)
+		}
+	})
+
+	t.Run("return error for invalid status", func(t *testing.T) {
+		s := NewQuarantineService(QuarantineRepositoryMock{}, QuoteRepositoryMock{})
+		_, err := s.GetQuarantinedQuotes(context.Background(), "deleted")
+
+		if err != ErrInvalidQuarantineStatus {
+			t.Errorf("want %s, got %v", ErrInvalidQuarantineStatus, err)
+		}
+	})
+}
+
+func TestReviewQuarantinedQuote(t *testing.T) {
+	newQuarantineRepo := func(status string, gotStatus *string) QuarantineRepositoryMock {
+		quarantineRepo := QuarantineRepositoryMock{}
+		quarantineRepo.FindByIDFn = func(ctx context.Context, id int64) (QuarantinedQuote, error) {
+			return QuarantinedQuote{ID: id, Isin: "BE123", Quote: testQuote("2020-07-09", 1020), Status: status}, nil
+		}
+		quarantineRepo.ReviewFn = func(ctx context.Context, id int64, status, reviewer string) (QuarantinedQuote, error) {
+			*gotStatus = status
+			return QuarantinedQuote{ID: id, Status: status, ReviewedBy: reviewer, ReviewedAt: time.Now()}, nil
+		}
+		return quarantineRepo
+	}
+
+	t.Run("accept and create the quote", func(t *testing.T) {
+		var gotStatus, gotIsin string
+		quoteRepo := QuoteRepositoryMock{}
+		quoteRepo.CreateFn = func(ctx context.Context, isin string, quote Quote) (Quote, error) {
+			gotIsin = isin
+			return quote, nil
+		}
+
+		s := NewQuarantineService(newQuarantineRepo(QuarantineStatusPending, &gotStatus), quoteRepo)
+		got, err := s.AcceptQuote(context.Background(), 1, "admin")
+
+		if err != nil || got.Status != QuarantineStatusAccepted || got.ReviewedBy != "admin" {
+			t.Errorf("want quote accepted by admin, got %v (%v)", got, err)
+		}
+		if gotIsin != "BE123" || gotStatus != QuarantineStatusAccepted {
+			t.Errorf("want quote of BE123 created and accepted, got %s and %s", gotIsin, gotStatus)
+		}
+	})
+
+	t.Run("reject the quote without creating it", func(t *testing.T) {
+		var gotStatus string
+		s := NewQuarantineService(newQuarantineRepo(QuarantineStatusPending, &gotStatus), QuoteRepositoryMock{})
+		got, err := s.RejectQuote(context.Background(), 1, "admin")
+
+		if err != nil || got.Status != QuarantineStatusRejected {
+			t.Errorf("want quote rejected, got %v (%v)", got, err)
+		}
+	})
+
+	t.Run("return error for quote already reviewed", func(t *testing.T) {
+		var gotStatus string
+		s := NewQuarantineService(newQuarantineRepo(QuarantineStatusRejected, &gotStatus), QuoteRepositoryMock{})
+		_, err := s.AcceptQuote(context.Background(), 1, "admin")
+
+		if err != ErrQuarantinedQuoteReviewed {
+			t.Errorf("want %s, got %v", ErrQuarantinedQuoteReviewed, err)
+		}
+	})
+
+	t.Run("keep the quote pending when it cannot be created", func(t *testing.T) {
+		var gotStatus string
+		quoteRepo := QuoteRepositoryMock{}
+		quoteRepo.CreateFn = func(ctx context.Context, isin string, quote Quote) (Quote, error) {
+			return Quote{}, ErrQuoteConflict
+		}
+
+		s := NewQuarantineService(newQuarantineRepo(QuarantineStatusPending, &gotStatus), quoteRepo)
+		_, err := s.AcceptQuote(context.Background(), 1, "admin")
+
+		if err != ErrQuoteConflict || gotStatus != "" {
+			t.Errorf("want %s and no review, got %v and %s", ErrQuoteConflict, err, gotStatus)
+		}
+	})
+}
@@ -1,31 +1,96 @@
 package pensiondata
 
 import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/shopspring/decimal"
 )
 
-// Quote is Quote's representation in the database
+// Quote is Quote's representation in the database.
+// Provenance is nil for the quotes written before their provenance was recorded.
 type Quote struct {
-	Date  time.Time
-	Price decimal.Decimal
+	Date       time.Time
+	Price      decimal.Decimal
+	Provenance *Provenance
+}
+
+// ProvenanceSourceCorrection is the source system of the quotes created or replaced by a correction
+const ProvenanceSourceCorrection = "correction"
+
+// Provenance describes where a quote comes from and who wrote it
+type Provenance struct {
+	SourceSystem string
+	RunID        string
+	SourceURL    string
+	IngestedAt   time.Time
+	IngestedBy   string
+}
+
+// Sort orders
+const (
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+// MaxQuoteLimit is the maximum number of quotes that can be requested in a single page
+const MaxQuoteLimit = 5000
+
+// MaxQuoteBatchSize is the maximum number of quotes that can be created in a single batch
+const MaxQuoteBatchSize = 10000
+
+// Statuses of the quotes of a batch
+const (
+	QuoteStatusCreated   = "created"
+	QuoteStatusDuplicate = "duplicate"
+	QuoteStatusInvalid   = "invalid"
+)
+
+// FundQuote is a quote of the fund for the given isin
+type FundQuote struct {
+	Isin  string
+	Quote Quote
+}
+
+// QuoteCriteria are the filters and pagination used to list quotes.
+// From and To are inclusive days, After is the exclusive day to start from in the given Order
+// (the cursor of the previous page). Zero values mean no bound and no limit.
+type QuoteCriteria struct {
+	From  time.Time
+	To    time.Time
+	After time.Time
+	Limit int
+	Order string
 }
 
 // QuoteRepository handle the data access operations on Quote
 type QuoteRepository interface {
-	FindByISINAndDate(string, string) (Quote, error)
-	FindByDateDesc(string) (Quote, error)
-	FindAll(string) ([]Quote, error)
-	Create(string, Quote) (Quote, error)
+	FindByISINAndDate(context.Context, string, string) (Quote, error)
+	FindByDateDesc(context.Context, string) (Quote, error)
+	FindAll(context.Context, string) ([]Quote, error)
+	FindByCriteria(context.Context, string, QuoteCriteria) ([]Quote, error)
+	StreamByCriteria(context.Context, string, QuoteCriteria, func(Quote) error) error
+	Create(context.Context, string, Quote) (Quote, error)
+	CreateBatch(context.Context, []FundQuote) ([]string, error)
+	Correct(context.Context, string, Quote, string) (Quote, error)
 }
 
 // QuoteService handle the use cases for Quote
 type QuoteService interface {
-	GetQuote(string, string) (PublicQuote, error)
-	GetLatestQuote(string) (PublicQuote, error)
-	GetQuotes(string) ([]PublicQuote, error)
-	CreateQuote(string, ScraperCreateQuote) (PublicQuote, error)
+	GetQuote(context.Context, string, string) (PublicQuote, error)
+	GetQuoteWithProvenance(context.Context, string, string) (PublicQuoteWithProvenance, error)
+	GetLatestQuote(context.Context, string) (PublicQuote, error)
+	GetQuotes(context.Context, string) ([]PublicQuote, error)
+	GetQuotePage(context.Context, string, QuoteCriteria) (PublicQuotePage, error)
+	StreamQuotes(context.Context, string, QuoteCriteria, func(PublicQuote) error) error
+	StreamAllQuotes(context.Context, func(PublicFundQuote) error) error
+	CreateQuote(context.Context, string, ScraperCreateQuote, string) (PublicQuote, error)
+	CreateQuoteBatch(context.Context, []ScraperBatchQuote, string) (PublicQuoteBatch, error)
+	CorrectQuote(context.Context, string, string, ScraperCorrectQuote, string) (PublicQuote, error)
 }
 
 // QuoteServiceImpl is the implementation of QuoteService
@@ -40,86 +105,446 @@ func NewQuoteService(fundRepo FundRepository, quoteRepo QuoteRepository) *QuoteS
 }
 
 // GetQuote return the quote for the given isin and date
-func (s QuoteServiceImpl) GetQuote(isin string, date string) (PublicQuote, error) {
-	if _, err := s.fundRepo.FindByISIN(isin); err != nil {
+func (s QuoteServiceImpl) GetQuote(ctx context.Context, isin string, date string) (PublicQuote, error) {
+	fund, err := s.fundRepo.FindByISIN(ctx, isin)
+	if err != nil {
 		return PublicQuote{}, err
 	}
 
-	quote, err := s.quoteRepo.FindByISINAndDate(isin, date)
+	quote, err := s.quoteRepo.FindByISINAndDate(ctx, isin, date)
 	if err != nil {
 		return PublicQuote{}, err
 	}
 
-	return newPublicQuote(quote), nil
+	return newPublicQuote(quote, fund.PricePrecision), nil
+}
+
+// GetQuoteWithProvenance return the quote for the given isin and date along with its provenance
+func (s QuoteServiceImpl) GetQuoteWithProvenance(ctx context.Context, isin string, date string) (PublicQuoteWithProvenance, error) {
+	fund, err := s.fundRepo.FindByISIN(ctx, isin)
+	if err != nil {
+		return PublicQuoteWithProvenance{}, err
+	}
+
+	quote, err := s.quoteRepo.FindByISINAndDate(ctx, isin, date)
+	if err != nil {
+		return PublicQuoteWithProvenance{}, err
+	}
+
+	return newPublicQuoteWithProvenance(quote, fund.PricePrecision), nil
 }
 
 // GetLatestQuote return the latest (date desc) quote for the given isin
-func (s QuoteServiceImpl) GetLatestQuote(isin string) (PublicQuote, error) {
-	quote, err := s.quoteRepo.FindByDateDesc(isin)
+func (s QuoteServiceImpl) GetLatestQuote(ctx context.Context, isin string) (PublicQuote, error) {
+	fund, err := s.fundRepo.FindByISIN(ctx, isin)
+	if err != nil {
+		return PublicQuote{}, err
+	}
 
+	quote, err := s.quoteRepo.FindByDateDesc(ctx, isin)
 	if err != nil {
 		return PublicQuote{}, err
 	}
 
-	return newPublicQuote(quote), nil
+	return newPublicQuote(quote, fund.PricePrecision), nil
 }
 
 // GetQuotes return all quotes for the given isin
-func (s QuoteServiceImpl) GetQuotes(isin string) ([]PublicQuote, error) {
-	if _, err := s.fundRepo.FindByISIN(isin); err != nil {
+func (s QuoteServiceImpl) GetQuotes(ctx context.Context, isin string) ([]PublicQuote, error) {
+	fund, err := s.fundRepo.FindByISIN(ctx, isin)
+	if err != nil {
 		return []PublicQuote{}, err
 	}
 
-	quotes, err := s.quoteRepo.FindAll(isin)
+	quotes, err := s.quoteRepo.FindAll(ctx, isin)
 	if err != nil {
 		return []PublicQuote{}, err
 	}
 
 	var publicQuotes []PublicQuote
 	for _, quote := range quotes {
-		publicQuotes = append(publicQuotes, newPublicQuote(quote))
+		publicQuotes = append(publicQuotes, newPublicQuote(quote, fund.PricePrecision))
 	}
 
 	return publicQuotes, nil
 }
 
-// CreateQuote return the created quote for the given isin
-func (s QuoteServiceImpl) CreateQuote(isin string, scraperQuote ScraperCreateQuote) (PublicQuote, error) {
-	if _, err := s.fundRepo.FindByISIN(isin); err != nil {
+// GetQuotePage return a page of quotes for the given isin matching the given criteria
+func (s QuoteServiceImpl) GetQuotePage(ctx context.Context, isin string, criteria QuoteCriteria) (PublicQuotePage, error) {
+	if err := criteria.validate(); err != nil {
+		return PublicQuotePage{}, err
+	}
+
+	fund, err := s.fundRepo.FindByISIN(ctx, isin)
+	if err != nil {
+		return PublicQuotePage{}, err
+	}
+
+	if criteria.Order == "" {
+		criteria.Order = OrderDesc
+	}
+
+	// Fetch one extra quote to know if there is a next page
+	limit := criteria.Limit
+	if limit > 0 {
+		criteria.Limit = limit + 1
+	}
+
+	quotes, err := s.quoteRepo.FindByCriteria(ctx, isin, criteria)
+	if err != nil {
+		return PublicQuotePage{}, err
+	}
+
+	page := PublicQuotePage{Quotes: []PublicQuote{}}
+	if limit > 0 && len(quotes) > limit {
+		quotes = quotes[:limit]
+		page.NextCursor = EncodeQuoteCursor(quotes[limit-1].Date)
+	}
+
+	for _, quote := range quotes {
+		page.Quotes = append(page.Quotes, newPublicQuote(quote, fund.PricePrecision))
+	}
+
+	return page, nil
+}
+
+// StreamQuotes call fn with each quote for the given isin matching the given criteria as it is read
+// from the repository, latest first by default. It stops at the first error returned by fn, or when ctx is done,
+// and return it.
+func (s QuoteServiceImpl) StreamQuotes(ctx context.Context, isin string, criteria QuoteCriteria, fn func(PublicQuote) error) error {
+	if err := criteria.validate(); err != nil {
+		return err
+	}
+
+	fund, err := s.fundRepo.FindByISIN(ctx, isin)
+	if err != nil {
+		return err
+	}
+
+	if criteria.Order == "" {
+		criteria.Order = OrderDesc
+	}
+
+	return s.quoteRepo.StreamByCriteria(ctx, isin, criteria, func(quote Quote) error {
+		return fn(newPublicQuote(quote, fund.PricePrecision))
+	})
+}
+
+// StreamAllQuotes call fn with every quote of every fund, ordered by fund and date, as they are read from the repository.
+// Only the funds are held in memory, whatever the length of their histories. It stops at the first error
+// returned by fn, or when ctx is done, and return it.
+func (s QuoteServiceImpl) StreamAllQuotes(ctx context.Context, fn func(PublicFundQuote) error) error {
+	funds, err := s.fundRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, fund := range funds {
+		err := s.quoteRepo.StreamByCriteria(ctx, fund.Isin, QuoteCriteria{Order: OrderAsc}, func(quote Quote) error {
+			return fn(PublicFundQuote{Isin: fund.Isin, PublicQuote: newPublicQuote(quote, fund.PricePrecision)})
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CreateQuote return the created quote for the given isin.
+// Creating the same quote twice is idempotent, ErrQuoteConflict is returned when a quote already
+// exists for the fund on the same day with a different price.
+// The provenance of the quote is recorded with the given author as the identity that wrote it.
+func (s QuoteServiceImpl) CreateQuote(ctx context.Context, isin string, scraperQuote ScraperCreateQuote, author string) (PublicQuote, error) {
+	if err := scraperQuote.validate(); err != nil {
 		return PublicQuote{}, err
 	}
 
-	date, err := time.Parse(time.RFC3339, scraperQuote.Date)
+	fund, err := s.fundRepo.FindByISIN(ctx, isin)
 	if err != nil {
 		return PublicQuote{}, err
 	}
 
-	quote := Quote{Date: date, Price: scraperQuote.Price}
-	createdQuote, err := s.quoteRepo.Create(isin, quote)
+	date, _ := time.Parse(time.RFC3339, scraperQuote.Date)
+	quote := Quote{Date: date, Price: scraperQuote.Price, Provenance: scraperQuote.provenance(time.Now().UTC(), author)}
+	createdQuote, err := s.quoteRepo.Create(ctx, isin, quote)
 	if err != nil {
 		return PublicQuote{}, err
 	}
 
-	return newPublicQuote(createdQuote), nil
+	return newPublicQuote(createdQuote, fund.PricePrecision), nil
+}
+
+// CreateQuoteBatch create the valid quotes of the batch in a single transaction and return the status of each of them.
+// Quotes for unknown funds, with an invalid date or a price that is not positive are invalid and not created,
+// quotes already existing for a fund on the same day are duplicates and suspicious quotes can be quarantined.
+// The provenance of the created quotes is recorded with the given author and the same ingestion time.
+func (s QuoteServiceImpl) CreateQuoteBatch(ctx context.Context, scraperQuotes []ScraperBatchQuote, author string) (PublicQuoteBatch, error) {
+	if len(scraperQuotes) == 0 || len(scraperQuotes) > MaxQuoteBatchSize {
+		return PublicQuoteBatch{}, fmt.Errorf("%w: a batch must contain between 1 and %d quotes", ErrInvalidBatch, MaxQuoteBatchSize)
+	}
+
+	ingestedAt := time.Now().UTC()
+	batch := PublicQuoteBatch{Results: make([]PublicQuoteBatchResult, len(scraperQuotes))}
+	knownFunds := make(map[string]bool)
+	var fundQuotes []FundQuote
+	var indexes []int
+
+	for i, scraperQuote := range scraperQuotes {
+		isin := strings.ToUpper(scraperQuote.Isin)
+		batch.Results[i] = PublicQuoteBatchResult{Index: i, Isin: isin, Date: scraperQuote.Date, Status: QuoteStatusInvalid}
+
+		known, checked := knownFunds[isin]
+		if !checked {
+			_, err := s.fundRepo.FindByISIN(ctx, isin)
+			if err != nil && err != ErrFundNotFound {
+				return PublicQuoteBatch{}, err
+			}
+			known = err == nil
+			knownFunds[isin] = known
+		}
+		if !known {
+			batch.Results[i].Message = "The fund was not found"
+			continue
+		}
+
+		var validationErr ValidationError
+		if err := scraperQuote.validate(); errors.As(err, &validationErr) {
+			batch.Results[i].Message = validationErr.messages()
+			continue
+		}
+
+		date, _ := time.Parse(time.RFC3339, scraperQuote.Date)
+		quote := Quote{Date: date, Price: scraperQuote.Price, Provenance: scraperQuote.provenance(ingestedAt, author)}
+		fundQuotes = append(fundQuotes, FundQuote{Isin: isin, Quote: quote})
+		indexes = append(indexes, i)
+	}
+
+	if len(fundQuotes) > 0 {
+		statuses, err := s.quoteRepo.CreateBatch(ctx, fundQuotes)
+		if err != nil {
+			return PublicQuoteBatch{}, err
+		}
+
+		for i, status := range statuses {
+			batch.Results[indexes[i]].Status = status
+		}
+	}
+
+	for _, result := range batch.Results {
+		switch result.Status {
+		case QuoteStatusCreated:
+			batch.Created++
+		case QuoteStatusDuplicate:
+			batch.Duplicates++
+		case QuoteStatusQuarantined:
+			batch.Quarantined++
+		case QuoteStatusInvalid:
+			batch.Invalid++
+		}
+	}
+
+	return batch, nil
+}
+
+// CorrectQuote set the price of the quote for the given isin and day (YYYY-MM-DD), creating it if needed.
+// The previous price is kept in the history of the quote along with the author of the correction,
+// the provenance of the quote becomes the correction.
+func (s QuoteServiceImpl) CorrectQuote(ctx context.Context, isin, date string, correction ScraperCorrectQuote, author string) (PublicQuote, error) {
+	day, err := time.Parse("2006-01-02", date)
+
+	var v validator
+	v.check(err == nil, "date", "the date %s must use the YYYY-MM-DD format", date)
+	v.check(isValidPrice(correction.Price), "price", "the price must be positive with at most %d decimals and %d digits",
+		MaxPriceDecimals, MaxPriceDigits)
+	if err := v.error(ErrInvalidQuote); err != nil {
+		return PublicQuote{}, err
+	}
+
+	fund, err := s.fundRepo.FindByISIN(ctx, isin)
+	if err != nil {
+		return PublicQuote{}, err
+	}
+
+	provenance := &Provenance{SourceSystem: ProvenanceSourceCorrection, IngestedAt: time.Now().UTC(), IngestedBy: author}
+	quote, err := s.quoteRepo.Correct(ctx, isin, Quote{Date: day, Price: correction.Price, Provenance: provenance}, author)
+	if err != nil {
+		return PublicQuote{}, err
+	}
+
+	return newPublicQuote(quote, fund.PricePrecision), nil
+}
+
+// validate return ErrInvalidCriteria if the criteria cannot be used to list quotes
+func (c QuoteCriteria) validate() error {
+	if c.Order != "" && c.Order != OrderAsc && c.Order != OrderDesc {
+		return ErrInvalidCriteria
+	}
+
+	if c.Limit < 0 || c.Limit > MaxQuoteLimit {
+		return ErrInvalidCriteria
+	}
+
+	if !c.From.IsZero() && !c.To.IsZero() && c.From.After(c.To) {
+		return ErrInvalidCriteria
+	}
+
+	return nil
+}
+
+// EncodeQuoteCursor return the opaque cursor pointing after the quote of the given date
+func EncodeQuoteCursor(date time.Time) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(date.Format("2006-01-02")))
+}
+
+// DecodeQuoteCursor return the date of the quote the given cursor points after
+func DecodeQuoteCursor(cursor string) (time.Time, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, ErrInvalidCursor
+	}
+
+	date, err := time.Parse("2006-01-02", string(raw))
+	if err != nil {
+		return time.Time{}, ErrInvalidCursor
+	}
+
+	return date, nil
+}
+
+// PublicQuotePage is a page of PublicQuote, NextCursor is empty on the last page
+type PublicQuotePage struct {
+	Quotes     []PublicQuote
+	NextCursor string
 }
 
 // PublicQuote is Quote's representation to be returned by the API
 type PublicQuote struct {
-	Date  string  `json:"date"`
-	Price float64 `json:"price"`
+	Date  string `json:"date"`
+	Price Price  `json:"price"`
+}
+
+// ExactDecimals return the quote with its price written as an exact decimal string
+func (q PublicQuote) ExactDecimals() PublicQuote {
+	q.Price.Exact = true
+	return q
+}
+
+// PublicFundQuote is a PublicQuote along with the isin of its fund
+type PublicFundQuote struct {
+	Isin string `json:"isin"`
+	PublicQuote
+}
+
+// ExactDecimals return the quote with its price written as an exact decimal string
+func (q PublicFundQuote) ExactDecimals() PublicFundQuote {
+	q.PublicQuote = q.PublicQuote.ExactDecimals()
+	return q
 }
 
-// ScraperCreateQuote is Quote's representation send by the scraper to be created
+// ScraperCreateQuote is Quote's representation send by the scraper to be created.
+// Source, RunID and SourceURL are optional and recorded as the provenance of the quote.
 type ScraperCreateQuote struct {
-	Date  string          `json:"date"`
+	Date      string          `json:"date"`
+	Price     decimal.Decimal `json:"price"`
+	Source    string          `json:"source,omitempty"`
+	RunID     string          `json:"run_id,omitempty"`
+	SourceURL string          `json:"source_url,omitempty"`
+}
+
+// provenance return the Provenance of the quote ingested at the given time by the given author
+func (q ScraperCreateQuote) provenance(ingestedAt time.Time, author string) *Provenance {
+	return &Provenance{
+		SourceSystem: q.Source,
+		RunID:        q.RunID,
+		SourceURL:    q.SourceURL,
+		IngestedAt:   ingestedAt,
+		IngestedBy:   author,
+	}
+}
+
+// validate return a ValidationError wrapping ErrInvalidQuote when the date is not RFC 3339 or the price is invalid
+func (q ScraperCreateQuote) validate() error {
+	_, err := time.Parse(time.RFC3339, q.Date)
+
+	var v validator
+	v.check(err == nil, "date", "the date %s must use the RFC 3339 format", q.Date)
+	v.check(isValidPrice(q.Price), "price", "the price must be positive with at most %d decimals and %d digits",
+		MaxPriceDecimals, MaxPriceDigits)
+
+	return v.error(ErrInvalidQuote)
+}
+
+// ScraperCorrectQuote is the corrected price of a quote send by the scraper
+type ScraperCorrectQuote struct {
 	Price decimal.Decimal `json:"price"`
 }
 
-// newPublicQuote return a PublicQuote based on a Quote
-func newPublicQuote(quote Quote) PublicQuote {
-	price, _ := quote.Price.Float64()
+// ScraperBatchQuote is Quote's representation send by the scraper to be created in a batch
+type ScraperBatchQuote struct {
+	Isin string `json:"isin"`
+	ScraperCreateQuote
+}
+
+// PublicQuoteBatch is the result of a batch of quotes to be returned by the API
+type PublicQuoteBatch struct {
+	Created     int                      `json:"created"`
+	Duplicates  int                      `json:"duplicates"`
+	Quarantined int                      `json:"quarantined"`
+	Invalid     int                      `json:"invalid"`
+	Results     []PublicQuoteBatchResult `json:"results"`
+}
+
+// PublicQuoteBatchResult is the status of a quote of a batch, Index is its position in the batch
+type PublicQuoteBatchResult struct {
+	Index   int    `json:"index"`
+	Isin    string `json:"isin"`
+	Date    string `json:"date"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// PublicQuoteWithProvenance is a PublicQuote along with its provenance, which is null when unknown
+type PublicQuoteWithProvenance struct {
+	PublicQuote
+	Provenance *PublicProvenance `json:"provenance"`
+}
+
+// ExactDecimals return the quote with its price written as an exact decimal string
+func (q PublicQuoteWithProvenance) ExactDecimals() PublicQuoteWithProvenance {
+	q.PublicQuote = q.PublicQuote.ExactDecimals()
+	return q
+}
+
+// PublicProvenance is Provenance's representation to be returned by the API
+type PublicProvenance struct {
+	SourceSystem string `json:"source_system,omitempty"`
+	RunID        string `json:"run_id,omitempty"`
+	SourceURL    string `json:"source_url,omitempty"`
+	IngestedAt   string `json:"ingested_at"`
+	IngestedBy   string `json:"ingested_by"`
+}
+
+// newPublicQuoteWithProvenance return a PublicQuoteWithProvenance based on a Quote of a fund with the given price precision
+func newPublicQuoteWithProvenance(quote Quote, precision int32) PublicQuoteWithProvenance {
+	publicQuote := PublicQuoteWithProvenance{PublicQuote: newPublicQuote(quote, precision)}
+	if quote.Provenance != nil {
+		publicQuote.Provenance = &PublicProvenance{
+			SourceSystem: quote.Provenance.SourceSystem,
+			RunID:        quote.Provenance.RunID,
+			SourceURL:    quote.Provenance.SourceURL,
+			IngestedAt:   quote.Provenance.IngestedAt.Format(time.RFC3339),
+			IngestedBy:   quote.Provenance.IngestedBy,
+		}
+	}
+
+	return publicQuote
+}
+
+// newPublicQuote return a PublicQuote based on a Quote of a fund with the given price precision
+func newPublicQuote(quote Quote, precision int32) PublicQuote {
 	return PublicQuote{
 		Date:  quote.Date.Format("2006-01-02"),
-		Price: price,
+		Price: NewPrice(quote.Price, precision),
 	}
 }
@@ -1,57 +1,119 @@
 package pensiondata
 
+import "context"
+
 // QuoteRepositoryMock used for tests
 type QuoteRepositoryMock struct {
-	FindByISINAndDateFn func(string, string) (Quote, error)
-	FindByDateDescFn    func(string) (Quote, error)
-	FindAllFn           func(string) ([]Quote, error)
-	CreateFn            func(string, Quote) (Quote, error)
+	FindByISINAndDateFn func(context.Context, string, string) (Quote, error)
+	FindByDateDescFn    func(context.Context, string) (Quote, error)
+	FindAllFn           func(context.Context, string) ([]Quote, error)
+	FindByCriteriaFn    func(context.Context, string, QuoteCriteria) ([]Quote, error)
+	StreamByCriteriaFn  func(context.Context, string, QuoteCriteria, func(Quote) error) error
+	CreateFn            func(context.Context, string, Quote) (Quote, error)
+	CreateBatchFn       func(context.Context, []FundQuote) ([]string, error)
+	CorrectFn           func(context.Context, string, Quote, string) (Quote, error)
 }
 
 // QuoteServiceMock used for tests
 type QuoteServiceMock struct {
-	GetQuoteFn       func(string, string) (PublicQuote, error)
-	GetLatestQuoteFn func(string) (PublicQuote, error)
-	GetQuotesFn      func(string) ([]PublicQuote, error)
-	CreateQuoteFn    func(string, ScraperCreateQuote) (PublicQuote, error)
+	GetQuoteFn               func(context.Context, string, string) (PublicQuote, error)
+	GetQuoteWithProvenanceFn func(context.Context, string, string) (PublicQuoteWithProvenance, error)
+	GetLatestQuoteFn         func(context.Context, string) (PublicQuote, error)
+	GetQuotesFn              func(context.Context, string) ([]PublicQuote, error)
+	GetQuotePageFn           func(context.Context, string, QuoteCriteria) (PublicQuotePage, error)
+	StreamQuotesFn           func(context.Context, string, QuoteCriteria, func(PublicQuote) error) error
+	StreamAllQuotesFn        func(context.Context, func(PublicFundQuote) error) error
+	CreateQuoteFn            func(context.Context, string, ScraperCreateQuote, string) (PublicQuote, error)
+	CreateQuoteBatchFn       func(context.Context, []ScraperBatchQuote, string) (PublicQuoteBatch, error)
+	CorrectQuoteFn           func(context.Context, string, string, ScraperCorrectQuote, string) (PublicQuote, error)
 }
 
 // FindByISINAndDate mock
-func (q QuoteRepositoryMock) FindByISINAndDate(isin, date string) (Quote, error) {
-	return q.FindByISINAndDateFn(isin, date)
+func (q QuoteRepositoryMock) FindByISINAndDate(ctx context.Context, isin, date string) (Quote, error) {
+	return q.FindByISINAndDateFn(ctx, isin, date)
 }
 
 // FindByDateDesc mock
-func (q QuoteRepositoryMock) FindByDateDesc(isin string) (Quote, error) {
-	return q.FindByDateDescFn(isin)
+func (q QuoteRepositoryMock) FindByDateDesc(ctx context.Context, isin string) (Quote, error) {
+	return q.FindByDateDescFn(ctx, isin)
 }
 
 // FindAll mock
-func (q QuoteRepositoryMock) FindAll(isin string) ([]Quote, error) {
-	return q.FindAllFn(isin)
+func (q QuoteRepositoryMock) FindAll(ctx context.Context, isin string) ([]Quote, error) {
+	return q.FindAllFn(ctx, isin)
+}
+
+// FindByCriteria mock
+func (q QuoteRepositoryMock) FindByCriteria(ctx context.Context, isin string, criteria QuoteCriteria) ([]Quote, error) {
+	return q.FindByCriteriaFn(ctx, isin, criteria)
+}
+
+// StreamByCriteria mock
+func (q QuoteRepositoryMock) StreamByCriteria(ctx context.Context, isin string, criteria QuoteCriteria, fn func(Quote) error) error {
+	return q.StreamByCriteriaFn(ctx, isin, criteria, fn)
 }
 
 // Create mock
-func (q QuoteRepositoryMock) Create(isin string, quote Quote) (Quote, error) {
-	return q.CreateFn(isin, quote)
+func (q QuoteRepositoryMock) Create(ctx context.Context, isin string, quote Quote) (Quote, error) {
+	return q.CreateFn(ctx, isin, quote)
+}
+
+// CreateBatch mock
+func (q QuoteRepositoryMock) CreateBatch(ctx context.Context, quotes []FundQuote) ([]string, error) {
+	return q.CreateBatchFn(ctx, quotes)
+}
+
+// Correct mock
+func (q QuoteRepositoryMock) Correct(ctx context.Context, isin string, quote Quote, author string) (Quote, error) {
+	return q.CorrectFn(ctx, isin, quote, author)
 }
 
 // GetQuote mock
-func (s QuoteServiceMock) GetQuote(isin, date string) (PublicQuote, error) {
-	return s.GetQuoteFn(isin, date)
+func (s QuoteServiceMock) GetQuote(ctx context.Context, isin, date string) (PublicQuote, error) {
+	return s.GetQuoteFn(ctx, isin, date)
+}
+
+// GetQuoteWithProvenance mock
+func (s QuoteServiceMock) GetQuoteWithProvenance(ctx context.Context, isin, date string) (PublicQuoteWithProvenance, error) {
+	return s.GetQuoteWithProvenanceFn(ctx, isin, date)
 }
 
 // GetLatestQuote mock
-func (s QuoteServiceMock) GetLatestQuote(isin string) (PublicQuote, error) {
-	return s.GetLatestQuoteFn(isin)
+func (s QuoteServiceMock) GetLatestQuote(ctx context.Context, isin string) (PublicQuote, error) {
+	return s.GetLatestQuoteFn(ctx, isin)
 }
 
 // GetQuotes mock
-func (s QuoteServiceMock) GetQuotes(isin string) ([]PublicQuote, error) {
-	return s.GetQuotesFn(isin)
+func (s QuoteServiceMock) GetQuotes(ctx context.Context, isin string) ([]PublicQuote, error) {
+	return s.GetQuotesFn(ctx, isin)
+}
+
+// GetQuotePage mock
+func (s QuoteServiceMock) GetQuotePage(ctx context.Context, isin string, criteria QuoteCriteria) (PublicQuotePage, error) {
+	return s.GetQuotePageFn(ctx, isin, criteria)
+}
+
+// StreamQuotes mock
+func (s QuoteServiceMock) StreamQuotes(ctx context.Context, isin string, criteria QuoteCriteria, fn func(PublicQuote) error) error {
+	return s.StreamQuotesFn(ctx, isin, criteria, fn)
+}
+
+// StreamAllQuotes mock
+func (s QuoteServiceMock) StreamAllQuotes(ctx context.Context, fn func(PublicFundQuote) error) error {
+	return s.StreamAllQuotesFn(ctx, fn)
 }
 
 // CreateQuote mock
-func (s QuoteServiceMock) CreateQuote(isin string, scraperCreateQuote ScraperCreateQuote) (PublicQuote, error) {
-	return s.CreateQuoteFn(isin, scraperCreateQuote)
+func (s QuoteServiceMock) CreateQuote(ctx context.Context, isin string, scraperCreateQuote ScraperCreateQuote, author string) (PublicQuote, error) {
+	return s.CreateQuoteFn(ctx, isin, scraperCreateQuote, author)
+}
+
+// CreateQuoteBatch mock
+func (s QuoteServiceMock) CreateQuoteBatch(ctx context.Context, scraperQuotes []ScraperBatchQuote, author string) (PublicQuoteBatch, error) {
+	return s.CreateQuoteBatchFn(ctx, scraperQuotes, author)
+}
+
+// CorrectQuote mock
+func (s QuoteServiceMock) CorrectQuote(ctx context.Context, isin, date string, correction ScraperCorrectQuote, author string) (PublicQuote, error) {
+	return s.CorrectQuoteFn(ctx, isin, date, correction, author)
 }
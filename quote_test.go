@@ -1,6 +1,7 @@
 package pensiondata
 
 import (
+	"context"
 	"errors"
 	"reflect"
 	"testing"
@@ -16,36 +17,36 @@ func TestGetQuote(t *testing.T) {
 
 		fund := Fund{Isin: "BE123"}
 		fundRepo := FundRepositoryMock{}
-		fundRepo.FindByISINFn = func(isin string) (Fund, error) {
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
 			return fund, nil
 		}
 
 		quoteRepo := QuoteRepositoryMock{}
-		quoteRepo.FindByISINAndDateFn = func(isin, date string) (Quote, error) {
+		quoteRepo.FindByISINAndDateFn = func(ctx context.Context, isin, date string) (Quote, error) {
 			return want, nil
 		}
 
 		s := NewQuoteService(fundRepo, quoteRepo)
-		got, _ := s.GetQuote("BE123", "2020-06-27")
+		got, _ := s.GetQuote(context.Background(), "BE123", "2020-06-27")
 
-		if !reflect.DeepEqual(newPublicQuote(want), got) {
-			t.Errorf("want %v, got %v", newPublicQuote(want), got)
+		if !reflect.DeepEqual(newPublicQuote(want, 0), got) {
+			t.Errorf("want %v, got %v", newPublicQuote(want, 0), got)
 		}
 	})
 
 	t.Run("return error for fund", func(t *testing.T) {
 		fundRepo := FundRepositoryMock{}
-		fundRepo.FindByISINFn = func(isin string) (Fund, error) {
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
 			return Fund{}, errors.New("error")
 		}
 
 		quoteRepo := QuoteRepositoryMock{}
-		quoteRepo.FindByISINAndDateFn = func(isin, date string) (Quote, error) {
+		quoteRepo.FindByISINAndDateFn = func(ctx context.Context, isin, date string) (Quote, error) {
 			return Quote{}, nil
 		}
 
 		s := NewQuoteService(fundRepo, quoteRepo)
-		_, err := s.GetQuote("BE123", "2020-06-27")
+		_, err := s.GetQuote(context.Background(), "BE123", "2020-06-27")
 
 		if err == nil {
 			t.Errorf("want error")
@@ -55,17 +56,17 @@ func TestGetQuote(t *testing.T) {
 	t.Run("return error for quote", func(t *testing.T) {
 		fundRepo := FundRepositoryMock{}
 		fund := Fund{Isin: "BE123"}
-		fundRepo.FindByISINFn = func(isin string) (Fund, error) {
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
 			return fund, nil
 		}
 
 		quoteRepo := QuoteRepositoryMock{}
-		quoteRepo.FindByISINAndDateFn = func(isin, date string) (Quote, error) {
+		quoteRepo.FindByISINAndDateFn = func(ctx context.Context, isin, date string) (Quote, error) {
 			return Quote{}, errors.New("error")
 		}
 
 		s := NewQuoteService(fundRepo, quoteRepo)
-		_, err := s.GetQuote("BE123", "2020-06-27")
+		_, err := s.GetQuote(context.Background(), "BE123", "2020-06-27")
 
 		if err == nil {
 			t.Errorf("want error")
@@ -73,34 +74,112 @@ func TestGetQuote(t *testing.T) {
 	})
 }
 
+func TestGetQuoteWithProvenance(t *testing.T) {
+	t.Run("return quote with provenance successfully", func(t *testing.T) {
+		fundRepo := FundRepositoryMock{}
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return Fund{}, nil
+		}
+
+		date, _ := time.Parse("2006-01-02", "2020-06-27")
+		ingestedAt, _ := time.Parse(time.RFC3339, "2020-06-27T18:00:00Z")
+		quoteRepo := QuoteRepositoryMock{}
+		quoteRepo.FindByISINAndDateFn = func(ctx context.Context, isin, date string) (Quote, error) {
+			return Quote{Date: ingestedAt, Price: decimal.NewFromFloat(5.99), Provenance: &Provenance{
+				SourceSystem: "scraper",
+				RunID:        "run-42",
+				SourceURL:    "https://www.example.com/navs.pdf",
+				IngestedAt:   ingestedAt,
+				IngestedBy:   "scraper",
+			}}, nil
+		}
+
+		s := NewQuoteService(fundRepo, quoteRepo)
+		got, err := s.GetQuoteWithProvenance(context.Background(), "BE123", date.Format("2006-01-02"))
+
+		want := PublicQuoteWithProvenance{
+			PublicQuote: PublicQuote{Date: "2020-06-27", Price: NewPrice(decimal.NewFromFloat(5.99), 0)},
+			Provenance: &PublicProvenance{
+				SourceSystem: "scraper",
+				RunID:        "run-42",
+				SourceURL:    "https://www.example.com/navs.pdf",
+				IngestedAt:   "2020-06-27T18:00:00Z",
+				IngestedBy:   "scraper",
+			},
+		}
+		if err != nil || !reflect.DeepEqual(want, got) {
+			t.Errorf("want %v, got %v (%v)", want, got, err)
+		}
+	})
+
+	t.Run("return null provenance for older quotes", func(t *testing.T) {
+		fundRepo := FundRepositoryMock{}
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return Fund{}, nil
+		}
+
+		quoteRepo := QuoteRepositoryMock{}
+		quoteRepo.FindByISINAndDateFn = func(ctx context.Context, isin, date string) (Quote, error) {
+			return Quote{Date: time.Now(), Price: decimal.NewFromFloat(5.99)}, nil
+		}
+
+		s := NewQuoteService(fundRepo, quoteRepo)
+		got, _ := s.GetQuoteWithProvenance(context.Background(), "BE123", "2020-06-27")
+
+		if got.Provenance != nil {
+			t.Errorf("want no provenance, got %v", got.Provenance)
+		}
+	})
+}
+
 func TestLatestQuote(t *testing.T) {
 	t.Run("return quote successfully", func(t *testing.T) {
 		date, _ := time.Parse("2006-01-02", "2020-06-27")
 		want := Quote{Date: date, Price: decimal.NewFromFloat(5.99)}
 
 		fundRepo := FundRepositoryMock{}
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return Fund{PricePrecision: 4}, nil
+		}
 		quoteRepo := QuoteRepositoryMock{}
-		quoteRepo.FindByDateDescFn = func(isin string) (Quote, error) {
+		quoteRepo.FindByDateDescFn = func(ctx context.Context, isin string) (Quote, error) {
 			return want, nil
 		}
 
 		s := NewQuoteService(fundRepo, quoteRepo)
-		got, _ := s.GetLatestQuote("BE123")
+		got, _ := s.GetLatestQuote(context.Background(), "BE123")
+
+		if !reflect.DeepEqual(newPublicQuote(want, 4), got) {
+			t.Errorf("want %v, got %v", newPublicQuote(want, 4), got)
+		}
+	})
+
+	t.Run("return error if fund is not found", func(t *testing.T) {
+		fundRepo := FundRepositoryMock{}
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return Fund{}, ErrFundNotFound
+		}
+
+		s := NewQuoteService(fundRepo, QuoteRepositoryMock{})
+		_, err := s.GetLatestQuote(context.Background(), "BE123")
 
-		if !reflect.DeepEqual(newPublicQuote(want), got) {
-			t.Errorf("want %v, got %v", newPublicQuote(want), got)
+		if err != ErrFundNotFound {
+			t.Errorf("want %v, got %v", ErrFundNotFound, err)
 		}
 	})
 
 	t.Run("return error", func(t *testing.T) {
 		fundRepo := FundRepositoryMock{}
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return Fund{}, nil
+		}
 		quoteRepo := QuoteRepositoryMock{}
-		quoteRepo.FindByDateDescFn = func(isin string) (Quote, error) {
+		quoteRepo.FindByDateDescFn = func(ctx context.Context, isin string) (Quote, error) {
 			return Quote{}, errors.New("error")
 		}
 
 		s := NewQuoteService(fundRepo, quoteRepo)
-		_, err := s.GetLatestQuote("BE123")
+		_, err := s.GetLatestQuote(context.Background(), "BE123")
 
 		if err == nil {
 			t.Errorf("want error")
@@ -115,21 +194,21 @@ func TestGetQuotes(t *testing.T) {
 			{Date: date, Price: decimal.NewFromFloat(7.99)}}
 
 		fundRepo := FundRepositoryMock{}
-		fundRepo.FindByISINFn = func(isin string) (Fund, error) {
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
 			return Fund{}, nil
 		}
 
 		quoteRepo := QuoteRepositoryMock{}
-		quoteRepo.FindAllFn = func(isin string) ([]Quote, error) {
+		quoteRepo.FindAllFn = func(ctx context.Context, isin string) ([]Quote, error) {
 			return wants, nil
 		}
 
 		s := NewQuoteService(fundRepo, quoteRepo)
-		got, _ := s.GetQuotes("BE123")
+		got, _ := s.GetQuotes(context.Background(), "BE123")
 
 		var wantPublicQuote []PublicQuote
 		for _, want := range wants {
-			wantPublicQuote = append(wantPublicQuote, newPublicQuote(want))
+			wantPublicQuote = append(wantPublicQuote, newPublicQuote(want, 0))
 		}
 
 		if !reflect.DeepEqual(wantPublicQuote, got) {
@@ -139,17 +218,17 @@ func TestGetQuotes(t *testing.T) {
 
 	t.Run("return error for fund", func(t *testing.T) {
 		fundRepo := FundRepositoryMock{}
-		fundRepo.FindByISINFn = func(isin string) (Fund, error) {
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
 			return Fund{}, errors.New("error")
 		}
 
 		quoteRepo := QuoteRepositoryMock{}
-		quoteRepo.FindAllFn = func(isin string) ([]Quote, error) {
+		quoteRepo.FindAllFn = func(ctx context.Context, isin string) ([]Quote, error) {
 			return []Quote{}, nil
 		}
 
 		s := NewQuoteService(fundRepo, quoteRepo)
-		_, err := s.GetQuotes("BE123")
+		_, err := s.GetQuotes(context.Background(), "BE123")
 
 		if err == nil {
 			t.Errorf("want error")
@@ -158,17 +237,17 @@ func TestGetQuotes(t *testing.T) {
 
 	t.Run("return error for quote", func(t *testing.T) {
 		fundRepo := FundRepositoryMock{}
-		fundRepo.FindByISINFn = func(isin string) (Fund, error) {
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
 			return Fund{}, nil
 		}
 
 		quoteRepo := QuoteRepositoryMock{}
-		quoteRepo.FindAllFn = func(isin string) ([]Quote, error) {
+		quoteRepo.FindAllFn = func(ctx context.Context, isin string) ([]Quote, error) {
 			return []Quote{}, errors.New("error")
 		}
 
 		s := NewQuoteService(fundRepo, quoteRepo)
-		_, err := s.GetQuotes("BE123")
+		_, err := s.GetQuotes(context.Background(), "BE123")
 
 		if err == nil {
 			t.Errorf("want error")
@@ -176,45 +255,250 @@ func TestGetQuotes(t *testing.T) {
 	})
 }
 
+func TestGetQuotePage(t *testing.T) {
+	t.Run("return page with next cursor", func(t *testing.T) {
+		date, _ := time.Parse("2006-01-02", "2020-06-27")
+		quotes := []Quote{
+			{Date: date, Price: decimal.NewFromFloat(5.99)},
+			{Date: date.AddDate(0, 0, -1), Price: decimal.NewFromFloat(6.99)},
+			{Date: date.AddDate(0, 0, -2), Price: decimal.NewFromFloat(7.99)},
+		}
+
+		fundRepo := FundRepositoryMock{}
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return Fund{}, nil
+		}
+
+		var gotCriteria QuoteCriteria
+		quoteRepo := QuoteRepositoryMock{}
+		quoteRepo.FindByCriteriaFn = func(ctx context.Context, isin string, criteria QuoteCriteria) ([]Quote, error) {
+			gotCriteria = criteria
+			return quotes[:criteria.Limit], nil
+		}
+
+		s := NewQuoteService(fundRepo, quoteRepo)
+		got, err := s.GetQuotePage(context.Background(), "BE123", QuoteCriteria{Limit: 2})
+		if err != nil {
+			t.Fatalf("want no error, got %s", err)
+		}
+
+		if gotCriteria.Limit != 3 || gotCriteria.Order != OrderDesc {
+			t.Errorf("want limit 3 and order desc, got limit %d and order %s", gotCriteria.Limit, gotCriteria.Order)
+		}
+		if len(got.Quotes) != 2 {
+			t.Errorf("want %d, got %d", 2, len(got.Quotes))
+		}
+
+		after, _ := DecodeQuoteCursor(got.NextCursor)
+		if !after.Equal(quotes[1].Date) {
+			t.Errorf("want %s, got %s", quotes[1].Date, after)
+		}
+	})
+
+	t.Run("return last page without cursor", func(t *testing.T) {
+		fundRepo := FundRepositoryMock{}
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return Fund{}, nil
+		}
+
+		quoteRepo := QuoteRepositoryMock{}
+		quoteRepo.FindByCriteriaFn = func(ctx context.Context, isin string, criteria QuoteCriteria) ([]Quote, error) {
+			return []Quote{{Price: decimal.NewFromFloat(5.99)}}, nil
+		}
+
+		s := NewQuoteService(fundRepo, quoteRepo)
+		got, _ := s.GetQuotePage(context.Background(), "BE123", QuoteCriteria{Limit: 2})
+
+		if got.NextCursor != "" {
+			t.Errorf("want no cursor, got %s", got.NextCursor)
+		}
+	})
+
+	t.Run("return error for invalid criteria", func(t *testing.T) {
+		s := NewQuoteService(FundRepositoryMock{}, QuoteRepositoryMock{})
+
+		for _, criteria := range []QuoteCriteria{
+			{Order: "sideways"},
+			{Limit: MaxQuoteLimit + 1},
+			{From: time.Now(), To: time.Now().AddDate(0, 0, -1)},
+		} {
+			if _, err := s.GetQuotePage(context.Background(), "BE123", criteria); err != ErrInvalidCriteria {
+				t.Errorf("want %s, got %v", ErrInvalidCriteria, err)
+			}
+		}
+	})
+
+	t.Run("return error for fund", func(t *testing.T) {
+		fundRepo := FundRepositoryMock{}
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return Fund{}, errors.New("error")
+		}
+
+		s := NewQuoteService(fundRepo, QuoteRepositoryMock{})
+		_, err := s.GetQuotePage(context.Background(), "BE123", QuoteCriteria{})
+
+		if err == nil {
+			t.Errorf("want error")
+		}
+	})
+}
+
+func TestStreamQuotes(t *testing.T) {
+	t.Run("stream quotes latest first with the precision of the fund", func(t *testing.T) {
+		fundRepo := FundRepositoryMock{}
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return Fund{PricePrecision: 4}, nil
+		}
+
+		var got QuoteCriteria
+		date, _ := time.Parse("2006-01-02", "2020-06-27")
+		quoteRepo := QuoteRepositoryMock{}
+		quoteRepo.StreamByCriteriaFn = func(ctx context.Context, isin string, criteria QuoteCriteria, fn func(Quote) error) error {
+			got = criteria
+			return fn(Quote{Date: date, Price: decimal.NewFromFloat(5.99)})
+		}
+
+		var quotes []PublicQuote
+		s := NewQuoteService(fundRepo, quoteRepo)
+		err := s.StreamQuotes(context.Background(), "BE123", QuoteCriteria{From: date}, func(quote PublicQuote) error {
+			quotes = append(quotes, quote)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("want no error, got %s", err)
+		}
+
+		if len(quotes) != 1 || quotes[0].Date != "2020-06-27" || quotes[0].Price.String() != "5.9900" {
+			t.Errorf("want quote of 2020-06-27 at 5.9900, got %v", quotes)
+		}
+		if got.Order != OrderDesc || !got.From.Equal(date) {
+			t.Errorf("want quotes from %s desc, got from %s %s", date, got.From, got.Order)
+		}
+	})
+
+	t.Run("return error if fund is not found", func(t *testing.T) {
+		fundRepo := FundRepositoryMock{}
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return Fund{}, ErrFundNotFound
+		}
+
+		s := NewQuoteService(fundRepo, QuoteRepositoryMock{})
+		err := s.StreamQuotes(context.Background(), "BE123", QuoteCriteria{}, nil)
+
+		if err != ErrFundNotFound {
+			t.Errorf("want %v, got %v", ErrFundNotFound, err)
+		}
+	})
+
+	t.Run("return error for invalid criteria", func(t *testing.T) {
+		s := NewQuoteService(FundRepositoryMock{}, QuoteRepositoryMock{})
+		err := s.StreamQuotes(context.Background(), "BE123", QuoteCriteria{Order: "up"}, nil)
+
+		if err != ErrInvalidCriteria {
+			t.Errorf("want %v, got %v", ErrInvalidCriteria, err)
+		}
+	})
+}
+
+func TestStreamAllQuotes(t *testing.T) {
+	t.Run("stream the quotes of every fund with their precision", func(t *testing.T) {
+		fundRepo := FundRepositoryMock{}
+		fundRepo.FindAllFn = func(ctx context.Context) ([]Fund, error) {
+			return []Fund{{Isin: "BE123", PricePrecision: 2}, {Isin: "LU123", PricePrecision: 4}}, nil
+		}
+
+		date, _ := time.Parse("2006-01-02", "2020-06-27")
+		quoteRepo := QuoteRepositoryMock{}
+		quoteRepo.StreamByCriteriaFn = func(ctx context.Context, isin string, criteria QuoteCriteria, fn func(Quote) error) error {
+			if criteria.Order != OrderAsc {
+				t.Errorf("want %s, got %s", OrderAsc, criteria.Order)
+			}
+			return fn(Quote{Date: date, Price: decimal.NewFromFloat(5.99)})
+		}
+
+		var got []string
+		s := NewQuoteService(fundRepo, quoteRepo)
+		err := s.StreamAllQuotes(context.Background(), func(quote PublicFundQuote) error {
+			got = append(got, quote.Isin+" "+quote.Date+" "+quote.Price.String())
+			return nil
+		})
+
+		want := []string{"BE123 2020-06-27 5.99", "LU123 2020-06-27 5.9900"}
+		if err != nil || !reflect.DeepEqual(want, got) {
+			t.Errorf("want %v, got %v (%v)", want, got, err)
+		}
+	})
+
+	t.Run("stop at the first error of the callback", func(t *testing.T) {
+		fundRepo := FundRepositoryMock{}
+		fundRepo.FindAllFn = func(ctx context.Context) ([]Fund, error) {
+			return []Fund{{Isin: "BE123"}, {Isin: "LU123"}}, nil
+		}
+
+		var isins []string
+		quoteRepo := QuoteRepositoryMock{}
+		quoteRepo.StreamByCriteriaFn = func(ctx context.Context, isin string, criteria QuoteCriteria, fn func(Quote) error) error {
+			isins = append(isins, isin)
+			return fn(Quote{})
+		}
+
+		s := NewQuoteService(fundRepo, quoteRepo)
+		err := s.StreamAllQuotes(context.Background(), func(quote PublicFundQuote) error {
+			return context.Canceled
+		})
+
+		if err != context.Canceled || len(isins) != 1 {
+			t.Errorf("want %v after 1 fund, got %v after %v", context.Canceled, err, isins)
+		}
+	})
+}
+
 func TestCreateQuote(t *testing.T) {
 	t.Run("create quote successfully", func(t *testing.T) {
 		want := ScraperCreateQuote{Date: "2020-07-09T00:00:00+02:00", Price: decimal.NewFromFloat(5.99)}
 
 		fundRepo := FundRepositoryMock{}
-		fundRepo.FindByISINFn = func(isin string) (Fund, error) {
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
 			return Fund{}, nil
 		}
 
 		date, _ := time.Parse("2006-01-02", "2020-06-27")
 		quote := Quote{Date: date, Price: decimal.NewFromFloat(5.99)}
+		var gotProvenance *Provenance
 		quoteRepo := QuoteRepositoryMock{}
-		quoteRepo.CreateFn = func(isin string, q Quote) (Quote, error) {
+		quoteRepo.CreateFn = func(ctx context.Context, isin string, q Quote) (Quote, error) {
+			gotProvenance = q.Provenance
 			return quote, nil
 		}
 
+		want.Source = "scraper"
+		want.RunID = "run-42"
 		s := NewQuoteService(fundRepo, quoteRepo)
-		got, _ := s.CreateQuote("BE123", want)
+		got, _ := s.CreateQuote(context.Background(), "BE123", want, "scraper")
 
-		if !reflect.DeepEqual(newPublicQuote(quote), got) {
+		if !reflect.DeepEqual(newPublicQuote(quote, 0), got) {
 			t.Errorf("want %v, got %v", want, got)
 		}
+		if gotProvenance == nil || gotProvenance.RunID != "run-42" || gotProvenance.IngestedBy != "scraper" || gotProvenance.IngestedAt.IsZero() {
+			t.Errorf("want provenance of run-42 ingested by scraper, got %v", gotProvenance)
+		}
 	})
 
 	t.Run("return error for time parsing", func(t *testing.T) {
 		want := ScraperCreateQuote{Date: "2020-065-274", Price: decimal.NewFromFloat(5.99)}
 
 		fundRepo := FundRepositoryMock{}
-		fundRepo.FindByISINFn = func(isin string) (Fund, error) {
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
 			return Fund{}, nil
 		}
 
 		quoteRepo := QuoteRepositoryMock{}
-		quoteRepo.CreateFn = func(isin string, q Quote) (Quote, error) {
+		quoteRepo.CreateFn = func(ctx context.Context, isin string, q Quote) (Quote, error) {
 			return Quote{}, nil
 		}
 
 		s := NewQuoteService(fundRepo, quoteRepo)
-		_, err := s.CreateQuote("BE123", want)
+		_, err := s.CreateQuote(context.Background(), "BE123", want, "scraper")
 
 		if err == nil {
 			t.Errorf("want error")
@@ -223,17 +507,17 @@ func TestCreateQuote(t *testing.T) {
 
 	t.Run("return error for fund", func(t *testing.T) {
 		fundRepo := FundRepositoryMock{}
-		fundRepo.FindByISINFn = func(isin string) (Fund, error) {
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
 			return Fund{}, errors.New("error")
 		}
 
 		quoteRepo := QuoteRepositoryMock{}
-		quoteRepo.CreateFn = func(isin string, q Quote) (Quote, error) {
+		quoteRepo.CreateFn = func(ctx context.Context, isin string, q Quote) (Quote, error) {
 			return Quote{}, nil
 		}
 
 		s := NewQuoteService(fundRepo, quoteRepo)
-		_, err := s.CreateQuote("BE123", ScraperCreateQuote{})
+		_, err := s.CreateQuote(context.Background(), "BE123", ScraperCreateQuote{}, "scraper")
 
 		if err == nil {
 			t.Errorf("want error")
@@ -244,17 +528,196 @@ func TestCreateQuote(t *testing.T) {
 		want := ScraperCreateQuote{Date: "2020-07-09T00:00:00+02:00", Price: decimal.NewFromFloat(5.99)}
 
 		fundRepo := FundRepositoryMock{}
-		fundRepo.FindByISINFn = func(isin string) (Fund, error) {
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return Fund{}, nil
+		}
+
+		quoteRepo := QuoteRepositoryMock{}
+		quoteRepo.CreateFn = func(ctx context.Context, isin string, q Quote) (Quote, error) {
+			return Quote{}, errors.New("error")
+		}
+
+		s := NewQuoteService(fundRepo, quoteRepo)
+		_, err := s.CreateQuote(context.Background(), "BE123", want, "scraper")
+
+		if err == nil {
+			t.Errorf("want error")
+		}
+	})
+}
+
+func TestCreateQuoteBatch(t *testing.T) {
+	t.Run("return the status of each quote", func(t *testing.T) {
+		fundRepo := FundRepositoryMock{}
+		calls := 0
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			calls++
+			if isin == "BE456" {
+				return Fund{}, ErrFundNotFound
+			}
+			return Fund{Isin: isin}, nil
+		}
+
+		var created []FundQuote
+		quoteRepo := QuoteRepositoryMock{}
+		quoteRepo.CreateBatchFn = func(ctx context.Context, quotes []FundQuote) ([]string, error) {
+			created = quotes
+			return []string{QuoteStatusCreated, QuoteStatusDuplicate}, nil
+		}
+
+		scraperQuotes := []ScraperBatchQuote{
+			{Isin: "be123", ScraperCreateQuote: ScraperCreateQuote{Date: "2020-07-09T00:00:00+02:00", Price: decimal.NewFromFloat(5.99)}},
+			{Isin: "BE456", ScraperCreateQuote: ScraperCreateQuote{Date: "2020-07-09T00:00:00+02:00", Price: decimal.NewFromFloat(5.99)}},
+			{Isin: "BE123", ScraperCreateQuote: ScraperCreateQuote{Date: "2020-07-10", Price: decimal.NewFromFloat(5.99)}},
+			{Isin: "BE123", ScraperCreateQuote: ScraperCreateQuote{Date: "2020-07-10T00:00:00+02:00", Price: decimal.Zero}},
+			{Isin: "BE123", ScraperCreateQuote: ScraperCreateQuote{Date: "2020-07-09T00:00:00+02:00", Price: decimal.NewFromFloat(6.01)}},
+		}
+
+		s := NewQuoteService(fundRepo, quoteRepo)
+		got, err := s.CreateQuoteBatch(context.Background(), scraperQuotes, "scraper")
+
+		if err != nil {
+			t.Fatalf("want no error, got %s", err)
+		}
+		if calls != 2 {
+			t.Errorf("want 2 fund lookups, got %d", calls)
+		}
+		if len(created) != 2 || created[0].Isin != "BE123" {
+			t.Errorf("want 2 quotes for BE123 sent to the repository, got %v", created)
+		}
+
+		wantStatuses := []string{QuoteStatusCreated, QuoteStatusInvalid, QuoteStatusInvalid, QuoteStatusInvalid, QuoteStatusDuplicate}
+		for i, result := range got.Results {
+			if result.Index != i || result.Status != wantStatuses[i] {
+				t.Errorf("want status %s at %d, got %s at %d", wantStatuses[i], i, result.Status, result.Index)
+			}
+		}
+		if got.Created != 1 || got.Duplicates != 1 || got.Invalid != 3 {
+			t.Errorf("want 1 created, 1 duplicate and 3 invalid, got %d, %d and %d", got.Created, got.Duplicates, got.Invalid)
+		}
+	})
+
+	t.Run("return error for empty batch", func(t *testing.T) {
+		s := NewQuoteService(FundRepositoryMock{}, QuoteRepositoryMock{})
+		_, err := s.CreateQuoteBatch(context.Background(), []ScraperBatchQuote{}, "scraper")
+
+		if !errors.Is(err, ErrInvalidBatch) {
+			t.Errorf("want %s, got %v", ErrInvalidBatch, err)
+		}
+	})
+
+	t.Run("return error for too large batch", func(t *testing.T) {
+		s := NewQuoteService(FundRepositoryMock{}, QuoteRepositoryMock{})
+		_, err := s.CreateQuoteBatch(context.Background(), make([]ScraperBatchQuote, MaxQuoteBatchSize+1), "scraper")
+
+		if !errors.Is(err, ErrInvalidBatch) {
+			t.Errorf("want %s, got %v", ErrInvalidBatch, err)
+		}
+	})
+
+	t.Run("return error for quote", func(t *testing.T) {
+		fundRepo := FundRepositoryMock{}
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return Fund{}, nil
+		}
+
+		quoteRepo := QuoteRepositoryMock{}
+		quoteRepo.CreateBatchFn = func(ctx context.Context, quotes []FundQuote) ([]string, error) {
+			return nil, errors.New("error")
+		}
+
+		scraperQuotes := []ScraperBatchQuote{
+			{Isin: "BE123", ScraperCreateQuote: ScraperCreateQuote{Date: "2020-07-09T00:00:00+02:00", Price: decimal.NewFromFloat(5.99)}},
+		}
+
+		s := NewQuoteService(fundRepo, quoteRepo)
+		_, err := s.CreateQuoteBatch(context.Background(), scraperQuotes, "scraper")
+
+		if err == nil {
+			t.Errorf("want error")
+		}
+	})
+}
+
+func TestCorrectQuote(t *testing.T) {
+	t.Run("correct quote successfully", func(t *testing.T) {
+		fundRepo := FundRepositoryMock{}
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return Fund{}, nil
+		}
+
+		var gotQuote Quote
+		var gotAuthor string
+		quoteRepo := QuoteRepositoryMock{}
+		quoteRepo.CorrectFn = func(ctx context.Context, isin string, quote Quote, author string) (Quote, error) {
+			gotQuote, gotAuthor = quote, author
+			return quote, nil
+		}
+
+		s := NewQuoteService(fundRepo, quoteRepo)
+		got, err := s.CorrectQuote(context.Background(), "BE123", "2020-06-27", ScraperCorrectQuote{Price: decimal.NewFromFloat(6.01)}, "scraper")
+
+		if err != nil {
+			t.Fatalf("want no error, got %s", err)
+		}
+		want := PublicQuote{Date: "2020-06-27", Price: NewPrice(decimal.NewFromFloat(6.01), 0)}
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+		if gotQuote.Date.Format("2006-01-02") != "2020-06-27" || gotAuthor != "scraper" {
+			t.Errorf("want quote of 2020-06-27 corrected by scraper, got %v by %s", gotQuote, gotAuthor)
+		}
+		if gotQuote.Provenance == nil || gotQuote.Provenance.SourceSystem != ProvenanceSourceCorrection ||
+			gotQuote.Provenance.IngestedBy != "scraper" || gotQuote.Provenance.IngestedAt.IsZero() {
+			t.Errorf("want provenance of the correction by scraper, got %v", gotQuote.Provenance)
+		}
+	})
+
+	t.Run("return error for invalid date", func(t *testing.T) {
+		s := NewQuoteService(FundRepositoryMock{}, QuoteRepositoryMock{})
+		_, err := s.CorrectQuote(context.Background(), "BE123", "2020-06-27T00:00:00Z", ScraperCorrectQuote{Price: decimal.NewFromFloat(6.01)}, "scraper")
+
+		if !errors.Is(err, ErrInvalidQuote) {
+			t.Errorf("want %s, got %v", ErrInvalidQuote, err)
+		}
+	})
+
+	t.Run("return error for price not positive", func(t *testing.T) {
+		s := NewQuoteService(FundRepositoryMock{}, QuoteRepositoryMock{})
+		_, err := s.CorrectQuote(context.Background(), "BE123", "2020-06-27", ScraperCorrectQuote{Price: decimal.NewFromFloat(-1)}, "scraper")
+
+		if !errors.Is(err, ErrInvalidQuote) {
+			t.Errorf("want %s, got %v", ErrInvalidQuote, err)
+		}
+	})
+
+	t.Run("return error for fund", func(t *testing.T) {
+		fundRepo := FundRepositoryMock{}
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return Fund{}, ErrFundNotFound
+		}
+
+		s := NewQuoteService(fundRepo, QuoteRepositoryMock{})
+		_, err := s.CorrectQuote(context.Background(), "BE123", "2020-06-27", ScraperCorrectQuote{Price: decimal.NewFromFloat(6.01)}, "scraper")
+
+		if err != ErrFundNotFound {
+			t.Errorf("want %s, got %v", ErrFundNotFound, err)
+		}
+	})
+
+	t.Run("return error for quote", func(t *testing.T) {
+		fundRepo := FundRepositoryMock{}
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
 			return Fund{}, nil
 		}
 
 		quoteRepo := QuoteRepositoryMock{}
-		quoteRepo.CreateFn = func(isin string, q Quote) (Quote, error) {
+		quoteRepo.CorrectFn = func(ctx context.Context, isin string, quote Quote, author string) (Quote, error) {
 			return Quote{}, errors.New("error")
 		}
 
 		s := NewQuoteService(fundRepo, quoteRepo)
-		_, err := s.CreateQuote("BE123", want)
+		_, err := s.CorrectQuote(context.Background(), "BE123", "2020-06-27", ScraperCorrectQuote{Price: decimal.NewFromFloat(6.01)}, "scraper")
 
 		if err == nil {
 			t.Errorf("want error")
@@ -270,13 +733,13 @@ func TestNewPublicQuote(t *testing.T) {
 			Price: decimal.NewFromFloat(5.99),
 		}
 
-		got := newPublicQuote(want)
+		got := newPublicQuote(want, 4)
 
 		if want.Date.Format("2006-01-02") != got.Date {
 			t.Errorf("want %s, got %s", want.Date.Format("2006-01-02"), got.Date)
 		}
-		if !want.Price.Equal(decimal.NewFromFloat(got.Price)) {
-			t.Errorf("want %s, got %.2f", want.Price, got.Price)
+		if !want.Price.Equal(got.Price.Value) || got.Price.String() != "5.9900" {
+			t.Errorf("want %s with 4 decimals, got %s", want.Price, got.Price)
 		}
 	})
 }
@@ -0,0 +1,126 @@
+package pensiondata
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rate limit plans always available, RateLimitPlanAnonymous limits the requests without API key by client IP,
+// RateLimitPlanStandard the API keys without plan or with an unknown plan
+// and RateLimitPlanAuthentication the failed authentications by client IP
+const (
+	RateLimitPlanAnonymous      = "anonymous"
+	RateLimitPlanStandard       = "standard"
+	RateLimitPlanAuthentication = "authentication"
+)
+
+// rateLimitPeriods are the units of the period of a rate limit plan
+var rateLimitPeriods = map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}
+
+// RateLimitPlan allows Limit requests per Period, refilled continuously, with bursts up to Limit requests
+type RateLimitPlan struct {
+	Name   string
+	Limit  int
+	Period time.Duration
+}
+
+// RateLimitResult is the state of the bucket of a client after taking a request from it.
+// Reset is the time until the bucket is full again and RetryAfter the time until a denied request is allowed.
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// RateLimitStore holds the token buckets of the clients, it must be safe for concurrent use.
+// Peek return the state of the bucket of a client without taking a token from it.
+type RateLimitStore interface {
+	Take(string, RateLimitPlan, time.Time) (RateLimitResult, error)
+	Peek(string, RateLimitPlan, time.Time) (RateLimitResult, error)
+}
+
+// RateLimitService handle the use cases for rate limiting
+type RateLimitService interface {
+	Allow(string, string) (RateLimitResult, error)
+	Check(string, string) (RateLimitResult, error)
+}
+
+// RateLimitServiceImpl is the implementation of RateLimitService
+type RateLimitServiceImpl struct {
+	store RateLimitStore
+	plans map[string]RateLimitPlan
+	now   func() time.Time
+}
+
+// NewRateLimitService return a new, fully functional, implementation of RateLimitService
+func NewRateLimitService(store RateLimitStore, plans map[string]RateLimitPlan) *RateLimitServiceImpl {
+	return &RateLimitServiceImpl{store: store, plans: plans, now: time.Now}
+}
+
+// Allow take a request from the bucket of the client, limited by the given plan
+func (s RateLimitServiceImpl) Allow(client, plan string) (RateLimitResult, error) {
+	return s.store.Take(client, s.plan(plan), s.now())
+}
+
+// Check return whether the bucket of the client, limited by the given plan, allows a request without taking it
+func (s RateLimitServiceImpl) Check(client, plan string) (RateLimitResult, error) {
+	return s.store.Peek(client, s.plan(plan), s.now())
+}
+
+// plan return the plan of the given name, the standard plan when it is unknown
+func (s RateLimitServiceImpl) plan(name string) RateLimitPlan {
+	ratePlan, ok := s.plans[name]
+	if !ok {
+		ratePlan = s.plans[RateLimitPlanStandard]
+	}
+
+	return ratePlan
+}
+
+// DefaultRateLimitPlans return the anonymous, standard and authentication plans
+func DefaultRateLimitPlans() map[string]RateLimitPlan {
+	return map[string]RateLimitPlan{
+		RateLimitPlanAnonymous:      {Name: RateLimitPlanAnonymous, Limit: 60, Period: time.Minute},
+		RateLimitPlanStandard:       {Name: RateLimitPlanStandard, Limit: 600, Period: time.Minute},
+		RateLimitPlanAuthentication: {Name: RateLimitPlanAuthentication, Limit: 10, Period: time.Minute},
+	}
+}
+
+// ParseRateLimitPlans return the default plans overridden by the comma separated plans,
+// such as "anonymous=30/m,partner=100/s" for 30 requests per minute and 100 requests per second
+func ParseRateLimitPlans(plans string) (map[string]RateLimitPlan, error) {
+	ratePlans := DefaultRateLimitPlans()
+	if strings.TrimSpace(plans) == "" {
+		return ratePlans, nil
+	}
+
+	for _, plan := range strings.Split(plans, ",") {
+		parts := strings.SplitN(strings.TrimSpace(plan), "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("the rate limit plan %s must be name=limit/period", plan)
+		}
+
+		rate := strings.SplitN(parts[1], "/", 2)
+		if len(rate) != 2 {
+			return nil, fmt.Errorf("the rate limit plan %s must be name=limit/period", plan)
+		}
+
+		limit, err := strconv.Atoi(rate[0])
+		if err != nil || limit <= 0 {
+			return nil, fmt.Errorf("the limit of the rate limit plan %s must be a positive number", parts[0])
+		}
+
+		period, ok := rateLimitPeriods[rate[1]]
+		if !ok {
+			return nil, fmt.Errorf("the period of the rate limit plan %s must be s, m or h", parts[0])
+		}
+
+		ratePlans[parts[0]] = RateLimitPlan{Name: parts[0], Limit: limit, Period: period}
+	}
+
+	return ratePlans, nil
+}
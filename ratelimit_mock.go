@@ -0,0 +1,35 @@
+package pensiondata
+
+import "time"
+
+// RateLimitStoreMock used for tests
+type RateLimitStoreMock struct {
+	TakeFn func(string, RateLimitPlan, time.Time) (RateLimitResult, error)
+	PeekFn func(string, RateLimitPlan, time.Time) (RateLimitResult, error)
+}
+
+// RateLimitServiceMock used for tests
+type RateLimitServiceMock struct {
+	AllowFn func(string, string) (RateLimitResult, error)
+	CheckFn func(string, string) (RateLimitResult, error)
+}
+
+// Take mock
+func (s RateLimitStoreMock) Take(client string, plan RateLimitPlan, now time.Time) (RateLimitResult, error) {
+	return s.TakeFn(client, plan, now)
+}
+
+// Peek mock
+func (s RateLimitStoreMock) Peek(client string, plan RateLimitPlan, now time.Time) (RateLimitResult, error) {
+	return s.PeekFn(client, plan, now)
+}
+
+// Allow mock
+func (s RateLimitServiceMock) Allow(client, plan string) (RateLimitResult, error) {
+	return s.AllowFn(client, plan)
+}
+
+// Check mock
+func (s RateLimitServiceMock) Check(client, plan string) (RateLimitResult, error) {
+	return s.CheckFn(client, plan)
+}
@@ -0,0 +1,60 @@
+package pensiondata
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimitPlans(t *testing.T) {
+	t.Run("return default plans overridden by the given plans", func(t *testing.T) {
+		got, err := ParseRateLimitPlans("anonymous=30/m, partner=100/s")
+
+		want := map[string]RateLimitPlan{
+			RateLimitPlanAnonymous:      {Name: RateLimitPlanAnonymous, Limit: 30, Period: time.Minute},
+			RateLimitPlanStandard:       DefaultRateLimitPlans()[RateLimitPlanStandard],
+			RateLimitPlanAuthentication: DefaultRateLimitPlans()[RateLimitPlanAuthentication],
+			"partner":                   {Name: "partner", Limit: 100, Period: time.Second},
+		}
+		if err != nil || !reflect.DeepEqual(want, got) {
+			t.Errorf("want %v, got %v (%v)", want, got, err)
+		}
+	})
+
+	for _, plans := range []string{"partner", "partner=100", "partner=0/m", "partner=100/d", "=100/m"} {
+		t.Run("return error for "+plans, func(t *testing.T) {
+			if _, err := ParseRateLimitPlans(plans); err == nil {
+				t.Errorf("want error")
+			}
+		})
+	}
+}
+
+func TestAllow(t *testing.T) {
+	tests := []struct {
+		name string
+		plan string
+		want string
+	}{
+		{name: "limit with the given plan", plan: RateLimitPlanAnonymous, want: RateLimitPlanAnonymous},
+		{name: "limit with the standard plan for unknown plan", plan: "partner", want: RateLimitPlanStandard},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got RateLimitPlan
+			store := RateLimitStoreMock{}
+			store.TakeFn = func(client string, plan RateLimitPlan, now time.Time) (RateLimitResult, error) {
+				got = plan
+				return RateLimitResult{Allowed: true}, nil
+			}
+
+			if _, err := NewRateLimitService(store, DefaultRateLimitPlans()).Allow("ip:127.0.0.1", tt.plan); err != nil {
+				t.Fatalf("want no error, got %s", err)
+			}
+			if got.Name != tt.want {
+				t.Errorf("want %s plan, got %s", tt.want, got.Name)
+			}
+		})
+	}
+}
@@ -0,0 +1,311 @@
+package pensiondata
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+)
+
+// DefaultRiskWindow is the window used when no window is given
+const DefaultRiskWindow = "3y"
+
+// RiskWindowMax is the window covering the full quote history of a fund
+const RiskWindowMax = "max"
+
+// Risk is the risk metrics of a fund over a window, computed from its quotes
+type Risk struct {
+	Isin             string
+	Window           string
+	StartDate        time.Time
+	EndDate          time.Time
+	Observations     int
+	RiskFreeRate     float64
+	AnnualizedReturn float64
+	Volatility       float64
+	Sharpe           *float64
+	Sortino          *float64
+	MaxDrawdown      Drawdown
+}
+
+// Drawdown is the largest peak-to-trough decline of a fund.
+// RecoveryDate is nil when the price never got back to the peak.
+type Drawdown struct {
+	Drawdown     float64
+	PeakDate     time.Time
+	TroughDate   time.Time
+	RecoveryDate *time.Time
+}
+
+// RiskService handle the use cases for the risk metrics of a fund
+type RiskService interface {
+	GetRisk(context.Context, string, string) (PublicRisk, error)
+}
+
+// RiskServiceImpl is the implementation of RiskService
+type RiskServiceImpl struct {
+	fundRepo     FundRepository
+	quoteRepo    QuoteRepository
+	riskFreeRate float64
+}
+
+// NewRiskService return a new, fully functional, implementation of RiskService.
+// The risk free rate is an annual rate expressed as a fraction (0.01 is 1%).
+func NewRiskService(fundRepo FundRepository, quoteRepo QuoteRepository, riskFreeRate float64) *RiskServiceImpl {
+	return &RiskServiceImpl{fundRepo: fundRepo, quoteRepo: quoteRepo, riskFreeRate: riskFreeRate}
+}
+
+// GetRisk return the risk metrics of the fund for the given isin over the given window
+func (s RiskServiceImpl) GetRisk(ctx context.Context, isin string, window string) (PublicRisk, error) {
+	if window == "" {
+		window = DefaultRiskWindow
+	}
+
+	months, err := parseWindow(window)
+	if err != nil {
+		return PublicRisk{}, err
+	}
+
+	if _, err := s.fundRepo.FindByISIN(ctx, isin); err != nil {
+		return PublicRisk{}, err
+	}
+
+	quotes, err := s.quoteRepo.FindAll(ctx, isin)
+	if err != nil {
+		return PublicRisk{}, err
+	}
+
+	risk, err := computeRisk(quotes, months, s.riskFreeRate)
+	if err != nil {
+		return PublicRisk{}, err
+	}
+	risk.Isin = isin
+	risk.Window = window
+
+	return newPublicRisk(risk), nil
+}
+
+// parseWindow return the number of months of a window such as 6m or 3y, or 0 for the max window
+func parseWindow(window string) (int, error) {
+	if window == RiskWindowMax {
+		return 0, nil
+	}
+
+	if len(window) < 2 {
+		return 0, ErrInvalidWindow
+	}
+
+	n, err := strconv.Atoi(window[:len(window)-1])
+	if err != nil || n <= 0 {
+		return 0, ErrInvalidWindow
+	}
+
+	switch window[len(window)-1] {
+	case 'm':
+		return n, nil
+	case 'y':
+		return n * 12, nil
+	}
+
+	return 0, ErrInvalidWindow
+}
+
+// computeRisk return the risk metrics over the last months of quotes, or over all quotes when months is 0.
+//
+// Quotes are not evenly spaced (weekends, bank holidays, funds priced weekly), so each log return is
+// weighted by the time elapsed since the previous quote: a return over dt years is assumed to have a
+// variance of sigma² * dt, which gives the annualized volatility without assuming a number of
+// observations per year.
+func computeRisk(quotes []Quote, months int, riskFreeRate float64) (Risk, error) {
+	if len(quotes) == 0 {
+		return Risk{}, ErrQuoteNotFound
+	}
+
+	sorted := sortQuotesAsc(quotes)
+	if months > 0 {
+		start := subtractMonths(truncateToDay(sorted[len(sorted)-1].Date), months)
+		sorted = quotesFrom(sorted, start)
+	}
+
+	var dates []time.Time
+	var prices []float64
+	for _, quote := range sorted {
+		price, _ := quote.Price.Float64()
+		if price <= 0 {
+			continue
+		}
+		dates = append(dates, truncateToDay(quote.Date))
+		prices = append(prices, price)
+	}
+
+	var returns, durations []float64
+	for i := 1; i < len(prices); i++ {
+		dt := dates[i].Sub(dates[i-1]).Hours() / 24 / daysPerYear
+		if dt <= 0 {
+			continue
+		}
+		returns = append(returns, math.Log(prices[i]/prices[i-1]))
+		durations = append(durations, dt)
+	}
+
+	if len(returns) < 2 {
+		return Risk{}, ErrNotEnoughQuotes
+	}
+
+	var totalReturn, totalDuration float64
+	for i := range returns {
+		totalReturn += returns[i]
+		totalDuration += durations[i]
+	}
+	drift := totalReturn / totalDuration
+	riskFreeDrift := math.Log(1 + riskFreeRate)
+
+	var variance, downsideVariance float64
+	for i := range returns {
+		deviation := returns[i] - drift*durations[i]
+		variance += deviation * deviation / durations[i]
+
+		if excess := returns[i] - riskFreeDrift*durations[i]; excess < 0 {
+			downsideVariance += excess * excess / durations[i]
+		}
+	}
+	volatility := roundFloat(math.Sqrt(variance/float64(len(returns)-1)), returnPrecision)
+	downsideDeviation := roundFloat(math.Sqrt(downsideVariance/float64(len(returns))), returnPrecision)
+
+	annualizedReturn := math.Exp(drift) - 1
+	risk := Risk{
+		StartDate:        dates[0],
+		EndDate:          dates[len(dates)-1],
+		Observations:     len(prices),
+		RiskFreeRate:     riskFreeRate,
+		AnnualizedReturn: roundFloat(annualizedReturn, returnPrecision),
+		Volatility:       volatility,
+		MaxDrawdown:      computeMaxDrawdown(dates, prices),
+	}
+
+	if volatility > 0 {
+		sharpe := roundFloat((annualizedReturn-riskFreeRate)/volatility, returnPrecision)
+		risk.Sharpe = &sharpe
+	}
+	if downsideDeviation > 0 {
+		sortino := roundFloat((annualizedReturn-riskFreeRate)/downsideDeviation, returnPrecision)
+		risk.Sortino = &sortino
+	}
+
+	return risk, nil
+}
+
+// computeMaxDrawdown return the largest decline from a peak to a following trough,
+// and the first date the price got back to the peak
+func computeMaxDrawdown(dates []time.Time, prices []float64) Drawdown {
+	var maxDrawdown Drawdown
+	var maxPeakPrice float64
+	peak := 0
+
+	for i := range prices {
+		if prices[i] > prices[peak] {
+			peak = i
+		}
+
+		drawdown := prices[i]/prices[peak] - 1
+		if drawdown < maxDrawdown.Drawdown {
+			maxDrawdown = Drawdown{Drawdown: drawdown, PeakDate: dates[peak], TroughDate: dates[i]}
+			maxPeakPrice = prices[peak]
+		}
+	}
+
+	if maxDrawdown.Drawdown == 0 {
+		return Drawdown{PeakDate: dates[peak], TroughDate: dates[peak], RecoveryDate: &dates[peak]}
+	}
+
+	for i := range prices {
+		if dates[i].After(maxDrawdown.TroughDate) && prices[i] >= maxPeakPrice {
+			recoveryDate := dates[i]
+			maxDrawdown.RecoveryDate = &recoveryDate
+			break
+		}
+	}
+	maxDrawdown.Drawdown = roundFloat(maxDrawdown.Drawdown, returnPrecision)
+
+	return maxDrawdown
+}
+
+// quotesFrom return the quotes sorted by date asc starting at the nearest quote at or before the given day
+func quotesFrom(sorted []Quote, day time.Time) []Quote {
+	for i := len(sorted) - 1; i >= 0; i-- {
+		if !truncateToDay(sorted[i].Date).After(day) {
+			return sorted[i:]
+		}
+	}
+
+	return sorted
+}
+
+// roundFloat return x rounded to the given number of decimal places
+func roundFloat(x float64, places int) float64 {
+	shift := math.Pow(10, float64(places))
+	return math.Round(x*shift) / shift
+}
+
+// PublicRisk is Risk's representation to be returned by the API.
+// Rates and returns are annual and expressed as fractions (0.05 is 5%).
+type PublicRisk struct {
+	Isin             string         `json:"isin"`
+	Window           string         `json:"window"`
+	StartDate        string         `json:"start_date"`
+	EndDate          string         `json:"end_date"`
+	Observations     int            `json:"observations"`
+	RiskFreeRate     Number         `json:"risk_free_rate"`
+	AnnualizedReturn Number         `json:"annualized_return"`
+	Volatility       Number         `json:"volatility"`
+	Sharpe           *Number        `json:"sharpe_ratio"`
+	Sortino          *Number        `json:"sortino_ratio"`
+	MaxDrawdown      PublicDrawdown `json:"max_drawdown"`
+}
+
+// PublicDrawdown is Drawdown's representation to be returned by the API
+type PublicDrawdown struct {
+	Drawdown     Number  `json:"drawdown"`
+	PeakDate     string  `json:"peak_date"`
+	TroughDate   string  `json:"trough_date"`
+	RecoveryDate *string `json:"recovery_date"`
+}
+
+// ExactDecimals return the risk with its computed values written as exact decimal strings
+func (r PublicRisk) ExactDecimals() PublicRisk {
+	r.RiskFreeRate.Exact = true
+	r.AnnualizedReturn.Exact = true
+	r.Volatility.Exact = true
+	r.Sharpe = exactNumber(r.Sharpe)
+	r.Sortino = exactNumber(r.Sortino)
+	r.MaxDrawdown.Drawdown.Exact = true
+	return r
+}
+
+// newPublicRisk return a PublicRisk based on a Risk
+func newPublicRisk(risk Risk) PublicRisk {
+	publicRisk := PublicRisk{
+		Isin:             risk.Isin,
+		Window:           risk.Window,
+		StartDate:        risk.StartDate.Format("2006-01-02"),
+		EndDate:          risk.EndDate.Format("2006-01-02"),
+		Observations:     risk.Observations,
+		RiskFreeRate:     NewNumberFromFloat(risk.RiskFreeRate),
+		AnnualizedReturn: NewNumberFromFloat(risk.AnnualizedReturn),
+		Volatility:       NewNumberFromFloat(risk.Volatility),
+		Sharpe:           newOptionalNumber(risk.Sharpe),
+		Sortino:          newOptionalNumber(risk.Sortino),
+		MaxDrawdown: PublicDrawdown{
+			Drawdown:   NewNumberFromFloat(risk.MaxDrawdown.Drawdown),
+			PeakDate:   risk.MaxDrawdown.PeakDate.Format("2006-01-02"),
+			TroughDate: risk.MaxDrawdown.TroughDate.Format("2006-01-02"),
+		},
+	}
+
+	if risk.MaxDrawdown.RecoveryDate != nil {
+		recoveryDate := risk.MaxDrawdown.RecoveryDate.Format("2006-01-02")
+		publicRisk.MaxDrawdown.RecoveryDate = &recoveryDate
+	}
+
+	return publicRisk
+}
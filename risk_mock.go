@@ -0,0 +1,13 @@
+package pensiondata
+
+import "context"
+
+// RiskServiceMock used for tests
+type RiskServiceMock struct {
+	GetRiskFn func(context.Context, string, string) (PublicRisk, error)
+}
+
+// GetRisk mock
+func (s RiskServiceMock) GetRisk(ctx context.Context, isin, window string) (PublicRisk, error) {
+	return s.GetRiskFn(ctx, isin, window)
+}
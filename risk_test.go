@@ -0,0 +1,151 @@
+package pensiondata
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestGetRisk(t *testing.T) {
+	t.Run("return risk successfully", func(t *testing.T) {
+		fundRepo := FundRepositoryMock{}
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return Fund{Isin: isin}, nil
+		}
+
+		quoteRepo := QuoteRepositoryMock{}
+		quoteRepo.FindAllFn = func(ctx context.Context, isin string) ([]Quote, error) {
+			return testRiskQuotes(), nil
+		}
+
+		s := NewRiskService(fundRepo, quoteRepo, 0.01)
+		got, err := s.GetRisk(context.Background(), "BE123", "")
+		if err != nil {
+			t.Fatalf("want no error, got %s", err)
+		}
+
+		if got.Window != DefaultRiskWindow {
+			t.Errorf("want %s, got %s", DefaultRiskWindow, got.Window)
+		}
+		if got.RiskFreeRate.Float64() != 0.01 {
+			t.Errorf("want %f, got %s", 0.01, got.RiskFreeRate)
+		}
+		if got.MaxDrawdown.Drawdown.Float64() != -0.25 {
+			t.Errorf("want %f, got %s", -0.25, got.MaxDrawdown.Drawdown)
+		}
+	})
+
+	t.Run("return error for invalid window", func(t *testing.T) {
+		s := NewRiskService(FundRepositoryMock{}, QuoteRepositoryMock{}, 0)
+		_, err := s.GetRisk(context.Background(), "BE123", "3w")
+
+		if err != ErrInvalidWindow {
+			t.Errorf("want %s, got %v", ErrInvalidWindow, err)
+		}
+	})
+
+	t.Run("return error for fund", func(t *testing.T) {
+		fundRepo := FundRepositoryMock{}
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return Fund{}, errors.New("error")
+		}
+
+		s := NewRiskService(fundRepo, QuoteRepositoryMock{}, 0)
+		_, err := s.GetRisk(context.Background(), "BE123", "1y")
+
+		if err == nil {
+			t.Errorf("want error")
+		}
+	})
+
+	t.Run("return error when there are not enough quotes", func(t *testing.T) {
+		fundRepo := FundRepositoryMock{}
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return Fund{Isin: isin}, nil
+		}
+
+		quoteRepo := QuoteRepositoryMock{}
+		quoteRepo.FindAllFn = func(ctx context.Context, isin string) ([]Quote, error) {
+			return testRiskQuotes()[:2], nil
+		}
+
+		s := NewRiskService(fundRepo, quoteRepo, 0)
+		_, err := s.GetRisk(context.Background(), "BE123", "max")
+
+		if err != ErrNotEnoughQuotes {
+			t.Errorf("want %s, got %v", ErrNotEnoughQuotes, err)
+		}
+	})
+}
+
+func TestComputeRisk(t *testing.T) {
+	t.Run("return no volatility for a constant growth on irregular dates", func(t *testing.T) {
+		start, _ := time.Parse("2006-01-02", "2020-01-01")
+		var quotes []Quote
+		for _, days := range []int{0, 1, 4, 5, 30, 31, 90, 365} {
+			price := 100 * math.Exp(0.05*float64(days)/daysPerYear)
+			quotes = append(quotes, Quote{Date: start.AddDate(0, 0, days), Price: decimal.NewFromFloat(price)})
+		}
+
+		got, err := computeRisk(quotes, 0, 0)
+		if err != nil {
+			t.Fatalf("want no error, got %s", err)
+		}
+
+		if got.Volatility != 0 {
+			t.Errorf("want %f, got %f", 0.0, got.Volatility)
+		}
+		if got.Sharpe != nil {
+			t.Errorf("want no sharpe ratio, got %f", *got.Sharpe)
+		}
+	})
+
+	t.Run("return max drawdown with peak, trough and recovery dates", func(t *testing.T) {
+		got, _ := computeRisk(testRiskQuotes(), 0, 0)
+
+		if got.MaxDrawdown.PeakDate.Format("2006-01-02") != "2020-01-02" {
+			t.Errorf("want %s, got %s", "2020-01-02", got.MaxDrawdown.PeakDate.Format("2006-01-02"))
+		}
+		if got.MaxDrawdown.TroughDate.Format("2006-01-02") != "2020-01-03" {
+			t.Errorf("want %s, got %s", "2020-01-03", got.MaxDrawdown.TroughDate.Format("2006-01-02"))
+		}
+		if got.MaxDrawdown.RecoveryDate == nil || got.MaxDrawdown.RecoveryDate.Format("2006-01-02") != "2020-01-07" {
+			t.Errorf("want %s, got %v", "2020-01-07", got.MaxDrawdown.RecoveryDate)
+		}
+	})
+}
+
+func TestParseWindow(t *testing.T) {
+	tests := map[string]int{"6m": 6, "1y": 12, "10y": 120, "max": 0}
+	for window, want := range tests {
+		got, err := parseWindow(window)
+		if err != nil || got != want {
+			t.Errorf("%s: want %d, got %d (%v)", window, want, got, err)
+		}
+	}
+
+	for _, window := range []string{"", "y", "0y", "-1m", "3d"} {
+		if _, err := parseWindow(window); err != ErrInvalidWindow {
+			t.Errorf("%s: want %s, got %v", window, ErrInvalidWindow, err)
+		}
+	}
+}
+
+func testRiskQuotes() []Quote {
+	quote := func(date string, price float64) Quote {
+		d, _ := time.Parse("2006-01-02", date)
+		return Quote{Date: d, Price: decimal.NewFromFloat(price)}
+	}
+
+	return []Quote{
+		quote("2020-01-07", 125),
+		quote("2020-01-06", 110),
+		quote("2020-01-03", 90),
+		quote("2020-01-02", 120),
+		quote("2020-01-01", 100),
+	}
+}
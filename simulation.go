@@ -0,0 +1,329 @@
+package pensiondata
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Tax regimes of the Belgian pension savings, named after their tax reduction rate
+const (
+	TaxRegime30 = "30"
+	TaxRegime25 = "25"
+)
+
+// Ages bounding a pension savings plan
+const (
+	pensionSavingsMinAge             = 18
+	pensionSavingsAnticipatoryTaxAge = 60
+)
+
+// TaxParameters are the pension savings tax parameters applicable to the contributions of an income year.
+// The basic regime gives a reduction of BasicRate up to BasicCeiling, the extended regime a reduction of
+// ExtendedRate up to ExtendedCeiling. The anticipatory tax is levied at AnticipatoryTaxRate on the contributions
+// capitalised at the legal FictitiousRate, whatever the actual return of the fund.
+type TaxParameters struct {
+	Year                int
+	BasicCeiling        decimal.Decimal
+	BasicRate           decimal.Decimal
+	ExtendedCeiling     decimal.Decimal
+	ExtendedRate        decimal.Decimal
+	AnticipatoryTaxRate decimal.Decimal
+	FictitiousRate      decimal.Decimal
+}
+
+// TaxParametersRepository handle the data access operations on TaxParameters
+type TaxParametersRepository interface {
+	FindAll(context.Context) ([]TaxParameters, error)
+}
+
+// PensionSavingsSimulation is the year by year projection of a pension savings plan
+type PensionSavingsSimulation struct {
+	Isin               string
+	TaxRegime          string
+	AverageReturn      decimal.Decimal
+	Years              []PensionSavingsYear
+	TotalContributions decimal.Decimal
+	TotalTaxReduction  decimal.Decimal
+	CapitalAt60        decimal.Decimal
+	AnticipatoryTax    decimal.Decimal
+	FinalCapital       decimal.Decimal
+}
+
+// PensionSavingsYear is a single year of a PensionSavingsSimulation.
+// Historical is true when Return is the actual return of the fund for that year,
+// false when it is the average historical return of the fund.
+type PensionSavingsYear struct {
+	Year         int
+	Age          int
+	Contribution decimal.Decimal
+	TaxReduction decimal.Decimal
+	Return       decimal.Decimal
+	Historical   bool
+	Capital      decimal.Decimal
+}
+
+// SimulationService handle the use cases for simulations
+type SimulationService interface {
+	SimulatePensionSavings(context.Context, PensionSavingsSimulationRequest) (PublicPensionSavingsSimulation, error)
+}
+
+// SimulationServiceImpl is the implementation of SimulationService
+type SimulationServiceImpl struct {
+	fundRepo          FundRepository
+	quoteRepo         QuoteRepository
+	taxParametersRepo TaxParametersRepository
+}
+
+// NewSimulationService return a new, fully functional, implementation of SimulationService
+func NewSimulationService(fundRepo FundRepository, quoteRepo QuoteRepository, taxParametersRepo TaxParametersRepository) *SimulationServiceImpl {
+	return &SimulationServiceImpl{fundRepo: fundRepo, quoteRepo: quoteRepo, taxParametersRepo: taxParametersRepo}
+}
+
+// SimulatePensionSavings return the projection of a pension savings plan invested in the requested fund
+func (s SimulationServiceImpl) SimulatePensionSavings(ctx context.Context, request PensionSavingsSimulationRequest) (PublicPensionSavingsSimulation, error) {
+	birthDate, err := request.validate()
+	if err != nil {
+		return PublicPensionSavingsSimulation{}, err
+	}
+
+	if _, err := s.fundRepo.FindByISIN(ctx, request.Isin); err != nil {
+		return PublicPensionSavingsSimulation{}, err
+	}
+
+	quotes, err := s.quoteRepo.FindAll(ctx, request.Isin)
+	if err != nil {
+		return PublicPensionSavingsSimulation{}, err
+	}
+
+	parameters, err := s.taxParametersRepo.FindAll(ctx)
+	if err != nil {
+		return PublicPensionSavingsSimulation{}, err
+	}
+	if len(parameters) == 0 {
+		return PublicPensionSavingsSimulation{}, ErrTaxParametersNotFound
+	}
+
+	simulation, err := simulatePensionSavings(request, birthDate, quotes, parameters)
+	if err != nil {
+		return PublicPensionSavingsSimulation{}, err
+	}
+
+	return newPublicPensionSavingsSimulation(simulation), nil
+}
+
+// simulatePensionSavings project the plan from the start year until the saver turns 60.
+//
+// Contributions are invested at the beginning of each year, up to the ceiling of the tax regime, and
+// grow with the calendar year return of the fund when it is known, or with its average historical
+// return otherwise. The anticipatory tax is not levied on the capital reached at 60 but on the contributions
+// capitalised at the fictitious rate of the tax parameters of each year.
+func simulatePensionSavings(request PensionSavingsSimulationRequest, birthDate time.Time, quotes []Quote, parameters []TaxParameters) (PensionSavingsSimulation, error) {
+	yearlyReturns, averageReturn, err := historicalReturns(quotes)
+	if err != nil {
+		return PensionSavingsSimulation{}, err
+	}
+
+	simulation := PensionSavingsSimulation{
+		Isin:          request.Isin,
+		TaxRegime:     request.TaxRegime,
+		AverageReturn: averageReturn.Round(returnPrecision),
+	}
+
+	one := decimal.NewFromInt(1)
+	capital, taxBase := decimal.Zero, decimal.Zero
+	lastYear := birthDate.Year() + pensionSavingsAnticipatoryTaxAge - 1
+	for year := request.StartYear; year <= lastYear; year++ {
+		yearParameters := taxParametersForYear(parameters, year)
+
+		ceiling, rate := yearParameters.BasicCeiling, yearParameters.BasicRate
+		if request.TaxRegime == TaxRegime25 {
+			ceiling, rate = yearParameters.ExtendedCeiling, yearParameters.ExtendedRate
+		}
+		contribution := decimal.Min(request.YearlyContribution, ceiling)
+		taxReduction := contribution.Mul(rate).Round(2)
+
+		yearReturn, historical := yearlyReturns[year]
+		if !historical {
+			yearReturn = averageReturn
+		}
+		capital = capital.Add(contribution).Mul(one.Add(yearReturn)).Round(2)
+		taxBase = taxBase.Add(contribution).Mul(one.Add(yearParameters.FictitiousRate)).Round(2)
+
+		simulation.Years = append(simulation.Years, PensionSavingsYear{
+			Year:         year,
+			Age:          year - birthDate.Year(),
+			Contribution: contribution,
+			TaxReduction: taxReduction,
+			Return:       yearReturn.Round(returnPrecision),
+			Historical:   historical,
+			Capital:      capital,
+		})
+		simulation.TotalContributions = simulation.TotalContributions.Add(contribution)
+		simulation.TotalTaxReduction = simulation.TotalTaxReduction.Add(taxReduction)
+	}
+
+	anticipatoryTaxRate := taxParametersForYear(parameters, lastYear+1).AnticipatoryTaxRate
+	simulation.CapitalAt60 = capital
+	simulation.AnticipatoryTax = taxBase.Mul(anticipatoryTaxRate).Round(2)
+	simulation.FinalCapital = capital.Sub(simulation.AnticipatoryTax)
+
+	return simulation, nil
+}
+
+// historicalReturns return the return of every complete calendar year of the quotes,
+// and the annualized return over the whole history
+func historicalReturns(quotes []Quote) (map[int]decimal.Decimal, decimal.Decimal, error) {
+	if len(quotes) == 0 {
+		return nil, decimal.Zero, ErrQuoteNotFound
+	}
+
+	sorted := sortQuotesAsc(quotes)
+	first, last := sorted[0], sorted[len(sorted)-1]
+
+	days := truncateToDay(last.Date).Sub(truncateToDay(first.Date)).Hours() / 24
+	if days < 365 || !first.Price.IsPositive() {
+		return nil, decimal.Zero, ErrNotEnoughQuotes
+	}
+
+	growth, _ := last.Price.DivRound(first.Price, 16).Float64()
+	averageReturn := decimal.NewFromFloat(math.Pow(growth, daysPerYear/days) - 1)
+
+	yearlyReturns := make(map[int]decimal.Decimal)
+	for year := first.Date.Year() + 1; year < last.Date.Year(); year++ {
+		start, _ := quoteAtOrBefore(sorted, time.Date(year-1, time.December, 31, 0, 0, 0, 0, time.UTC))
+		end, _ := quoteAtOrBefore(sorted, time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC))
+		if !start.Price.IsPositive() {
+			continue
+		}
+		yearlyReturns[year] = end.Price.DivRound(start.Price, 16).Sub(decimal.NewFromInt(1))
+	}
+
+	return yearlyReturns, averageReturn, nil
+}
+
+// taxParametersForYear return the parameters of the latest year at or before the given year,
+// or the parameters of the earliest year when the given year is before all of them.
+// Parameters must be sorted by year asc.
+func taxParametersForYear(parameters []TaxParameters, year int) TaxParameters {
+	found := parameters[0]
+	for _, p := range parameters {
+		if p.Year > year {
+			break
+		}
+		found = p
+	}
+
+	return found
+}
+
+// PensionSavingsSimulationRequest is the representation of a pension savings simulation requested to the API
+type PensionSavingsSimulationRequest struct {
+	YearlyContribution decimal.Decimal `json:"yearly_contribution"`
+	BirthDate          string          `json:"birth_date"`
+	StartYear          int             `json:"start_year"`
+	Isin               string          `json:"isin"`
+	TaxRegime          string          `json:"tax_regime"`
+}
+
+// validate return the parsed birth date, or an error wrapping ErrInvalidSimulation
+func (r PensionSavingsSimulationRequest) validate() (time.Time, error) {
+	if !r.YearlyContribution.IsPositive() {
+		return time.Time{}, fmt.Errorf("%w: the yearly contribution must be positive", ErrInvalidSimulation)
+	}
+
+	if r.TaxRegime != TaxRegime30 && r.TaxRegime != TaxRegime25 {
+		return time.Time{}, fmt.Errorf("%w: the tax regime must be %s or %s", ErrInvalidSimulation, TaxRegime30, TaxRegime25)
+	}
+
+	birthDate, err := time.Parse("2006-01-02", r.BirthDate)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: the birth date must use the YYYY-MM-DD format", ErrInvalidSimulation)
+	}
+
+	startAge := r.StartYear - birthDate.Year()
+	if startAge < pensionSavingsMinAge || startAge >= pensionSavingsAnticipatoryTaxAge {
+		return time.Time{}, fmt.Errorf("%w: the saver must be between %d and %d years old in the start year",
+			ErrInvalidSimulation, pensionSavingsMinAge, pensionSavingsAnticipatoryTaxAge-1)
+	}
+
+	return birthDate, nil
+}
+
+// PublicPensionSavingsSimulation is PensionSavingsSimulation's representation to be returned by the API
+type PublicPensionSavingsSimulation struct {
+	Isin               string                     `json:"isin"`
+	TaxRegime          string                     `json:"tax_regime"`
+	AverageReturn      Number                     `json:"average_return"`
+	Years              []PublicPensionSavingsYear `json:"years"`
+	TotalContributions Amount                     `json:"total_contributions"`
+	TotalTaxReduction  Amount                     `json:"total_tax_reduction"`
+	CapitalAt60        Amount                     `json:"capital_at_60"`
+	AnticipatoryTax    Amount                     `json:"anticipatory_tax"`
+	FinalCapital       Amount                     `json:"final_capital"`
+}
+
+// PublicPensionSavingsYear is PensionSavingsYear's representation to be returned by the API
+type PublicPensionSavingsYear struct {
+	Year         int    `json:"year"`
+	Age          int    `json:"age"`
+	Contribution Amount `json:"contribution"`
+	TaxReduction Amount `json:"tax_reduction"`
+	Return       Number `json:"return"`
+	Historical   bool   `json:"historical"`
+	Capital      Amount `json:"capital"`
+}
+
+// ExactDecimals return the simulation with its amounts and average return written as exact decimal strings
+func (s PublicPensionSavingsSimulation) ExactDecimals() PublicPensionSavingsSimulation {
+	s.AverageReturn.Exact = true
+	s.TotalContributions.Exact = true
+	s.TotalTaxReduction.Exact = true
+	s.CapitalAt60.Exact = true
+	s.AnticipatoryTax.Exact = true
+	s.FinalCapital.Exact = true
+
+	years := make([]PublicPensionSavingsYear, len(s.Years))
+	for i, year := range s.Years {
+		year.Contribution.Exact = true
+		year.TaxReduction.Exact = true
+		year.Return.Exact = true
+		year.Capital.Exact = true
+		years[i] = year
+	}
+	s.Years = years
+
+	return s
+}
+
+// newPublicPensionSavingsSimulation return a PublicPensionSavingsSimulation based on a PensionSavingsSimulation
+func newPublicPensionSavingsSimulation(simulation PensionSavingsSimulation) PublicPensionSavingsSimulation {
+	publicSimulation := PublicPensionSavingsSimulation{
+		Isin:               simulation.Isin,
+		TaxRegime:          simulation.TaxRegime,
+		AverageReturn:      NewNumber(simulation.AverageReturn),
+		Years:              []PublicPensionSavingsYear{},
+		TotalContributions: NewAmount(simulation.TotalContributions, amountPrecision),
+		TotalTaxReduction:  NewAmount(simulation.TotalTaxReduction, amountPrecision),
+		CapitalAt60:        NewAmount(simulation.CapitalAt60, amountPrecision),
+		AnticipatoryTax:    NewAmount(simulation.AnticipatoryTax, amountPrecision),
+		FinalCapital:       NewAmount(simulation.FinalCapital, amountPrecision),
+	}
+
+	for _, year := range simulation.Years {
+		publicSimulation.Years = append(publicSimulation.Years, PublicPensionSavingsYear{
+			Year:         year.Year,
+			Age:          year.Age,
+			Contribution: NewAmount(year.Contribution, amountPrecision),
+			TaxReduction: NewAmount(year.TaxReduction, amountPrecision),
+			Return:       NewNumber(year.Return),
+			Historical:   year.Historical,
+			Capital:      NewAmount(year.Capital, amountPrecision),
+		})
+	}
+
+	return publicSimulation
+}
@@ -0,0 +1,23 @@
+package pensiondata
+
+import "context"
+
+// TaxParametersRepositoryMock used for tests
+type TaxParametersRepositoryMock struct {
+	FindAllFn func(context.Context) ([]TaxParameters, error)
+}
+
+// SimulationServiceMock used for tests
+type SimulationServiceMock struct {
+	SimulatePensionSavingsFn func(context.Context, PensionSavingsSimulationRequest) (PublicPensionSavingsSimulation, error)
+}
+
+// FindAll mock
+func (r TaxParametersRepositoryMock) FindAll(ctx context.Context) ([]TaxParameters, error) {
+	return r.FindAllFn(ctx)
+}
+
+// SimulatePensionSavings mock
+func (s SimulationServiceMock) SimulatePensionSavings(ctx context.Context, request PensionSavingsSimulationRequest) (PublicPensionSavingsSimulation, error) {
+	return s.SimulatePensionSavingsFn(ctx, request)
+}
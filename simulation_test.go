@@ -0,0 +1,189 @@
+package pensiondata
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestSimulatePensionSavings(t *testing.T) {
+	t.Run("return simulation successfully", func(t *testing.T) {
+		s := NewSimulationService(testSimulationFundRepo(), testSimulationQuoteRepo(), testTaxParametersRepo())
+		got, err := s.SimulatePensionSavings(context.Background(), testSimulationRequest())
+		if err != nil {
+			t.Fatalf("want no error, got %s", err)
+		}
+
+		if len(got.Years) != 5 {
+			t.Fatalf("want %d, got %d", 5, len(got.Years))
+		}
+
+		first := got.Years[0]
+		if first.Year != 2020 || first.Age != 55 {
+			t.Errorf("want year 2020 at 55, got year %d at %d", first.Year, first.Age)
+		}
+		if first.Contribution.Float64() != 990 || first.TaxReduction.Float64() != 297 {
+			t.Errorf("want contribution 990 and tax reduction 297, got %s and %s", first.Contribution, first.TaxReduction)
+		}
+		if !first.Historical || first.Return.Float64() != -0.1 || first.Capital.Float64() != 891 {
+			t.Errorf("want historical return -0.1 and capital 891, got %t %s and %s", first.Historical, first.Return, first.Capital)
+		}
+		if got.Years[1].Historical {
+			t.Errorf("want average return for %d", got.Years[1].Year)
+		}
+
+		if got.TotalContributions.Float64() != 4950 {
+			t.Errorf("want %f, got %s", 4950.0, got.TotalContributions)
+		}
+	})
+
+	t.Run("levy the anticipatory tax on the contributions capitalised at the fictitious rate", func(t *testing.T) {
+		s := NewSimulationService(testSimulationFundRepo(), testSimulationQuoteRepo(), testTaxParametersRepo())
+		got, err := s.SimulatePensionSavings(context.Background(), testSimulationRequest())
+		if err != nil {
+			t.Fatalf("want no error, got %s", err)
+		}
+
+		// 990 a year from 2020 to 2024 capitalised at 4.75% is 5701.67, whatever the -10% return of 2020
+		if got.AnticipatoryTax.String() != "456.13" {
+			t.Errorf("want %s, got %s", "456.13", got.AnticipatoryTax)
+		}
+		if !got.FinalCapital.Value.Equal(got.CapitalAt60.Value.Sub(got.AnticipatoryTax.Value)) {
+			t.Errorf("want %s minus %s, got %s", got.CapitalAt60, got.AnticipatoryTax, got.FinalCapital)
+		}
+	})
+
+	t.Run("return error for invalid request", func(t *testing.T) {
+		s := NewSimulationService(testSimulationFundRepo(), testSimulationQuoteRepo(), testTaxParametersRepo())
+
+		invalidRequests := []func(*PensionSavingsSimulationRequest){
+			func(r *PensionSavingsSimulationRequest) { r.YearlyContribution = decimal.Zero },
+			func(r *PensionSavingsSimulationRequest) { r.TaxRegime = "40" },
+			func(r *PensionSavingsSimulationRequest) { r.BirthDate = "01/06/1965" },
+			func(r *PensionSavingsSimulationRequest) { r.StartYear = 2030 },
+		}
+		for _, invalidate := range invalidRequests {
+			request := testSimulationRequest()
+			invalidate(&request)
+
+			if _, err := s.SimulatePensionSavings(context.Background(), request); !errors.Is(err, ErrInvalidSimulation) {
+				t.Errorf("want %s, got %v", ErrInvalidSimulation, err)
+			}
+		}
+	})
+
+	t.Run("return error when there is less than a year of quotes", func(t *testing.T) {
+		quoteRepo := QuoteRepositoryMock{}
+		quoteRepo.FindAllFn = func(ctx context.Context, isin string) ([]Quote, error) {
+			return testSimulationQuotes()[:1], nil
+		}
+
+		s := NewSimulationService(testSimulationFundRepo(), quoteRepo, testTaxParametersRepo())
+		_, err := s.SimulatePensionSavings(context.Background(), testSimulationRequest())
+
+		if err != ErrNotEnoughQuotes {
+			t.Errorf("want %s, got %v", ErrNotEnoughQuotes, err)
+		}
+	})
+
+	t.Run("return error when there are no tax parameters", func(t *testing.T) {
+		taxParametersRepo := TaxParametersRepositoryMock{}
+		taxParametersRepo.FindAllFn = func(ctx context.Context) ([]TaxParameters, error) {
+			return []TaxParameters{}, nil
+		}
+
+		s := NewSimulationService(testSimulationFundRepo(), testSimulationQuoteRepo(), taxParametersRepo)
+		_, err := s.SimulatePensionSavings(context.Background(), testSimulationRequest())
+
+		if err != ErrTaxParametersNotFound {
+			t.Errorf("want %s, got %v", ErrTaxParametersNotFound, err)
+		}
+	})
+
+	t.Run("return error for fund", func(t *testing.T) {
+		fundRepo := FundRepositoryMock{}
+		fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+			return Fund{}, errors.New("error")
+		}
+
+		s := NewSimulationService(fundRepo, testSimulationQuoteRepo(), testTaxParametersRepo())
+		_, err := s.SimulatePensionSavings(context.Background(), testSimulationRequest())
+
+		if err == nil {
+			t.Errorf("want error")
+		}
+	})
+}
+
+func TestTaxParametersForYear(t *testing.T) {
+	parameters := []TaxParameters{{Year: 2020}, {Year: 2022}}
+	tests := map[int]int{2019: 2020, 2020: 2020, 2021: 2020, 2022: 2022, 2040: 2022}
+
+	for year, want := range tests {
+		if got := taxParametersForYear(parameters, year); got.Year != want {
+			t.Errorf("%d: want %d, got %d", year, want, got.Year)
+		}
+	}
+}
+
+func testSimulationRequest() PensionSavingsSimulationRequest {
+	return PensionSavingsSimulationRequest{
+		YearlyContribution: decimal.NewFromInt(1000),
+		BirthDate:          "1965-06-01",
+		StartYear:          2020,
+		Isin:               "BE123",
+		TaxRegime:          TaxRegime30,
+	}
+}
+
+func testSimulationFundRepo() FundRepositoryMock {
+	fundRepo := FundRepositoryMock{}
+	fundRepo.FindByISINFn = func(ctx context.Context, isin string) (Fund, error) {
+		return Fund{Isin: isin}, nil
+	}
+
+	return fundRepo
+}
+
+func testSimulationQuoteRepo() QuoteRepositoryMock {
+	quoteRepo := QuoteRepositoryMock{}
+	quoteRepo.FindAllFn = func(ctx context.Context, isin string) ([]Quote, error) {
+		return testSimulationQuotes(), nil
+	}
+
+	return quoteRepo
+}
+
+func testSimulationQuotes() []Quote {
+	quote := func(date string, price float64) Quote {
+		d, _ := time.Parse("2006-01-02", date)
+		return Quote{Date: d, Price: decimal.NewFromFloat(price)}
+	}
+
+	return []Quote{
+		quote("2021-12-31", 110),
+		quote("2020-12-31", 99),
+		quote("2019-12-31", 110),
+		quote("2018-12-31", 100),
+	}
+}
+
+func testTaxParametersRepo() TaxParametersRepositoryMock {
+	taxParametersRepo := TaxParametersRepositoryMock{}
+	taxParametersRepo.FindAllFn = func(ctx context.Context) ([]TaxParameters, error) {
+		return []TaxParameters{{
+			Year:                2020,
+			BasicCeiling:        decimal.NewFromInt(990),
+			BasicRate:           decimal.NewFromFloat(0.30),
+			ExtendedCeiling:     decimal.NewFromInt(1270),
+			ExtendedRate:        decimal.NewFromFloat(0.25),
+			AnticipatoryTaxRate: decimal.NewFromFloat(0.08),
+			FictitiousRate:      decimal.NewFromFloat(0.0475),
+		}}, nil
+	}
+
+	return taxParametersRepo
+}
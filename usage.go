@@ -0,0 +1,276 @@
+package pensiondata
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Default parameters of the usage recorder and of the usage report
+const (
+	DefaultUsageFlushInterval = 30 * time.Second
+	DefaultUsageBufferSize    = 10000
+	DefaultUsageDays          = 7
+)
+
+// maxUsageFlushAttempts is the number of flushes trying to write the same buckets before they are dropped
+const maxUsageFlushAttempts = 3
+
+// UsageRecord is a request served by the API.
+// Key is the prefix of the API key, empty for anonymous requests, and Route the template of the route such as /funds/:isin.
+type UsageRecord struct {
+	Key     string
+	Route   string
+	Isin    string
+	Status  int
+	Latency time.Duration
+	At      time.Time
+}
+
+// UsageBucket is the aggregation of the requests with the same key, route, ISIN and status during an hour
+type UsageBucket struct {
+	Hour         time.Time
+	Key          string
+	Route        string
+	Isin         string
+	Status       int
+	Requests     int64
+	TotalLatency time.Duration
+	MaxLatency   time.Duration
+}
+
+// UsageCriteria are the filters of the usage, an empty Key matches every key
+type UsageCriteria struct {
+	Key  string
+	From time.Time
+	To   time.Time
+}
+
+// UsageRepository handle the data access operations on UsageBucket.
+// AddBuckets adds the requests of the buckets to the stored buckets of the same hour, key, route, ISIN and status.
+type UsageRepository interface {
+	AddBuckets(context.Context, []UsageBucket) error
+	FindByCriteria(context.Context, UsageCriteria) ([]UsageBucket, error)
+}
+
+// UsageRecorder records the requests served by the API, it must not slow down the requests
+type UsageRecorder interface {
+	Record(UsageRecord)
+}
+
+// usageBucketKey identifies the bucket of a request
+type usageBucketKey struct {
+	hour   time.Time
+	key    string
+	route  string
+	isin   string
+	status int
+}
+
+// BatchUsageRecorder is the implementation of UsageRecorder aggregating the records in memory,
+// the buckets are written to the repository every flush interval in a single batch.
+// Records are dropped rather than blocking the requests when the buffer is full.
+type BatchUsageRecorder struct {
+	repo     UsageRepository
+	records  chan UsageRecord
+	interval time.Duration
+	dropped  int64
+	mu       sync.Mutex
+	done     chan struct{}
+	stopped  chan struct{}
+}
+
+// NewUsageRecorder return a new BatchUsageRecorder, already started, flushing to the repository every interval
+func NewUsageRecorder(repo UsageRepository, interval time.Duration, bufferSize int) *BatchUsageRecorder {
+	r := &BatchUsageRecorder{
+		repo:     repo,
+		records:  make(chan UsageRecord, bufferSize),
+		interval: interval,
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go r.run()
+
+	return r
+}
+
+// Record queue the record to be aggregated without waiting
+func (r *BatchUsageRecorder) Record(record UsageRecord) {
+	select {
+	case r.records <- record:
+	default:
+		r.mu.Lock()
+		r.dropped++
+		r.mu.Unlock()
+	}
+}
+
+// Close stop the recorder after writing the records already queued
+func (r *BatchUsageRecorder) Close() {
+	close(r.done)
+	<-r.stopped
+}
+
+// run aggregate the queued records and flush them every interval, until the recorder is closed.
+// The buckets of a failed flush are kept and retried with the next one, up to maxUsageFlushAttempts times.
+func (r *BatchUsageRecorder) run() {
+	defer close(r.stopped)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	buckets := map[usageBucketKey]*UsageBucket{}
+	attempts := 0
+	for {
+		select {
+		case record := <-r.records:
+			aggregateUsage(buckets, record)
+		case <-ticker.C:
+			attempts++
+			if err := r.flush(buckets); err != nil && attempts < maxUsageFlushAttempts {
+				log.Printf("Error while recording usage of %d buckets, retrying with the next flush: %s", len(buckets), err)
+				continue
+			} else if err != nil {
+				log.Printf("Error while recording usage of %d buckets, dropping them: %s", len(buckets), err)
+			}
+			attempts = 0
+			buckets = map[usageBucketKey]*UsageBucket{}
+		case <-r.done:
+			for len(r.records) > 0 {
+				aggregateUsage(buckets, <-r.records)
+			}
+			if err := r.flush(buckets); err != nil {
+				log.Printf("Error while recording usage of %d buckets: %s", len(buckets), err)
+			}
+			return
+		}
+	}
+}
+
+// flush write the buckets to the repository
+func (r *BatchUsageRecorder) flush(buckets map[usageBucketKey]*UsageBucket) error {
+	r.mu.Lock()
+	dropped := r.dropped
+	r.dropped = 0
+	r.mu.Unlock()
+
+	if dropped > 0 {
+		log.Printf("Usage buffer full, %d requests were not recorded", dropped)
+	}
+
+	if len(buckets) == 0 {
+		return nil
+	}
+
+	batch := make([]UsageBucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		batch = append(batch, *bucket)
+	}
+
+	// the buckets aggregate many requests, they are written outside of the context of any of them
+	return r.repo.AddBuckets(context.Background(), batch)
+}
+
+// aggregateUsage add the record to its hourly bucket
+func aggregateUsage(buckets map[usageBucketKey]*UsageBucket, record UsageRecord) {
+	key := usageBucketKey{
+		hour:   record.At.UTC().Truncate(time.Hour),
+		key:    record.Key,
+		route:  record.Route,
+		isin:   record.Isin,
+		status: record.Status,
+	}
+
+	bucket, ok := buckets[key]
+	if !ok {
+		bucket = &UsageBucket{Hour: key.hour, Key: key.key, Route: key.route, Isin: key.isin, Status: key.status}
+		buckets[key] = bucket
+	}
+
+	bucket.Requests++
+	bucket.TotalLatency += record.Latency
+	if record.Latency > bucket.MaxLatency {
+		bucket.MaxLatency = record.Latency
+	}
+}
+
+// UsageService handle the use cases for the usage of the API
+type UsageService interface {
+	GetUsage(context.Context, UsageCriteria) ([]PublicUsage, error)
+}
+
+// UsageServiceImpl is the implementation of UsageService
+type UsageServiceImpl struct {
+	repo UsageRepository
+	now  func() time.Time
+}
+
+// NewUsageService return a new, fully functional, implementation of UsageService
+func NewUsageService(repo UsageRepository) *UsageServiceImpl {
+	return &UsageServiceImpl{repo: repo, now: time.Now}
+}
+
+// GetUsage return the hourly usage matching the criteria, since DefaultUsageDays by default
+func (s UsageServiceImpl) GetUsage(ctx context.Context, criteria UsageCriteria) ([]PublicUsage, error) {
+	if criteria.From.IsZero() {
+		criteria.From = truncateToDay(s.now().UTC()).AddDate(0, 0, -DefaultUsageDays)
+	}
+
+	if !criteria.To.IsZero() && criteria.To.Before(criteria.From) {
+		return []PublicUsage{}, ErrInvalidCriteria
+	}
+
+	buckets, err := s.repo.FindByCriteria(ctx, criteria)
+	if err != nil {
+		return []PublicUsage{}, err
+	}
+
+	sort.SliceStable(buckets, func(i, j int) bool {
+		return buckets[i].Hour.Before(buckets[j].Hour)
+	})
+
+	publicUsage := []PublicUsage{}
+	for _, bucket := range buckets {
+		publicUsage = append(publicUsage, newPublicUsage(bucket))
+	}
+
+	return publicUsage, nil
+}
+
+// PublicUsage is the hourly usage to be returned by the API, latencies are in milliseconds
+type PublicUsage struct {
+	Hour           string  `json:"hour"`
+	Key            string  `json:"key,omitempty"`
+	Route          string  `json:"route"`
+	Isin           string  `json:"isin,omitempty"`
+	Status         int     `json:"status"`
+	Requests       int64   `json:"requests"`
+	AverageLatency float64 `json:"average_latency_ms"`
+	MaxLatency     float64 `json:"max_latency_ms"`
+}
+
+// newPublicUsage return a PublicUsage based on a UsageBucket
+func newPublicUsage(bucket UsageBucket) PublicUsage {
+	publicUsage := PublicUsage{
+		Hour:       bucket.Hour.UTC().Format(time.RFC3339),
+		Key:        bucket.Key,
+		Route:      bucket.Route,
+		Isin:       bucket.Isin,
+		Status:     bucket.Status,
+		Requests:   bucket.Requests,
+		MaxLatency: milliseconds(bucket.MaxLatency),
+	}
+
+	if bucket.Requests > 0 {
+		publicUsage.AverageLatency = milliseconds(bucket.TotalLatency / time.Duration(bucket.Requests))
+	}
+
+	return publicUsage
+}
+
+// milliseconds return the duration in milliseconds, with a microsecond precision
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,39 @@
+package pensiondata
+
+import "context"
+
+// UsageRepositoryMock used for tests
+type UsageRepositoryMock struct {
+	AddBucketsFn     func(context.Context, []UsageBucket) error
+	FindByCriteriaFn func(context.Context, UsageCriteria) ([]UsageBucket, error)
+}
+
+// UsageRecorderMock used for tests
+type UsageRecorderMock struct {
+	RecordFn func(UsageRecord)
+}
+
+// UsageServiceMock used for tests
+type UsageServiceMock struct {
+	GetUsageFn func(context.Context, UsageCriteria) ([]PublicUsage, error)
+}
+
+// AddBuckets mock
+func (r UsageRepositoryMock) AddBuckets(ctx context.Context, buckets []UsageBucket) error {
+	return r.AddBucketsFn(ctx, buckets)
+}
+
+// FindByCriteria mock
+func (r UsageRepositoryMock) FindByCriteria(ctx context.Context, criteria UsageCriteria) ([]UsageBucket, error) {
+	return r.FindByCriteriaFn(ctx, criteria)
+}
+
+// Record mock
+func (r UsageRecorderMock) Record(record UsageRecord) {
+	r.RecordFn(record)
+}
+
+// GetUsage mock
+func (s UsageServiceMock) GetUsage(ctx context.Context, criteria UsageCriteria) ([]PublicUsage, error) {
+	return s.GetUsageFn(ctx, criteria)
+}
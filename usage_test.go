@@ -0,0 +1,126 @@
+package pensiondata
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBatchUsageRecorder(t *testing.T) {
+	t.Run("aggregate records in hourly buckets", func(t *testing.T) {
+		var got []UsageBucket
+		repo := UsageRepositoryMock{}
+		repo.AddBucketsFn = func(ctx context.Context, buckets []UsageBucket) error {
+			got = append(got, buckets...)
+			return nil
+		}
+
+		at := time.Date(2020, time.July, 15, 9, 10, 0, 0, time.UTC)
+		r := NewUsageRecorder(repo, time.Hour, 10)
+		r.Record(UsageRecord{Key: "0a1b2c3d", Route: "/funds/:isin/quotes", Isin: "BE123", Status: 200, Latency: 2 * time.Millisecond, At: at})
+		r.Record(UsageRecord{Key: "0a1b2c3d", Route: "/funds/:isin/quotes", Isin: "BE123", Status: 200, Latency: 4 * time.Millisecond, At: at.Add(time.Minute)})
+		r.Close()
+
+		want := []UsageBucket{{
+			Hour:         time.Date(2020, time.July, 15, 9, 0, 0, 0, time.UTC),
+			Key:          "0a1b2c3d",
+			Route:        "/funds/:isin/quotes",
+			Isin:         "BE123",
+			Status:       200,
+			Requests:     2,
+			TotalLatency: 6 * time.Millisecond,
+			MaxLatency:   4 * time.Millisecond,
+		}}
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("retry buckets with the next flush when writing fails", func(t *testing.T) {
+		written := make(chan []UsageBucket, 1)
+		calls := 0
+		repo := UsageRepositoryMock{}
+		repo.AddBucketsFn = func(ctx context.Context, buckets []UsageBucket) error {
+			calls++
+			if calls == 1 {
+				return errors.New("internal error")
+			}
+			written <- buckets
+			return nil
+		}
+
+		r := NewUsageRecorder(repo, time.Millisecond, 10)
+		r.Record(UsageRecord{Key: "0a1b2c3d", Route: "/funds", Status: 200, At: time.Now()})
+
+		select {
+		case got := <-written:
+			if len(got) != 1 || got[0].Requests != 1 {
+				t.Errorf("want the failed bucket written again, got %v", got)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("want the failed bucket written again")
+		}
+		r.Close()
+	})
+
+	t.Run("drop records when the buffer is full", func(t *testing.T) {
+		r := &BatchUsageRecorder{records: make(chan UsageRecord, 1)}
+		r.Record(UsageRecord{})
+		r.Record(UsageRecord{})
+
+		if len(r.records) != 1 || r.dropped != 1 {
+			t.Errorf("want 1 record queued and 1 dropped, got %d and %d", len(r.records), r.dropped)
+		}
+	})
+}
+
+func TestGetUsage(t *testing.T) {
+	t.Run("return usage since the default days", func(t *testing.T) {
+		var gotCriteria UsageCriteria
+		repo := UsageRepositoryMock{}
+		repo.FindByCriteriaFn = func(ctx context.Context, criteria UsageCriteria) ([]UsageBucket, error) {
+			gotCriteria = criteria
+			return []UsageBucket{{
+				Hour: time.Date(2020, time.July, 15, 9, 0, 0, 0, time.UTC), Route: "/funds", Status: 200,
+				Requests: 4, TotalLatency: 10 * time.Millisecond, MaxLatency: 5 * time.Millisecond,
+			}}, nil
+		}
+
+		s := NewUsageService(repo)
+		s.now = func() time.Time {
+			return time.Date(2020, time.July, 15, 9, 30, 0, 0, time.UTC)
+		}
+
+		got, err := s.GetUsage(context.Background(), UsageCriteria{})
+
+		want := []PublicUsage{{Hour: "2020-07-15T09:00:00Z", Route: "/funds", Status: 200, Requests: 4, AverageLatency: 2.5, MaxLatency: 5}}
+		if err != nil || !reflect.DeepEqual(want, got) {
+			t.Errorf("want %v, got %v (%v)", want, got, err)
+		}
+		if wantFrom := time.Date(2020, time.July, 8, 0, 0, 0, 0, time.UTC); !gotCriteria.From.Equal(wantFrom) {
+			t.Errorf("want from %s, got %s", wantFrom, gotCriteria.From)
+		}
+	})
+
+	t.Run("return error for to before from", func(t *testing.T) {
+		from := time.Date(2020, time.July, 15, 0, 0, 0, 0, time.UTC)
+		_, err := NewUsageService(UsageRepositoryMock{}).GetUsage(context.Background(), UsageCriteria{From: from, To: from.AddDate(0, 0, -1)})
+
+		if err != ErrInvalidCriteria {
+			t.Errorf("want %s, got %v", ErrInvalidCriteria, err)
+		}
+	})
+
+	t.Run("return error for usage", func(t *testing.T) {
+		repo := UsageRepositoryMock{}
+		repo.FindByCriteriaFn = func(ctx context.Context, criteria UsageCriteria) ([]UsageBucket, error) {
+			return nil, errors.New("error")
+		}
+
+		if _, err := NewUsageService(repo).GetUsage(context.Background(), UsageCriteria{}); err == nil {
+			t.Errorf("want error")
+		}
+	})
+}
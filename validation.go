@@ -0,0 +1,134 @@
+package pensiondata
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Bounds of the precision of a price: at most MaxPriceDecimals decimals and MaxPriceDigits digits including them,
+// so the prices returned as JSON numbers stay exact as float64
+const (
+	MaxPriceDecimals = 6
+	MaxPriceDigits   = 15
+)
+
+// FieldError is the error of an invalid field of an input, such as a path parameter or a property of a body
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned when fields of an input are invalid.
+// Err is the domain error it wraps, such as ErrInvalidQuote or ErrInvalidInput, Fields the errors of each field.
+type ValidationError struct {
+	Err    error
+	Fields []FieldError
+}
+
+// Error return the wrapped error followed by the messages of the fields
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Err, e.messages())
+}
+
+// messages return the messages of the errors of the fields, separated by commas
+func (e ValidationError) messages() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Message
+	}
+
+	return strings.Join(messages, ", ")
+}
+
+// Unwrap return the domain error wrapped by the ValidationError
+func (e ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// validator collects the errors of the fields of an input
+type validator struct {
+	fields []FieldError
+}
+
+// check add an error on the field with the formatted message when ok is false
+func (v *validator) check(ok bool, field, format string, args ...interface{}) {
+	if !ok {
+		v.fields = append(v.fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+// error return a ValidationError wrapping err when a field is invalid, nil otherwise
+func (v *validator) error(err error) error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+
+	return ValidationError{Err: err, Fields: v.fields}
+}
+
+// ValidateISIN return a ValidationError wrapping ErrInvalidInput when the isin is not an ISIN:
+// a two letters country code, nine alphanumeric characters and a Luhn check digit
+func ValidateISIN(isin string) error {
+	var v validator
+	v.check(isValidISIN(isin), "isin", "the isin %s must be 12 characters with a country code and a valid check digit", isin)
+
+	return v.error(ErrInvalidInput)
+}
+
+// ValidateDate return a ValidationError wrapping ErrInvalidInput when the date of the field is not an ISO 8601 day (YYYY-MM-DD)
+func ValidateDate(field, date string) error {
+	_, err := time.Parse("2006-01-02", date)
+
+	var v validator
+	v.check(err == nil, field, "the %s %s must be a valid day using the YYYY-MM-DD format", field, date)
+
+	return v.error(ErrInvalidInput)
+}
+
+// isValidISIN return true when the isin is made of a country code, nine alphanumeric characters and a check digit
+// computed with the Luhn algorithm on the digits of the isin, letters counting as two digits (A is 10, Z is 35)
+func isValidISIN(isin string) bool {
+	if len(isin) != 12 {
+		return false
+	}
+
+	var digits []int
+	for i, c := range isin {
+		switch {
+		case c >= 'A' && c <= 'Z' && i < 11:
+			value := int(c-'A') + 10
+			digits = append(digits, value/10, value%10)
+		case c >= '0' && c <= '9' && i >= 2:
+			digits = append(digits, int(c-'0'))
+		default:
+			return false
+		}
+	}
+
+	sum := 0
+	for i := range digits {
+		digit := digits[len(digits)-1-i]
+		if i%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+
+	return sum%10 == 0
+}
+
+// isValidPrice return true when the price is positive and within the bounds of precision
+func isValidPrice(price decimal.Decimal) bool {
+	rounded := price.Round(MaxPriceDecimals)
+	if !price.IsPositive() || !price.Equal(rounded) {
+		return false
+	}
+
+	return len(rounded.Coefficient().String()) <= MaxPriceDigits
+}
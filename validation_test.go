@@ -0,0 +1,143 @@
+package pensiondata
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestValidateISIN(t *testing.T) {
+	tests := []struct {
+		name  string
+		isin  string
+		valid bool
+	}{
+		{name: "accept valid isin", isin: "BE0948502365", valid: true},
+		{name: "accept isin of another country", isin: "US0378331005", valid: true},
+		{name: "accept isin with letters in the national code", isin: "GB00B03MLX29", valid: true},
+		{name: "reject wrong check digit", isin: "BE0948502366"},
+		{name: "reject too short isin", isin: "BE123"},
+		{name: "reject lower case isin", isin: "be0948502365"},
+		{name: "reject numeric country code", isin: "120948502365"},
+		{name: "reject letter check digit", isin: "BE094850236A"},
+		{name: "reject empty isin", isin: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateISIN(tt.isin)
+
+			if tt.valid && err != nil {
+				t.Errorf("want %s valid, got %s", tt.isin, err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidInput) {
+				t.Errorf("want %v, got %v", ErrInvalidInput, err)
+			}
+		})
+	}
+
+	t.Run("return error of isin field", func(t *testing.T) {
+		var validationErr ValidationError
+		if !errors.As(ValidateISIN("BE123"), &validationErr) {
+			t.Fatalf("want ValidationError")
+		}
+
+		if len(validationErr.Fields) != 1 || validationErr.Fields[0].Field != "isin" {
+			t.Errorf("want error of isin field, got %v", validationErr.Fields)
+		}
+	})
+}
+
+func TestValidateDate(t *testing.T) {
+	tests := []struct {
+		date  string
+		valid bool
+	}{
+		{date: "2020-06-30", valid: true},
+		{date: "2020-02-29", valid: true},
+		{date: "2021-02-29"},
+		{date: "2020-6-30"},
+		{date: "30-06-2020"},
+		{date: "2020-06-30T00:00:00Z"},
+		{date: "2020-06-30' OR '1'='1"},
+		{date: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			err := ValidateDate("date", tt.date)
+
+			if tt.valid && err != nil {
+				t.Errorf("want %s valid, got %s", tt.date, err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidInput) {
+				t.Errorf("want %v, got %v", ErrInvalidInput, err)
+			}
+		})
+	}
+}
+
+func TestScraperCreateQuoteValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		quote ScraperCreateQuote
+		want  []string
+	}{
+		{
+			name:  "accept valid quote",
+			quote: ScraperCreateQuote{Date: "2020-06-30T00:00:00+02:00", Price: decimal.RequireFromString("7.123456")},
+		},
+		{
+			name:  "accept trailing zeros beyond the decimals",
+			quote: ScraperCreateQuote{Date: "2020-06-30T00:00:00Z", Price: decimal.RequireFromString("7.12000000")},
+		},
+		{
+			name:  "reject date without time",
+			quote: ScraperCreateQuote{Date: "2020-06-30", Price: decimal.RequireFromString("7.99")},
+			want:  []string{"date"},
+		},
+		{
+			name:  "reject price that is not positive",
+			quote: ScraperCreateQuote{Date: "2020-06-30T00:00:00Z", Price: decimal.Zero},
+			want:  []string{"price"},
+		},
+		{
+			name:  "reject too many decimals",
+			quote: ScraperCreateQuote{Date: "2020-06-30T00:00:00Z", Price: decimal.RequireFromString("7.1234567")},
+			want:  []string{"price"},
+		},
+		{
+			name:  "reject too many digits",
+			quote: ScraperCreateQuote{Date: "2020-06-30T00:00:00Z", Price: decimal.RequireFromString("1234567890.5")},
+			want:  []string{"price"},
+		},
+		{
+			name:  "return every invalid field",
+			quote: ScraperCreateQuote{Date: "yesterday", Price: decimal.RequireFromString("-1")},
+			want:  []string{"date", "price"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.quote.validate()
+
+			var got []string
+			var validationErr ValidationError
+			if errors.As(err, &validationErr) {
+				for _, field := range validationErr.Fields {
+					got = append(got, field.Field)
+				}
+			}
+
+			if !reflect.DeepEqual(tt.want, got) {
+				t.Errorf("want invalid fields %v, got %v", tt.want, got)
+			}
+			if tt.want != nil && !errors.Is(err, ErrInvalidQuote) {
+				t.Errorf("want %v, got %v", ErrInvalidQuote, err)
+			}
+		})
+	}
+}